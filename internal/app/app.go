@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"video-transcript/internal/handler"
+	"video-transcript/internal/helper"
 	"video-transcript/internal/middleware"
 	"video-transcript/internal/repository"
 	"video-transcript/internal/service"
@@ -23,18 +24,40 @@ func NewApp(db *sql.DB) *App {
 	userRepo := repository.NewUserRepository(db)
 	videoRepo := repository.NewVideoRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
+	vocabularyRepo := repository.NewVocabularyRepository(db)
+	providerKeyRepo := repository.NewProviderKeyRepository(db)
+	videoArtifactRepo := repository.NewVideoArtifactRepository(db)
+	speechMapRepo := repository.NewSpeechMapRepository(db)
+	lexiconRepo := repository.NewLexiconRepository(db)
+	ttsCacheRepo := repository.NewTTSCacheRepository(db)
 
 	// init services
 	userSvc := service.NewUserService(userRepo)
-	videoSvc := service.NewVideoService(videoRepo)
+	videoSvc := service.NewVideoService(videoRepo, videoArtifactRepo)
 	taskSvc := service.NewTaskService(taskRepo)
+	vocabularySvc := service.NewVocabularyService(vocabularyRepo)
+	providerKeySvc := service.NewProviderKeyService(providerKeyRepo)
+	mediaPipeline := service.NewMediaPipeline(videoRepo, videoArtifactRepo, speechMapRepo)
+	transcriptionSvc := service.NewTranscriptionService(taskSvc, mediaPipeline)
+	transcodeSvc := service.NewTranscodeService(videoRepo, taskSvc, mediaPipeline)
+	renderSvc := service.NewRenderService(videoRepo, taskSvc, mediaPipeline)
+	lexiconSvc := service.NewLexiconService(lexiconRepo)
+	ttsSvc := service.NewTTSService(taskSvc, videoSvc, lexiconSvc, mediaPipeline, ttsCacheRepo)
+
+	// Speech provider lấy API key từ pool trong DB (fallback về DEEPGRAM_API_KEY khi pool trống).
+	helper.SetKeySource(providerKeySvc)
 
 	// init handlers
 	userHandler := handler.NewUserHandler(userSvc, videoSvc)
 	authHandler := handler.NewAuthHandler(userSvc)
-	uploadHandler := handler.NewUploadHandler(videoSvc)
-	deepgramHandler := handler.NewDeepgramHandler(videoSvc, taskSvc)
+	uploadHandler := handler.NewUploadHandler(videoSvc, mediaPipeline)
+	deepgramHandler := handler.NewDeepgramHandler(videoSvc, taskSvc, userSvc, vocabularySvc, transcriptionSvc, ttsSvc)
 	taskHandler := handler.NewTaskHandler(taskSvc)
+	vocabularyHandler := handler.NewVocabularyHandler(vocabularySvc)
+	providerKeyHandler := handler.NewProviderKeyHandler(providerKeySvc)
+	videoHandler := handler.NewVideoHandler(videoSvc, taskSvc, mediaPipeline, transcodeSvc, renderSvc)
+	ttsHandler := handler.NewTTSHandler(ttsSvc, taskSvc, videoSvc, renderSvc)
+	lexiconHandler := handler.NewLexiconHandler(lexiconSvc)
 
 	r := gin.Default()
 
@@ -54,6 +77,13 @@ func NewApp(db *sql.DB) *App {
 		})
 	})
 
+	// Trạng thái circuit breaker của các speech provider (chỉ state và bộ đếm, không kèm lỗi)
+	r.GET("/health/providers", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"providers": helper.ProvidersHealth(),
+		})
+	})
+
 	// Auth routes (public)
 	authHandler.RegisterRoutes(router)
 
@@ -64,6 +94,11 @@ func NewApp(db *sql.DB) *App {
 
 	deepgramHandler.RegisterRoutes(router, middleware.JWTAuth())
 	taskHandler.RegisterRoutes(router, middleware.JWTAuth())
+	vocabularyHandler.RegisterRoutes(router, middleware.JWTAuth())
+	providerKeyHandler.RegisterRoutes(router, middleware.JWTAuth())
+	videoHandler.RegisterRoutes(router, middleware.JWTAuth())
+	ttsHandler.RegisterRoutes(router, middleware.JWTAuth())
+	lexiconHandler.RegisterRoutes(router, middleware.JWTAuth())
 
 	return &App{
 		Engine:      r,
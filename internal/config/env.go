@@ -29,6 +29,63 @@ type svcConfig struct {
 
 	// Deepgram
 	DeepgramAPIKey string `env:"DEEPGRAM_API_KEY" envDefault:""`
+
+	// Pool API key của provider lưu trong DB (bảng provider_keys), mã hoá bằng secret này.
+	// DEEPGRAM_API_KEY chỉ còn dùng làm fallback khi pool chưa có key nào.
+	ProviderKeySecret         string `env:"PROVIDER_KEY_SECRET" envDefault:""`
+	ProviderKeyCooldownSecond int    `env:"PROVIDER_KEY_COOLDOWN_SECONDS" envDefault:"60"` // thời gian tạm bỏ qua key bị 429
+	ProviderKeyReloadSecond   int    `env:"PROVIDER_KEY_RELOAD_SECONDS" envDefault:"30"`   // chu kỳ đọc lại pool từ DB
+
+	// Secondary speech provider (Deepgram-compatible endpoint: self-hosted hoặc region khác).
+	// Để trống SPEECH_SECONDARY_HOST nếu không dùng failover. Host / key của từng provider luôn lấy theo
+	// config ở đây: biến môi trường DEEPGRAM_HOST, DEEPGRAM_ACCESS_TOKEN, DEEPGRAM_API_VERSION, DEEPGRAM_API_PATH
+	// mà Deepgram SDK tự đọc bị bỏ qua (nếu không provider chính và phụ sẽ cùng gọi 1 endpoint).
+	SpeechSecondaryHost   string `env:"SPEECH_SECONDARY_HOST" envDefault:""`
+	SpeechSecondaryAPIKey string `env:"SPEECH_SECONDARY_API_KEY" envDefault:""`
+
+	// Media pipeline (ffmpeg) chạy trước khi gửi audio cho STT
+	MediaPipelineEnabled  bool   `env:"MEDIA_PIPELINE_ENABLED" envDefault:"true"`  // false = không chạy ffmpeg / ffprobe, gửi thẳng URL video cho provider như cũ
+	MediaWorkDir          string `env:"MEDIA_WORK_DIR" envDefault:""`              // thư mục tạm cho ffmpeg, rỗng = os.TempDir()
+	MediaJobTimeoutMinute int    `env:"MEDIA_JOB_TIMEOUT_MINUTES" envDefault:"60"` // thời gian tối đa của 1 job (tải + ffmpeg + STT)
+	MediaAssetWorkers     int    `env:"MEDIA_ASSET_WORKERS" envDefault:"2"`        // số video sinh waveform / poster / sprite đồng thời
+
+	// Render video dẫn xuất (burn-in phụ đề, cắt ghép): encode lại cả video nên cần timeout riêng
+	RenderJobTimeoutMinute int `env:"RENDER_JOB_TIMEOUT_MINUTES" envDefault:"120"`
+
+	// Thư mục font cho filter ass khi burn-in phụ đề, rỗng = dùng font hệ thống (fontconfig)
+	CaptionFontsDir string `env:"CAPTION_FONTS_DIR" envDefault:""`
+
+	// HLS transcode cho web player (encode nhiều rendition, lâu hơn job STT nhiều)
+	HLSJobTimeoutMinute int `env:"HLS_JOB_TIMEOUT_MINUTES" envDefault:"240"`
+
+	// Text-to-speech: text dài được chia chunk theo câu / đoạn văn, tổng hợp song song rồi nối lại
+	TTSJobTimeoutMinute int `env:"TTS_JOB_TIMEOUT_MINUTES" envDefault:"10"` // thời gian tối đa của 1 job (gọi provider + nối + upload)
+	TTSChunkChars       int `env:"TTS_CHUNK_CHARS" envDefault:"1800"`       // số ký tự tối đa 1 request speak (Deepgram giới hạn 2000)
+	TTSChunkWorkers     int `env:"TTS_CHUNK_WORKERS" envDefault:"4"`        // số chunk tổng hợp đồng thời
+	TTSChunkRetries     int `env:"TTS_CHUNK_RETRIES" envDefault:"2"`        // số lần thử lại 1 chunk lỗi
+	TTSMaxTextChars     int `env:"TTS_MAX_TEXT_CHARS" envDefault:"100000"`  // độ dài text tối đa của 1 request
+
+	// Cache audio TTS theo hash nội dung: request trùng trả lại object R2 đã có, không gọi provider
+	TTSCacheEnabled      bool   `env:"TTS_CACHE_ENABLED" envDefault:"true"`
+	TTSCacheDefaultScope string `env:"TTS_CACHE_DEFAULT_SCOPE" envDefault:"user"` // user / shared / none, khi request không chọn
+
+	// Audiobook từ tài liệu (txt / md / html / epub): TTS theo từng chương + ghép M4B, lâu hơn job TTS thường nhiều
+	AudiobookJobTimeoutMinute int `env:"AUDIOBOOK_JOB_TIMEOUT_MINUTES" envDefault:"240"`
+	AudiobookMaxFileMB        int `env:"AUDIOBOOK_MAX_FILE_MB" envDefault:"50"`    // dung lượng tối đa file tài liệu upload
+	AudiobookMaxChars         int `env:"AUDIOBOOK_MAX_CHARS" envDefault:"1000000"` // tổng số ký tự tối đa sau khi tách text
+
+	// STT theo chunk cho file dài: cắt tại khoảng lặng, transcribe song song rồi ghép lại
+	STTChunkMinMinutes     int `env:"STT_CHUNK_MIN_MINUTES" envDefault:"30"`    // file dài hơn ngưỡng này mới cắt chunk
+	STTChunkSeconds        int `env:"STT_CHUNK_SECONDS" envDefault:"600"`       // độ dài mục tiêu của 1 chunk
+	STTChunkOverlapSeconds int `env:"STT_CHUNK_OVERLAP_SECONDS" envDefault:"5"` // phần chồng lấn giữa 2 chunk liền nhau
+	STTChunkWorkers        int `env:"STT_CHUNK_WORKERS" envDefault:"4"`         // số chunk transcribe đồng thời
+
+	// Circuit breaker cho speech provider
+	BreakerWindow         int     `env:"BREAKER_WINDOW" envDefault:"20"`          // số request gần nhất dùng để tính error rate
+	BreakerMinRequests    int     `env:"BREAKER_MIN_REQUESTS" envDefault:"5"`     // số request tối thiểu trước khi được phép mở mạch
+	BreakerErrorRate      float64 `env:"BREAKER_ERROR_RATE" envDefault:"0.5"`     // error rate để mở mạch
+	BreakerOpenSeconds    int     `env:"BREAKER_OPEN_SECONDS" envDefault:"30"`    // thời gian mở mạch trước khi thử half-open
+	BreakerHalfOpenProbes int     `env:"BREAKER_HALF_OPEN_PROBES" envDefault:"1"` // số request thử đồng thời khi half-open
 }
 
 func init() {
@@ -1,11 +1,12 @@
 package handler
 
 import (
-	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"video-transcript/internal/helper"
+	"strings"
+	"unicode/utf8"
+	"video-transcript/internal/config"
 	"video-transcript/internal/middleware"
 	"video-transcript/internal/model"
 	"video-transcript/internal/service"
@@ -15,12 +16,16 @@ import (
 )
 
 type DeepgramHandler struct {
-	videoSvc service.VideoService
-	taskSvc  service.TaskService
+	videoSvc         service.VideoService
+	taskSvc          service.TaskService
+	userSvc          service.UserService
+	vocabularySvc    service.VocabularyService
+	transcriptionSvc service.TranscriptionService
+	ttsSvc           service.TTSService
 }
 
-func NewDeepgramHandler(videoSvc service.VideoService, taskSvc service.TaskService) *DeepgramHandler {
-	return &DeepgramHandler{videoSvc: videoSvc, taskSvc: taskSvc}
+func NewDeepgramHandler(videoSvc service.VideoService, taskSvc service.TaskService, userSvc service.UserService, vocabularySvc service.VocabularyService, transcriptionSvc service.TranscriptionService, ttsSvc service.TTSService) *DeepgramHandler {
+	return &DeepgramHandler{videoSvc: videoSvc, taskSvc: taskSvc, userSvc: userSvc, vocabularySvc: vocabularySvc, transcriptionSvc: transcriptionSvc, ttsSvc: ttsSvc}
 }
 
 func (h *DeepgramHandler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
@@ -36,60 +41,42 @@ func (h *DeepgramHandler) DeepgramTTS(c *gin.Context) {
 		return
 	}
 
-	userID := currentUser.ID
-	ctx := context.Background()
 	var in struct {
-		Text string `json:"text"`
+		Text    string            `json:"text"`
+		Options *model.TTSOptions `json:"options"` // voice, encoding, sample_rate, bit_rate, sentence_gap_ms, paragraph_gap_ms, markup, skip_lexicon, cache, cache_refresh, timings, tempo, pitch_semitones, loudness, trim_silence
 	}
 	if err := c.ShouldBindJSON(&in); err != nil {
 		zap.S().Errorw("should bind json failed", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// create task
-	task := &model.Task{
-		TaskType:  model.TaskTypeTTS,
-		Status:    model.TaskStatusPending,
-		InputText: &in.Text,
-		UserID:    &userID,
+	if strings.TrimSpace(in.Text) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text is required"})
+		return
 	}
-	if err := h.taskSvc.Create(ctx, task); err != nil {
-		zap.S().Errorw("create task failed", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if n := utf8.RuneCountInString(in.Text); n > config.SvcCfg.TTSMaxTextChars {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("text is too long (%d characters, max %d)", n, config.SvcCfg.TTSMaxTextChars)})
+		return
+	}
+	if err := in.Options.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	go func() {
-		url, err := helper.DeepgramTTS(ctx, fmt.Sprintf("%d", userID), in.Text)
-		if err != nil {
-			zap.S().Errorw("deepgram tts failed", "error", err)
-			errorMessage := err.Error()
-			err := h.taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusFailed, nil, nil, &errorMessage)
-			if err != nil {
-				zap.S().Errorw("update task status failed", "id", task.ID, "error", err)
-				return
-			}
-			return
-		}
-		uploadVideo := &model.Video{
-			UserID:      userID,
-			LinkVideo:   url,
-			NameFile:    "deepgram-tts.mp3",
-			Description: &in.Text,
-		}
-		if err := h.videoSvc.Create(ctx, uploadVideo); err != nil {
-			zap.S().Errorw("create video failed", "user_id", userID, "file_url", url, "error", err)
-			errorMessage := err.Error()
-			_ = h.taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusFailed, nil, nil, &errorMessage)
+	task, err := h.ttsSvc.Start(c.Request.Context(), &service.TTSJob{
+		UserID:  currentUser.ID,
+		Text:    in.Text,
+		Options: in.Options,
+	})
+	if err != nil {
+		var markupErr *service.TTSMarkupError
+		if errors.As(err, &markupErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": markupErr.Error(), "line": markupErr.Line, "column": markupErr.Column})
 			return
 		}
-		err = h.taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusCompleted, &url, nil, nil)
-		if err != nil {
-			zap.S().Errorw("update task status failed", "id", task.ID, "error", err)
-			return
-		}
-	}()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": task})
 }
@@ -103,134 +90,108 @@ func (h *DeepgramHandler) DeepgramSTT(c *gin.Context) {
 	}
 
 	userID := currentUser.ID
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	var in struct {
-		FileURL  string `json:"file_url"`
-		Language string `json:"language"`
+		FileURL  string            `json:"file_url"`
+		VideoID  *int64            `json:"video_id"` // video đã upload, thay cho file_url
+		Language string            `json:"language"` // giữ để tương thích, ưu tiên options.language
+		Options  *model.STTOptions `json:"options"`
 	}
 	if err := c.ShouldBindJSON(&in); err != nil {
 		zap.S().Errorw("should bind json failed", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if in.FileURL == "" && in.VideoID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file_url or video_id is required"})
+		return
+	}
 
-	// create task
-	task := &model.Task{
-		TaskType: model.TaskTypeSTT,
-		Status:   model.TaskStatusPending,
-		InputURL: &in.FileURL,
-		UserID:   &userID,
+	requested := in.Options
+	if in.Language != "" && (requested == nil || requested.Language == "") {
+		requested = requested.Merge(&model.STTOptions{Language: in.Language})
 	}
-	if err := h.taskSvc.Create(ctx, task); err != nil {
-		zap.S().Errorw("create task failed", "error", err)
+	if err := requested.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := h.userSvc.ResolveSTTOptions(ctx, userID, requested)
+	if err != nil {
+		zap.S().Errorw("resolve stt options failed", "user_id", userID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	go func() {
-		res, err := helper.DeepgramSTTFromBytes(ctx, in.FileURL, "audio/mpeg", in.Language)
+	// Vocabulary: đưa term lên provider, và giữ lại để sửa lỗi local sau khi có transcript.
+	var vocabulary *model.Vocabulary
+	if opts.VocabularyID != nil {
+		vocabulary, err = h.vocabularySvc.GetForUser(ctx, *opts.VocabularyID, currentUser)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			errorMessage := err.Error()
-			err := h.taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusFailed, nil, nil, &errorMessage)
-			if err != nil {
-				zap.S().Errorw("update task status failed", "id", task.ID, "error", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
+			c.JSON(vocabularyErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
-
-		videos, err := h.videoSvc.GetVideoByUserIDAndURL(ctx, userID, in.FileURL)
-		if err != nil {
-			zap.S().Errorw("get video by user id and url failed", "user_id", userID, "file_url", in.FileURL, "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		if len(videos) == 0 {
-			uploadVideo := &model.Video{
-				UserID:      userID,
-				LinkVideo:   in.FileURL,
-				NameFile:    "",
-				Description: nil,
-			}
-			if err := h.videoSvc.Create(ctx, uploadVideo); err != nil {
-				zap.S().Errorw("create video failed", "user_id", userID, "file_url", in.FileURL, "error", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-		}
-
-		// Log Deepgram response structure for debugging
-		if res != nil && res.Results != nil {
-			utterancesCount := 0
-			if res.Results.Utterances != nil {
-				utterancesCount = len(res.Results.Utterances)
-			}
-			channelsCount := 0
-			if res.Results.Channels != nil {
-				channelsCount = len(res.Results.Channels)
-			}
-			zap.S().Infow("Deepgram response received",
-				"task_id", task.ID,
-				"utterances_count", utterancesCount,
-				"channels_count", channelsCount,
-			)
-		}
-
-		simpleTranscript, err := model.ConvertDeepgramToSimple(res)
-		if err != nil {
-			zap.S().Errorw("convert deepgram to simple transcript failed",
-				"error", err,
-				"task_id", task.ID,
-				"file_url", in.FileURL,
-			)
-			errorMessage := err.Error()
-			if updateErr := h.taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusFailed, nil, nil, &errorMessage); updateErr != nil {
-				zap.S().Errorw("update task status failed", "id", task.ID, "error", updateErr)
-			}
+		vocabulary.ApplyTo(opts)
+		if err := opts.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+	}
 
-		// Check if transcript is empty (no data available)
-		hasTranscript := simpleTranscript.TranscriptText != "" || len(simpleTranscript.Words) > 0 || len(simpleTranscript.Utterances) > 0
+	video, err := h.sourceVideo(c, currentUser, in.VideoID, in.FileURL)
+	if err != nil {
+		return
+	}
 
-		if !hasTranscript {
-			zap.S().Warnw("No transcript data available from Deepgram, marking task as completed with null transcript",
-				"task_id", task.ID,
-				"file_url", in.FileURL,
-			)
-		}
+	task, err := h.transcriptionSvc.Start(ctx, &service.STTJob{
+		UserID:     userID,
+		Video:      video,
+		Options:    opts,
+		Vocabulary: vocabulary,
+	})
+	if err != nil {
+		c.JSON(mediaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
 
-		// Marshal transcript (will be empty/null if no data)
-		var transcriptText *string
-		if simpleTranscript.TranscriptText != "" {
-			transcriptText = &simpleTranscript.TranscriptText
-		}
+	c.JSON(http.StatusOK, gin.H{"data": task})
+}
 
-		var transcriptJSON json.RawMessage
-		if hasTranscript {
-			jsonBytes, err := json.Marshal(simpleTranscript)
-			if err != nil {
-				zap.S().Errorw("marshal simple transcript failed", "error", err)
+// sourceVideo lấy video nguồn theo video_id (phải thuộc user) hoặc theo file_url (chưa có thì tạo; file
+// trên R2 phải thuộc user, link ngoài phải là URL public).
+// Lỗi đã được ghi ra response.
+func (h *DeepgramHandler) sourceVideo(c *gin.Context, user *model.User, videoID *int64, fileURL string) (*model.Video, error) {
+	ctx := c.Request.Context()
+	if videoID != nil {
+		video, err := h.videoSvc.GetByID(ctx, *videoID)
+		if err != nil {
+			if err.Error() == "video not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
 			}
-			transcriptJSON = jsonBytes
-		} else {
-			// Set to null JSON if no transcript data
-			transcriptJSON = nil
+			return nil, err
 		}
+		if video.UserID != user.ID && user.Role != "admin" {
+			err := errors.New("video does not belong to current user")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return nil, err
+		}
+		return video, nil
+	}
 
-		// Update task as completed (even if transcript is null/empty)
-		err = h.taskSvc.UpdateTranscript(ctx, task.ID, model.TaskStatusCompleted, transcriptText, transcriptJSON)
-		if err != nil {
-			zap.S().Errorw("update task transcript failed", "id", task.ID, "error", err)
+	video, err := h.videoSvc.GetOrCreateByURL(ctx, user.ID, fileURL)
+	if err != nil {
+		zap.S().Errorw("get or create video failed", "user_id", user.ID, "file_url", fileURL, "error", err)
+		switch {
+		case errors.Is(err, service.ErrSourceNotOwned):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUnsafeSourceURL):
+			c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrUnsafeSourceURL.Error()})
+		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
 		}
-	}()
-
-	c.JSON(http.StatusOK, gin.H{"data": task})
+		return nil, err
+	}
+	return video, nil
 }
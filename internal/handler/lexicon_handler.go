@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"video-transcript/internal/middleware"
+	"video-transcript/internal/model"
+	"video-transcript/internal/service"
+)
+
+// LexiconHandler exposes the per-user TTS pronunciation lexicon endpoints.
+type LexiconHandler struct {
+	svc service.LexiconService
+}
+
+// NewLexiconHandler creates a new LexiconHandler.
+func NewLexiconHandler(svc service.LexiconService) *LexiconHandler {
+	return &LexiconHandler{svc: svc}
+}
+
+// RegisterRoutes registers lexicon routes under /tts/lexicon (JWT required).
+func (h *LexiconHandler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	g := r.Group("/tts/lexicon", authMiddleware)
+	g.POST("", h.create)
+	g.GET("", h.list)
+	g.GET("/:id", h.getByID)
+	g.PUT("/:id", h.update)
+	g.DELETE("/:id", h.delete)
+	g.POST("/:id/preview", h.preview)
+}
+
+func (h *LexiconHandler) create(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var in model.LexiconEntryRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	e := &model.LexiconEntry{
+		UserID:      currentUser.ID,
+		Word:        in.Word,
+		Replacement: in.Replacement,
+		Kind:        in.Kind,
+		Language:    in.Language,
+		MatchCase:   in.MatchCase,
+	}
+	e.Normalize()
+	if err := e.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Create(c.Request.Context(), e); err != nil {
+		zap.S().Errorw("create lexicon entry failed", "user_id", currentUser.ID, "error", err)
+		c.JSON(lexiconErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"entry": e})
+}
+
+func (h *LexiconHandler) list(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	entries, err := h.svc.ListForUser(c.Request.Context(), currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func (h *LexiconHandler) getByID(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	e, err := h.svc.GetForUser(c.Request.Context(), id, currentUser)
+	if err != nil {
+		c.JSON(lexiconErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entry": e})
+}
+
+func (h *LexiconHandler) update(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in model.LexiconEntryRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	e := &model.LexiconEntry{
+		ID:          id,
+		Word:        in.Word,
+		Replacement: in.Replacement,
+		Kind:        in.Kind,
+		Language:    in.Language,
+		MatchCase:   in.MatchCase,
+	}
+	e.Normalize()
+	if err := e.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Update(c.Request.Context(), e, currentUser); err != nil {
+		c.JSON(lexiconErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entry": e})
+}
+
+func (h *LexiconHandler) delete(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), id, currentUser); err != nil {
+		c.JSON(lexiconErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// preview tổng hợp replacement của entry và trả thẳng audio (body JSON tuỳ chọn: {"voice": "..."}).
+func (h *LexiconHandler) preview(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in struct {
+		Voice string `json:"voice"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if in.Voice != "" {
+		if _, ok := model.LookupTTSVoice(in.Voice); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported voice"})
+			return
+		}
+	}
+
+	audio, err := h.svc.Preview(c.Request.Context(), id, currentUser, in.Voice)
+	if err != nil {
+		zap.S().Errorw("preview lexicon entry failed", "id", id, "error", err)
+		c.JSON(lexiconErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, audio.ContentType, audio.Data)
+}
+
+// lexiconErrorStatus map lỗi từ service sang HTTP status.
+func lexiconErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrLexiconForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrLexiconFull):
+		return http.StatusConflict
+	case err.Error() == "lexicon entry not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
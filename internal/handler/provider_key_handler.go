@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"video-transcript/internal/helper"
+	"video-transcript/internal/middleware"
+	"video-transcript/internal/model"
+	"video-transcript/internal/service"
+)
+
+// ProviderKeyHandler exposes admin endpoints for the provider API key pool.
+type ProviderKeyHandler struct {
+	svc service.ProviderKeyService
+}
+
+// NewProviderKeyHandler creates a new ProviderKeyHandler.
+func NewProviderKeyHandler(svc service.ProviderKeyService) *ProviderKeyHandler {
+	return &ProviderKeyHandler{svc: svc}
+}
+
+// RegisterRoutes registers admin routes under /admin/provider-keys (JWT + admin required).
+func (h *ProviderKeyHandler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	g := r.Group("/admin/provider-keys", authMiddleware, requireAdmin)
+	g.POST("", h.create)
+	g.GET("", h.list)
+	g.PUT("/:id/disable", h.disable)
+	g.PUT("/:id/enable", h.enable)
+	g.PUT("/:id/rotate", h.rotate)
+}
+
+// requireAdmin chặn request của user không phải admin.
+func requireAdmin(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	if currentUser.Role != "admin" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+	c.Next()
+}
+
+func (h *ProviderKeyHandler) create(c *gin.Context) {
+	var in model.CreateProviderKeyRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	k, err := h.svc.Create(c.Request.Context(), &in)
+	if err != nil {
+		zap.S().Errorw("create provider key failed", "provider", in.Provider, "error", err)
+		c.JSON(providerKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"provider_key": k})
+}
+
+func (h *ProviderKeyHandler) list(c *gin.Context) {
+	keys, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"provider_keys": keys})
+}
+
+func (h *ProviderKeyHandler) disable(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// Body không bắt buộc.
+	var in model.DisableProviderKeyRequest
+	_ = c.ShouldBindJSON(&in)
+
+	if err := h.svc.Disable(c.Request.Context(), id, in.Reason); err != nil {
+		c.JSON(providerKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "provider key disabled"})
+}
+
+func (h *ProviderKeyHandler) enable(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svc.Enable(c.Request.Context(), id); err != nil {
+		c.JSON(providerKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "provider key enabled"})
+}
+
+func (h *ProviderKeyHandler) rotate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in model.RotateProviderKeyRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	k, err := h.svc.Rotate(c.Request.Context(), id, &in)
+	if err != nil {
+		zap.S().Errorw("rotate provider key failed", "id", id, "error", err)
+		c.JSON(providerKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"provider_key": k})
+}
+
+// providerKeyErrorStatus map lỗi từ service sang HTTP status.
+func providerKeyErrorStatus(err error) int {
+	if errors.Is(err, helper.ErrMissingSecret) {
+		return http.StatusServiceUnavailable
+	}
+	if errors.Is(err, service.ErrInvalidProviderKey) {
+		return http.StatusBadRequest
+	}
+	if err.Error() == "provider key not found" {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
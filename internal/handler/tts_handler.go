@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/middleware"
+	"video-transcript/internal/model"
+	"video-transcript/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TTSHandler phục vụ các API phụ trợ cho text-to-speech (catalog voice, hội thoại nhiều người nói, audiobook, ...).
+type TTSHandler struct {
+	ttsSvc    service.TTSService
+	taskSvc   service.TaskService
+	videoSvc  service.VideoService
+	renderSvc service.RenderService
+}
+
+// NewTTSHandler creates a new TTSHandler.
+func NewTTSHandler(ttsSvc service.TTSService, taskSvc service.TaskService, videoSvc service.VideoService, renderSvc service.RenderService) *TTSHandler {
+	return &TTSHandler{ttsSvc: ttsSvc, taskSvc: taskSvc, videoSvc: videoSvc, renderSvc: renderSvc}
+}
+
+func (h *TTSHandler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	g := r.Group("/tts", authMiddleware)
+	g.GET("/voices", h.listVoices)
+	g.POST("/dialogue", h.dialogue)
+	g.POST("/audiobook", h.audiobook)
+	g.GET("/audiobook/:id", h.getAudiobook)
+	g.POST("/:id/mix", h.mixMusic)
+}
+
+// listVoices trả về catalog voice, lọc theo ?language= (vd "en", "en-GB") và ?gender=.
+func (h *TTSHandler) listVoices(c *gin.Context) {
+	voices := model.ListTTSVoices(c.Query("language"), c.Query("gender"))
+	c.JSON(http.StatusOK, gin.H{"data": voices})
+}
+
+// dialogue tổng hợp script hội thoại ("[Anna]: ...") thành 1 file audio, mỗi người nói 1 voice.
+// Chạy ở background như /deepgram/tts; audio kèm transcript + phụ đề WebVTT theo từng câu thoại.
+func (h *TTSHandler) dialogue(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var in model.DialogueRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		zap.S().Errorw("should bind json failed", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if n := utf8.RuneCountInString(in.Script); n > config.SvcCfg.TTSMaxTextChars {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("script is too long (%d characters, max %d)", n, config.SvcCfg.TTSMaxTextChars)})
+		return
+	}
+	dialogue, err := in.Parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.ttsSvc.Start(c.Request.Context(), &service.TTSJob{
+		UserID:   currentUser.ID,
+		Text:     in.Script,
+		Options:  in.Options,
+		Dialogue: dialogue,
+	})
+	if err != nil {
+		var markupErr *service.TTSMarkupError
+		if errors.As(err, &markupErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": markupErr.Error(), "line": markupErr.Line, "column": markupErr.Column})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": task})
+}
+
+// audiobook nhận tài liệu (multipart: field "file" .txt / .md / .html / .epub, field "options" JSON tuỳ chọn),
+// tách chương theo heading và tạo audiobook ở background. Trả về task và danh sách chương đã tách.
+func (h *TTSHandler) audiobook(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		zap.S().Errorw("failed to get file from form", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get file from form", "details": err.Error()})
+		return
+	}
+	maxSize := int64(config.SvcCfg.AudiobookMaxFileMB) << 20
+	if file.Size > maxSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":        "file too large",
+			"max_size_mb":  config.SvcCfg.AudiobookMaxFileMB,
+			"file_size_mb": file.Size / (1 << 20),
+		})
+		return
+	}
+
+	var in model.AudiobookRequest
+	if raw := c.PostForm("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid options: " + err.Error()})
+			return
+		}
+	}
+	if err := in.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		zap.S().Errorw("could not open uploaded file", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not open uploaded file"})
+		return
+	}
+	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, maxSize))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not read uploaded file"})
+		return
+	}
+
+	doc, err := service.ParseDocument(file.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	chars := 0
+	for _, ch := range doc.Chapters {
+		chars += utf8.RuneCountInString(ch.Title) + utf8.RuneCountInString(ch.Text)
+	}
+	if chars > config.SvcCfg.AudiobookMaxChars {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("document is too long (%d characters, max %d)", chars, config.SvcCfg.AudiobookMaxChars)})
+		return
+	}
+
+	task, err := h.ttsSvc.StartAudiobook(c.Request.Context(), &service.AudiobookJob{
+		UserID:   currentUser.ID,
+		Document: doc,
+		Request:  &in,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrMediaPipelineDisabled) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		zap.S().Errorw("start audiobook failed", "user_id", currentUser.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": task, "document": doc})
+}
+
+// getAudiobook trả về task audiobook (progress tính theo chunk của cả sách) kèm các chương MP3 và file M4B.
+func (h *TTSHandler) getAudiobook(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	book, err := h.ttsSvc.GetAudiobook(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrNotAudiobook) || err.Error() == "task not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if currentUser.Role != "admin" && (book.Task.UserID == nil || *book.Task.UserID != currentUser.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": book})
+}
+
+// mixMusic trộn nhạc nền (1 video / audio user đã upload) dưới giọng đọc của task TTS :id đã completed.
+// Chạy ở background như các job render; bản mix là Video mới trỏ về audio narration và task TTS.
+func (h *TTSHandler) mixMusic(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in model.MixMusicRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := in.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	task, err := h.taskSvc.GetByID(ctx, id)
+	if err != nil {
+		if err.Error() == "task not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if currentUser.Role != "admin" && (task.UserID == nil || *task.UserID != currentUser.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "task does not belong to current user"})
+		return
+	}
+	if task.TaskType != model.TaskTypeTTS || task.Status != model.TaskStatusCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task must be a completed tts task"})
+		return
+	}
+	if task.VideoID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task has no narration audio"})
+		return
+	}
+
+	narration, ok := h.getVideo(c, *task.VideoID)
+	if !ok {
+		return
+	}
+	music, ok := h.getVideo(c, in.MusicVideoID)
+	if !ok {
+		return
+	}
+	if music.UserID != narration.UserID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "music video does not belong to the task owner"})
+		return
+	}
+
+	mixTask, err := h.renderSvc.MixMusic(ctx, narration.UserID, narration, task, music, &in)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMediaPipelineDisabled):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		case errors.Is(err, model.ErrNoAudioStream), errors.Is(err, model.ErrCorruptMedia):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "music: " + err.Error()})
+		default:
+			zap.S().Errorw("start music mix failed", "task_id", task.ID, "music_video_id", music.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": mixTask})
+}
+
+// getVideo lấy video theo id. Lỗi đã được ghi ra response.
+func (h *TTSHandler) getVideo(c *gin.Context, id int64) (*model.Video, bool) {
+	video, err := h.videoSvc.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "video not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	return video, true
+}
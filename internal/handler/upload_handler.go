@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -11,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"video-transcript/internal/config"
 	"video-transcript/internal/middleware"
 	"video-transcript/internal/model"
 	"video-transcript/internal/service"
@@ -20,11 +24,12 @@ import (
 // UploadHandler xử lý upload file/audio lên server (R2) và lưu metadata vào DB.
 type UploadHandler struct {
 	videoSvc service.VideoService
+	pipeline service.MediaPipeline
 }
 
 // NewUploadHandler tạo UploadHandler mới.
-func NewUploadHandler(videoSvc service.VideoService) *UploadHandler {
-	return &UploadHandler{videoSvc: videoSvc}
+func NewUploadHandler(videoSvc service.VideoService, pipeline service.MediaPipeline) *UploadHandler {
+	return &UploadHandler{videoSvc: videoSvc, pipeline: pipeline}
 }
 
 // RegisterRoutes đăng ký route upload (yêu cầu JWT).
@@ -102,10 +107,53 @@ func (h *UploadHandler) uploadFile(c *gin.Context) {
 	}
 	defer src.Close()
 
-	// Tạo key duy nhất cho R2: uploads/<timestamp>-<filename>
-	key := filepath.Join("uploads", fmt.Sprintf("%d-%s", time.Now().UnixNano(), filepath.Base(file.Filename)))
+	// Ghi ra file tạm để ffprobe kiểm tra trước khi upload lên R2.
+	tmp, err := os.CreateTemp(config.SvcCfg.MediaWorkDir, "upload-*"+filepath.Ext(file.Filename))
+	if err != nil {
+		zap.S().Errorw("could not create temp file", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not store uploaded file"})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		zap.S().Errorw("could not write temp file", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not store uploaded file"})
+		return
+	}
+
+	// File không có audio vẫn nhận (render, HLS, poster...), STT / mix nhạc sẽ báo ErrNoAudioStream sau.
+	// Không probe được (pipeline tắt / thiếu ffprobe) thì nhận file, media info để trống.
+	var info *model.MediaInfo
+	if service.ProbeEnabled() {
+		info, err = service.ProbeMedia(c.Request.Context(), tmp.Name())
+		if err == nil && errors.Is(info.Validate(), model.ErrCorruptMedia) {
+			err = model.ErrCorruptMedia
+		}
+		if err != nil {
+			zap.S().Errorw("probe uploaded file failed",
+				"error", err,
+				"filename", file.Filename,
+			)
+			if errors.Is(err, model.ErrCorruptMedia) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not inspect uploaded file"})
+			return
+		}
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not read uploaded file"})
+		return
+	}
+
+	// Tạo key duy nhất cho R2: uploads/<userID>/<timestamp>-<filename>
+	key := uploads.UserUploadKey(currentUser.ID, fmt.Sprintf("%d-%s", time.Now().UnixNano(), filepath.Base(file.Filename)))
 
-	url, err := uploads.UploadToR2(c.Request.Context(), key, src, file.Size, file.Header.Get("Content-Type"))
+	url, err := uploads.UploadToR2(c.Request.Context(), key, tmp, file.Size, file.Header.Get("Content-Type"))
 	if err != nil {
 		zap.S().Errorw("upload to R2 failed",
 			"error", err,
@@ -126,6 +174,9 @@ func (h *UploadHandler) uploadFile(c *gin.Context) {
 		NameFile:    file.Filename,
 		Description: description,
 	}
+	if info != nil {
+		video.SetMediaInfo(info)
+	}
 
 	if err := h.videoSvc.Create(c.Request.Context(), video); err != nil {
 		zap.S().Errorw("could not save video metadata",
@@ -135,12 +186,16 @@ func (h *UploadHandler) uploadFile(c *gin.Context) {
 		return
 	}
 
+	// Waveform / poster / sprite sinh ở background, client lấy qua GET /api/videos/:id.
+	h.pipeline.ScheduleAssets(video)
+
 	c.JSON(http.StatusOK, gin.H{
 		"id":          video.ID,
 		"user_id":     video.UserID,
 		"link_video":  video.LinkVideo,
 		"name_file":   video.NameFile,
 		"description": video.Description,
+		"media":       info,
 		"created_at":  video.CreatedAt,
 		"updated_at":  video.UpdatedAt,
 	})
@@ -32,6 +32,8 @@ func (h *UserHandler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.Hand
 	userGroup.PUT("/:id", h.updateUser)
 	userGroup.DELETE("/:id", h.deleteUser)
 	userGroup.GET("videos/:id", h.listVideoByUserID)
+	userGroup.GET("/stt-defaults", h.getSTTDefaults)
+	userGroup.PUT("/stt-defaults", h.updateSTTDefaults)
 }
 
 func (h *UserHandler) createUser(c *gin.Context) {
@@ -257,3 +259,56 @@ func (h *UserHandler) listVideoByUserID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// getSTTDefaults trả về STT options mặc định của user hiện tại, kèm options hiệu lực sau khi merge.
+func (h *UserHandler) getSTTDefaults(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	defaults, err := h.svc.GetSTTDefaults(c.Request.Context(), currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	effective, err := h.svc.ResolveSTTOptions(c.Request.Context(), currentUser.ID, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"defaults":  defaults,
+		"effective": effective,
+	})
+}
+
+// updateSTTDefaults lưu STT options mặc định cho user hiện tại (body rỗng `{}` để reset).
+func (h *UserHandler) updateSTTDefaults(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var in model.STTOptions
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := in.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.UpdateSTTDefaults(c.Request.Context(), currentUser.ID, &in); err != nil {
+		zap.S().Errorw("update stt defaults failed", "user_id", currentUser.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"defaults": in})
+}
@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"video-transcript/internal/middleware"
+	"video-transcript/internal/model"
+	"video-transcript/internal/service"
+)
+
+// VideoHandler exposes video details and media analysis endpoints (assets, speech map, ...).
+type VideoHandler struct {
+	videoSvc     service.VideoService
+	taskSvc      service.TaskService
+	pipeline     service.MediaPipeline
+	transcodeSvc service.TranscodeService
+	renderSvc    service.RenderService
+}
+
+// NewVideoHandler creates a new VideoHandler.
+func NewVideoHandler(videoSvc service.VideoService, taskSvc service.TaskService, pipeline service.MediaPipeline, transcodeSvc service.TranscodeService, renderSvc service.RenderService) *VideoHandler {
+	return &VideoHandler{videoSvc: videoSvc, taskSvc: taskSvc, pipeline: pipeline, transcodeSvc: transcodeSvc, renderSvc: renderSvc}
+}
+
+// RegisterRoutes registers video routes under /videos (JWT required).
+func (h *VideoHandler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	g := r.Group("/videos", authMiddleware)
+	g.GET("/:id", h.getByID)
+	g.POST("/:id/assets", h.generateAssets)
+	g.GET("/:id/speech", h.getSpeechMap)
+	g.POST("/:id/transcode", h.transcode)
+	g.POST("/:id/render-captions", h.renderCaptions)
+	g.POST("/:id/edit", h.edit)
+	g.POST("/:id/clips", h.clips)
+	g.GET("/:id/derived", h.listDerived)
+}
+
+// getByID trả về video kèm thông tin media và asset cho web player (waveform, poster, sprite).
+func (h *VideoHandler) getByID(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	if err := h.videoSvc.AttachAssets(c.Request.Context(), video); err != nil {
+		zap.S().Errorw("attach video assets failed", "video_id", video.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": video})
+}
+
+// generateAssets sinh lại waveform / poster / sprite ở background (vd: video upload trước khi có tính năng này).
+func (h *VideoHandler) generateAssets(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	if !h.pipeline.ScheduleAssets(video) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "media pipeline is disabled"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "asset generation started"})
+}
+
+// transcode chạy job HLS ở background; theo dõi qua transcode_status / hls_url của GET /videos/:id.
+func (h *VideoHandler) transcode(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	if err := h.transcodeSvc.Start(c.Request.Context(), video); err != nil {
+		switch {
+		case errors.Is(err, service.ErrTranscodeInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrMediaPipelineDisabled):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			zap.S().Errorw("start transcode failed", "video_id", video.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": video})
+}
+
+// renderCaptions render video mới có phụ đề burn-in từ transcript của 1 task STT (chạy background, trả về task render).
+func (h *VideoHandler) renderCaptions(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+	currentUser := middleware.CurrentUser(c)
+
+	var in model.RenderCaptionsRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := in.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transcriptTask, ok := h.transcriptTask(c, currentUser, video, in.TaskID)
+	if !ok {
+		return
+	}
+
+	task, err := h.renderSvc.RenderCaptions(c.Request.Context(), currentUser.ID, video, transcriptTask, &in)
+	if err != nil {
+		h.renderError(c, video, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": task})
+}
+
+// edit cắt video theo transcript (xoá / giữ khoảng từ, bỏ từ đệm, rút ngắn khoảng lặng), chạy background.
+func (h *VideoHandler) edit(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+	currentUser := middleware.CurrentUser(c)
+
+	var in model.EditVideoRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := in.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transcriptTask, ok := h.transcriptTask(c, currentUser, video, in.TaskID)
+	if !ok {
+		return
+	}
+
+	task, err := h.renderSvc.EditVideo(c.Request.Context(), currentUser.ID, video, transcriptTask, &in)
+	if err != nil {
+		h.renderError(c, video, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": task})
+}
+
+// clips cắt clip theo thời gian / utterance của transcript, có thể ghép thành highlight reel (chạy background).
+func (h *VideoHandler) clips(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+	currentUser := middleware.CurrentUser(c)
+
+	var in model.ClipsRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := in.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transcriptTask, ok := h.transcriptTask(c, currentUser, video, in.TaskID)
+	if !ok {
+		return
+	}
+
+	task, err := h.renderSvc.ExtractClips(c.Request.Context(), currentUser.ID, video, transcriptTask, &in)
+	if err != nil {
+		h.renderError(c, video, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": task})
+}
+
+// listDerived trả về các video render từ video này (phụ đề, bản cắt, clip, highlight reel).
+func (h *VideoHandler) listDerived(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	videos, err := h.videoSvc.ListDerived(c.Request.Context(), video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": videos})
+}
+
+// transcriptTask lấy task đã completed có transcript của video (task STT, hoặc task TTS tạo kèm timing),
+// thuộc user (hoặc user là admin). Lỗi đã được ghi ra response.
+func (h *VideoHandler) transcriptTask(c *gin.Context, user *model.User, video *model.Video, taskID int64) (*model.Task, bool) {
+	task, err := h.taskSvc.GetByID(c.Request.Context(), taskID)
+	if err != nil {
+		if err.Error() == "task not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	if (task.UserID == nil || *task.UserID != user.ID) && user.Role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "task does not belong to current user"})
+		return nil, false
+	}
+	hasTranscript := task.TaskType == model.TaskTypeSTT || (task.TaskType == model.TaskTypeTTS && len(task.TranscriptJSON) > 0)
+	if !hasTranscript || task.Status != model.TaskStatusCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task must be a completed stt task or a tts task with timings"})
+		return nil, false
+	}
+	if task.VideoID != nil && *task.VideoID != video.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task is not a transcript of this video"})
+		return nil, false
+	}
+	return task, true
+}
+
+// renderError ghi lỗi khi tạo job render ra response.
+func (h *VideoHandler) renderError(c *gin.Context, video *model.Video, err error) {
+	switch {
+	case errors.Is(err, service.ErrEmptyTranscript), errors.Is(err, service.ErrInvalidEdit):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMediaPipelineDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrCorruptMedia):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		zap.S().Errorw("start render failed", "video_id", video.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// getSpeechMap trả về các đoạn có tiếng nói / khoảng lặng của video. Chưa có thì chạy VAD ở background
+// và trả 202 pending, client gọi lại sau.
+func (h *VideoHandler) getSpeechMap(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	speech, err := h.pipeline.ScheduleSpeech(c.Request.Context(), video)
+	if err != nil {
+		if errors.Is(err, service.ErrMediaPipelineDisabled) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		zap.S().Errorw("detect speech failed", "video_id", video.ID, "error", err)
+		c.JSON(mediaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if speech == nil {
+		c.JSON(http.StatusAccepted, gin.H{"status": model.TaskStatusPending})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": speech})
+}
+
+// ownedVideo lấy video theo :id, chỉ chủ video hoặc admin được xem. Lỗi đã được ghi ra response.
+func (h *VideoHandler) ownedVideo(c *gin.Context) (*model.Video, bool) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	video, err := h.videoSvc.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "video not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	if video.UserID != currentUser.ID && currentUser.Role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "video does not belong to current user"})
+		return nil, false
+	}
+	return video, true
+}
+
+// mediaErrorStatus: file hỏng / không có audio là lỗi của input, còn lại là lỗi server.
+func mediaErrorStatus(err error) int {
+	if errors.Is(err, model.ErrCorruptMedia) || errors.Is(err, model.ErrNoAudioStream) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
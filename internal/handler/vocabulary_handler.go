@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"video-transcript/internal/middleware"
+	"video-transcript/internal/model"
+	"video-transcript/internal/service"
+)
+
+// VocabularyHandler exposes custom vocabulary endpoints.
+type VocabularyHandler struct {
+	svc service.VocabularyService
+}
+
+// NewVocabularyHandler creates a new VocabularyHandler.
+func NewVocabularyHandler(svc service.VocabularyService) *VocabularyHandler {
+	return &VocabularyHandler{svc: svc}
+}
+
+// RegisterRoutes registers vocabulary routes under /vocabularies (JWT required).
+func (h *VocabularyHandler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	g := r.Group("/vocabularies", authMiddleware)
+	g.POST("", h.create)
+	g.GET("", h.list)
+	g.GET("/:id", h.getByID)
+	g.PUT("/:id", h.update)
+	g.DELETE("/:id", h.delete)
+}
+
+func (h *VocabularyHandler) create(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var in model.VocabularyRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Vocabulary dùng chung (user_id NULL) chỉ admin được tạo.
+	if in.Shared && currentUser.Role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admin can create shared vocabularies"})
+		return
+	}
+
+	v := &model.Vocabulary{
+		Name:  in.Name,
+		Terms: in.Terms,
+	}
+	if !in.Shared {
+		v.UserID = &currentUser.ID
+	}
+
+	if err := v.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Create(c.Request.Context(), v); err != nil {
+		zap.S().Errorw("create vocabulary failed", "user_id", currentUser.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"vocabulary": v})
+}
+
+func (h *VocabularyHandler) list(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	vocabularies, err := h.svc.ListForUser(c.Request.Context(), currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vocabularies": vocabularies})
+}
+
+func (h *VocabularyHandler) getByID(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	v, err := h.svc.GetForUser(c.Request.Context(), id, currentUser)
+	if err != nil {
+		c.JSON(vocabularyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vocabulary": v})
+}
+
+func (h *VocabularyHandler) update(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in model.VocabularyRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	v := &model.Vocabulary{
+		ID:    id,
+		Name:  in.Name,
+		Terms: in.Terms,
+	}
+	if err := v.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Update(c.Request.Context(), v, currentUser); err != nil {
+		c.JSON(vocabularyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vocabulary": v})
+}
+
+func (h *VocabularyHandler) delete(c *gin.Context) {
+	currentUser := middleware.CurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), id, currentUser); err != nil {
+		c.JSON(vocabularyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// vocabularyErrorStatus map lỗi từ service sang HTTP status.
+func vocabularyErrorStatus(err error) int {
+	if errors.Is(err, service.ErrVocabularyForbidden) {
+		return http.StatusForbidden
+	}
+	if err.Error() == "vocabulary not found" {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,187 @@
+package helper
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen trả về khi circuit breaker đang mở và request bị từ chối ngay.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState là trạng thái của circuit breaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // hoạt động bình thường
+	BreakerOpen     BreakerState = "open"      // fail fast, không gọi provider
+	BreakerHalfOpen BreakerState = "half_open" // cho 1 số request thử để kiểm tra provider đã hồi phục chưa
+)
+
+// BreakerConfig cấu hình ngưỡng cho CircuitBreaker.
+type BreakerConfig struct {
+	Window         int           // số kết quả gần nhất dùng để tính error rate
+	MinRequests    int           // số request tối thiểu trong window trước khi được phép mở mạch
+	ErrorRate      float64       // error rate (0..1) để mở mạch
+	OpenDuration   time.Duration // thời gian mở mạch trước khi chuyển half-open
+	HalfOpenProbes int           // số request thử đồng thời khi half-open
+}
+
+// BreakerSnapshot là trạng thái breaker trả về cho health endpoint (public nên chỉ có state và bộ đếm,
+// lỗi chi tiết của provider chỉ ghi log).
+type BreakerSnapshot struct {
+	State     BreakerState `json:"state"`
+	Requests  int          `json:"requests"`
+	Failures  int          `json:"failures"`
+	ErrorRate float64      `json:"error_rate"`
+	OpenedAt  *time.Time   `json:"opened_at,omitempty"`
+}
+
+// CircuitBreaker theo dõi error rate trên 1 cửa sổ trượt các request gần nhất.
+type CircuitBreaker struct {
+	mu  sync.Mutex
+	cfg BreakerConfig
+
+	state    BreakerState
+	outcomes []bool // ring buffer, true = lỗi
+	next     int
+	filled   int
+	openedAt time.Time
+	probes   int
+	now      func() time.Time
+}
+
+// NewCircuitBreaker tạo breaker ở trạng thái closed.
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.Window <= 0 {
+		cfg.Window = 20
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 1
+	}
+	if cfg.ErrorRate <= 0 || cfg.ErrorRate > 1 {
+		cfg.ErrorRate = 0.5
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	return &CircuitBreaker{
+		cfg:      cfg,
+		state:    BreakerClosed,
+		outcomes: make([]bool, cfg.Window),
+		now:      time.Now,
+	}
+}
+
+// Allow cho biết request có được gọi provider hay không. Nếu được, caller phải gọi done
+// đúng 1 lần với lỗi của request (nil hoặc lỗi không do provider = thành công).
+func (b *CircuitBreaker) Allow() (done func(failure error), ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenDuration {
+		b.state = BreakerHalfOpen
+		b.probes = 0
+	}
+
+	switch b.state {
+	case BreakerClosed:
+		return func(failure error) { b.record(false, failure) }, true
+	case BreakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return nil, false
+		}
+		b.probes++
+		return func(failure error) { b.record(true, failure) }, true
+	default:
+		return nil, false
+	}
+}
+
+func (b *CircuitBreaker) record(probe bool, failure error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		if b.probes > 0 {
+			b.probes--
+		}
+		if b.state != BreakerHalfOpen {
+			return
+		}
+		if failure != nil {
+			b.trip()
+			return
+		}
+		// Probe thành công: đóng mạch và bắt đầu đếm lại từ đầu.
+		b.state = BreakerClosed
+		b.resetWindow()
+		return
+	}
+
+	// Kết quả của request bắt đầu khi mạch còn đóng nhưng về sau khi mạch đã đổi trạng thái thì bỏ qua.
+	if b.state != BreakerClosed {
+		return
+	}
+	b.outcomes[b.next] = failure != nil
+	b.next = (b.next + 1) % len(b.outcomes)
+	if b.filled < len(b.outcomes) {
+		b.filled++
+	}
+
+	requests, failures := b.counts()
+	if requests >= b.cfg.MinRequests && float64(failures)/float64(requests) >= b.cfg.ErrorRate {
+		b.trip()
+	}
+}
+
+func (b *CircuitBreaker) trip() {
+	b.state = BreakerOpen
+	b.openedAt = b.now()
+	b.probes = 0
+	b.resetWindow()
+}
+
+func (b *CircuitBreaker) resetWindow() {
+	for i := range b.outcomes {
+		b.outcomes[i] = false
+	}
+	b.next = 0
+	b.filled = 0
+}
+
+func (b *CircuitBreaker) counts() (int, int) {
+	failures := 0
+	for i := 0; i < b.filled; i++ {
+		if b.outcomes[i] {
+			failures++
+		}
+	}
+	return b.filled, failures
+}
+
+// Snapshot trả về trạng thái hiện tại của breaker.
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenDuration {
+		state = BreakerHalfOpen
+	}
+
+	requests, failures := b.counts()
+	snap := BreakerSnapshot{
+		State:    state,
+		Requests: requests,
+		Failures: failures,
+	}
+	if requests > 0 {
+		snap.ErrorRate = float64(failures) / float64(requests)
+	}
+	if state != BreakerClosed {
+		openedAt := b.openedAt
+		snap.OpenedAt = &openedAt
+	}
+	return snap
+}
@@ -0,0 +1,107 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errProvider = errors.New("provider unavailable")
+
+// newTestBreaker tạo breaker với đồng hồ giả, tăng thời gian bằng *now.
+func newTestBreaker(cfg BreakerConfig) (*CircuitBreaker, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	b := NewCircuitBreaker(cfg)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+// call chạy 1 request qua breaker, trả về false nếu bị từ chối.
+func call(b *CircuitBreaker, failure error) bool {
+	done, ok := b.Allow()
+	if !ok {
+		return false
+	}
+	done(failure)
+	return true
+}
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	cfg := BreakerConfig{Window: 4, MinRequests: 4, ErrorRate: 0.5, OpenDuration: time.Minute}
+	tests := []struct {
+		name     string
+		failures []bool
+		want     BreakerState
+	}{
+		{"below min requests stays closed", []bool{true, true, true}, BreakerClosed},
+		{"error rate reached opens", []bool{false, false, true, true}, BreakerOpen},
+		{"below error rate stays closed", []bool{false, false, false, true}, BreakerClosed},
+		{"only the last window counts", []bool{false, false, false, true, true}, BreakerOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBreaker(cfg)
+			for _, failed := range tt.failures {
+				var err error
+				if failed {
+					err = errProvider
+				}
+				call(b, err)
+			}
+			if got := b.Snapshot().State; got != tt.want {
+				t.Errorf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe error
+		want  BreakerState
+	}{
+		{"successful probe closes", nil, BreakerClosed},
+		{"failed probe reopens", errProvider, BreakerOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, now := newTestBreaker(BreakerConfig{Window: 2, MinRequests: 2, ErrorRate: 0.5, OpenDuration: time.Minute, HalfOpenProbes: 1})
+			call(b, errProvider)
+			call(b, errProvider)
+			if call(b, nil) {
+				t.Fatal("open breaker allowed a request")
+			}
+
+			*now = now.Add(time.Minute)
+			if got := b.Snapshot().State; got != BreakerHalfOpen {
+				t.Fatalf("state after open duration = %s, want %s", got, BreakerHalfOpen)
+			}
+			done, ok := b.Allow()
+			if !ok {
+				t.Fatal("half-open breaker rejected the probe")
+			}
+			if _, ok := b.Allow(); ok {
+				t.Fatal("half-open breaker allowed more probes than HalfOpenProbes")
+			}
+			done(tt.probe)
+			if got := b.Snapshot().State; got != tt.want {
+				t.Errorf("state after probe = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerIgnoresLateResults(t *testing.T) {
+	b, _ := newTestBreaker(BreakerConfig{Window: 2, MinRequests: 2, ErrorRate: 0.5, OpenDuration: time.Minute})
+	late, _ := b.Allow()
+	call(b, errProvider)
+	call(b, errProvider)
+
+	// Request bắt đầu khi mạch còn đóng, kết thúc sau khi mạch đã mở: không được đóng lại mạch.
+	late(nil)
+	snap := b.Snapshot()
+	if snap.State != BreakerOpen || snap.Requests != 0 {
+		t.Errorf("snapshot = %+v, want open with an empty window", snap)
+	}
+}
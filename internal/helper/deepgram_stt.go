@@ -3,6 +3,9 @@ package helper
 import (
 	"context"
 	"fmt"
+	"strings"
+
+	"video-transcript/internal/model"
 
 	api "github.com/deepgram/deepgram-go-sdk/v3/pkg/api/listen/v1/rest"
 	interfacesv1 "github.com/deepgram/deepgram-go-sdk/v3/pkg/api/listen/v1/rest/interfaces"
@@ -11,35 +14,71 @@ import (
 	"go.uber.org/zap"
 )
 
-func DeepgramSTTFromBytes(ctx context.Context, file_url string, contentType string, language string) (*interfacesv1.PreRecordedResponse, error) {
-	if language == "" {
-		language = "en-US"
+// Transcribe gọi Deepgram pre-recorded API cho file_url với các option đã resolve.
+// opts = nil thì dùng model.DefaultSTTOptions().
+func (p *deepgramProvider) Transcribe(ctx context.Context, file_url string, opts *model.STTOptions) (*interfacesv1.PreRecordedResponse, error) {
+	if opts == nil {
+		opts = model.DefaultSTTOptions()
 	}
 
 	// 1. Init client Listen API
-	options := &interfaces.PreRecordedTranscriptionOptions{
-		Model:          "nova-3",
-		Keyterm:        []string{"deepgram"},
-		Punctuate:      true,
-		Diarize:        true,
-		Language:       language,
-		Utterances:     true,
-		Redact:         []string{"pci", "ssn"},
-		DetectLanguage: true,
-	}
+	options := buildPreRecordedOptions(opts)
 
-	c := client.NewRESTWithDefaults()
-	dg := api.New(c)
+	var res *interfacesv1.PreRecordedResponse
+	err := p.withAPIKey(ctx, func(apiKey string) error {
+		clientOptions := p.clientOptions()
+		c := client.NewREST(apiKey, clientOptions)
+		if c == nil {
+			return fmt.Errorf("deepgram listen client is not initialized (provider %s)", p.name)
+		}
+		p.pinClientOptions(clientOptions, apiKey)
+		dg := api.New(c)
 
-	res, err := dg.FromURL(ctx, file_url, options)
+		var err error
+		res, err = dg.FromURL(ctx, file_url, options)
+		return err
+	})
 	if err != nil {
-		if e, ok := err.(*interfaces.StatusError); ok {
-			zap.S().Errorw("DEEPGRAM ERROR", "error", e.DeepgramError.ErrCode, "message", e.DeepgramError.ErrMsg)
+		if e, ok := err.(*interfaces.StatusError); ok && e.DeepgramError != nil {
+			zap.S().Errorw("DEEPGRAM ERROR", "provider", p.name, "error", e.DeepgramError.ErrCode, "message", e.DeepgramError.ErrMsg)
 			return nil, fmt.Errorf("DEEPGRAM ERROR: %w", err)
 		}
-		zap.S().Errorw("FromURL failed", "error", err)
+		zap.S().Errorw("FromURL failed", "provider", p.name, "error", err)
 		return nil, fmt.Errorf("FromURL failed: %w", err)
 	}
 
 	return res, nil
 }
+
+// buildPreRecordedOptions map model.STTOptions sang options của Deepgram SDK.
+func buildPreRecordedOptions(opts *model.STTOptions) *interfaces.PreRecordedTranscriptionOptions {
+	options := &interfaces.PreRecordedTranscriptionOptions{
+		Model:           opts.Model,
+		Punctuate:       true,
+		Diarize:         model.BoolValue(opts.Diarize),
+		Utterances:      model.BoolValue(opts.Utterances),
+		Redact:          opts.Redact,
+		SmartFormat:     model.BoolValue(opts.SmartFormat),
+		Numerals:        model.BoolValue(opts.Numerals),
+		ProfanityFilter: model.BoolValue(opts.ProfanityFilter),
+	}
+
+	if opts.AutoDetectLanguage() {
+		options.DetectLanguage = true
+	} else {
+		options.Language = opts.Language
+	}
+
+	if opts.UttSplit != nil {
+		options.UttSplit = *opts.UttSplit
+	}
+
+	// Keyterm prompting chỉ có ở nova-3, các model cũ dùng keywords (có boost).
+	if strings.HasPrefix(opts.Model, "nova-3") {
+		options.Keyterm = opts.Keyterms
+	} else {
+		options.Keywords = append(append([]string{}, opts.Keyterms...), opts.Keywords...)
+	}
+
+	return options
+}
@@ -5,58 +5,60 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"time"
 
-	"video-transcript/internal/config"
-	"video-transcript/internal/uploads"
+	"video-transcript/internal/model"
 
 	api "github.com/deepgram/deepgram-go-sdk/v3/pkg/api/speak/v1/rest"
+	speakinterfaces "github.com/deepgram/deepgram-go-sdk/v3/pkg/api/speak/v1/rest/interfaces"
 	interfaces "github.com/deepgram/deepgram-go-sdk/v3/pkg/client/interfaces"
 	client "github.com/deepgram/deepgram-go-sdk/v3/pkg/client/speak"
 	"go.uber.org/zap"
 )
 
-func DeepgramTTS(ctx context.Context, userID string, text string) (string, error) {
-	// Deepgram client is already initialized in main()
-	if config.SvcCfg.DeepgramAPIKey == "" {
-		err := errors.New("missing Deepgram API key")
-		zap.S().Errorw("Deepgram client init failed", "error", err)
-		return "", err
-	}
+// Speak gọi Deepgram speak API, trả về audio bytes.
+func (p *deepgramProvider) Speak(ctx context.Context, text string, opts *model.TTSOptions) (*SpeechAudio, error) {
+	opts = opts.WithDefaults()
+	encoding, container := opts.ProviderEncoding()
 	options := &interfaces.SpeakOptions{
-		Model: "aura-2-thalia-en",
+		Model:     opts.Voice,
+		Encoding:  encoding,
+		Container: container,
 	}
-	log.Println("Deepgram API key", config.SvcCfg.DeepgramAPIKey)
-	c := client.NewRESTWithDefaults()
-	if c == nil {
-		log.Println("Deepgram client is not initialized", config.SvcCfg.DeepgramAPIKey)
-		zap.S().Errorw("Deepgram client is not initialized", "error", errors.New("deepgram client is not initialized"))
-		return "", fmt.Errorf("deepgram client is not initialized: %w", errors.New("deepgram client is not initialized"))
+	if opts.SampleRate != nil {
+		options.SampleRate = *opts.SampleRate
 	}
-	dg := api.New(c)
-	if dg == nil {
-		zap.S().Errorw("Deepgram API is not initialized", "error", errors.New("deepgram API is not initialized"))
-		return "", fmt.Errorf("deepgram API is not initialized: %w", errors.New("deepgram API is not initialized"))
+	if opts.BitRate != nil {
+		options.BitRate = *opts.BitRate
 	}
 	var buf bytes.Buffer
-	res, err := dg.ToFile(ctx, text, options, &buf)
+	var res *speakinterfaces.SpeakResponse
+	err := p.withAPIKey(ctx, func(apiKey string) error {
+		clientOptions := p.clientOptions()
+		c := client.NewREST(apiKey, clientOptions)
+		if c == nil {
+			zap.S().Errorw("Deepgram client is not initialized", "provider", p.name, "error", errors.New("deepgram client is not initialized"))
+			return fmt.Errorf("deepgram client is not initialized: %w", errors.New("deepgram client is not initialized"))
+		}
+		p.pinClientOptions(clientOptions, apiKey)
+		dg := api.New(c)
+		if dg == nil {
+			zap.S().Errorw("Deepgram API is not initialized", "provider", p.name, "error", errors.New("deepgram API is not initialized"))
+			return fmt.Errorf("deepgram API is not initialized: %w", errors.New("deepgram API is not initialized"))
+		}
+		// Thử lại với key khác thì bỏ audio của lần trước.
+		buf.Reset()
+		var err error
+		res, err = dg.ToFile(ctx, text, options, &buf)
+		return err
+	})
 	if err != nil {
-		zap.S().Errorw("from stream failed", "error", err)
-		return "", fmt.Errorf("from stream failed: %w", err)
+		zap.S().Errorw("from stream failed", "provider", p.name, "error", err)
+		return nil, fmt.Errorf("from stream failed: %w", err)
 	}
 
-	contentType := "application/octet-stream"
+	contentType := opts.ContentType()
 	if res != nil && res.ContextType != "" {
 		contentType = res.ContextType
 	}
-
-	// Lưu thẳng audio bytes lên R2, không cần ghi ra file tạm.
-	key := fmt.Sprintf("text-to-speech/%s/%d-audio", userID, time.Now().UnixNano())
-	url, err := uploads.UploadToR2(ctx, key, bytes.NewReader(buf.Bytes()), int64(buf.Len()), contentType)
-	if err != nil {
-		zap.S().Error("UploadToR2 Err: %v", err)
-		return "", fmt.Errorf("UploadToR2 Err: %v", err)
-	}
-	return url, nil
+	return &SpeechAudio{Data: buf.Bytes(), ContentType: contentType}, nil
 }
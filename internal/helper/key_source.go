@@ -0,0 +1,112 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	interfaces "github.com/deepgram/deepgram-go-sdk/v3/pkg/client/interfaces"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNoProviderKeys: pool chưa có key nào cho provider, provider dùng key từ env.
+	ErrNoProviderKeys = errors.New("no provider keys configured")
+	// ErrNoAvailableKey: pool có key nhưng tất cả đang bị disable, cooldown hoặc hết quota.
+	ErrNoAvailableKey = errors.New("no available provider key")
+)
+
+// KeyLease là 1 API key được pool cấp cho 1 lần gọi provider.
+type KeyLease struct {
+	ID  int64
+	Key string
+}
+
+// KeySource cấp API key cho speech provider (vd: pool key lưu trong DB).
+type KeySource interface {
+	// Acquire chọn 1 key cho provider, bỏ qua các key trong exclude (đã thử trong cùng request).
+	Acquire(ctx context.Context, provider string, exclude map[int64]bool) (*KeyLease, error)
+	// Release báo kết quả dùng key: status là HTTP status provider trả về (0 nếu không có).
+	Release(ctx context.Context, lease *KeyLease, status int, err error)
+}
+
+var (
+	keySource   KeySource
+	keySourceMu sync.RWMutex
+)
+
+// SetKeySource gắn pool key cho các speech provider. nil = chỉ dùng key từ env.
+func SetKeySource(src KeySource) {
+	keySourceMu.Lock()
+	defer keySourceMu.Unlock()
+	keySource = src
+}
+
+func getKeySource() KeySource {
+	keySourceMu.RLock()
+	defer keySourceMu.RUnlock()
+	return keySource
+}
+
+// maxKeyAttempts giới hạn số key thử lại trong 1 request khi key bị từ chối.
+const maxKeyAttempts = 3
+
+// IsKeyRejected cho biết provider từ chối chính key (sai key, hết credit, bị rate limit)
+// nên cần bỏ qua key đó và thử key khác.
+func IsKeyRejected(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusPaymentRequired || status == http.StatusTooManyRequests
+}
+
+// statusCode lấy HTTP status từ lỗi của Deepgram SDK (0 nếu không có).
+func statusCode(err error) int {
+	var statusErr *interfaces.StatusError
+	if errors.As(err, &statusErr) && statusErr.Resp != nil {
+		return statusErr.Resp.StatusCode
+	}
+	return 0
+}
+
+// withAPIKey chạy fn với key lấy từ pool; key bị từ chối (401/402/429) thì thử key khác.
+// Pool chưa có key nào cho provider thì dùng key cấu hình sẵn của provider.
+func (p *deepgramProvider) withAPIKey(ctx context.Context, fn func(apiKey string) error) error {
+	src := getKeySource()
+	if src == nil {
+		return p.withStaticKey(fn)
+	}
+
+	tried := make(map[int64]bool)
+	var lastErr error
+	for attempt := 0; attempt < maxKeyAttempts; attempt++ {
+		lease, err := src.Acquire(ctx, p.name, tried)
+		if errors.Is(err, ErrNoProviderKeys) && attempt == 0 {
+			return p.withStaticKey(fn)
+		}
+		if err != nil {
+			if lastErr != nil {
+				return lastErr
+			}
+			zap.S().Errorw("acquire provider key failed", "provider", p.name, "error", err)
+			return err
+		}
+		tried[lease.ID] = true
+
+		err = fn(lease.Key)
+		status := statusCode(err)
+		src.Release(ctx, lease, status, err)
+		if err == nil || !IsKeyRejected(status) {
+			return err
+		}
+		zap.S().Warnw("provider key rejected, trying next key", "provider", p.name, "key_id", lease.ID, "status", status)
+		lastErr = err
+	}
+	return lastErr
+}
+
+func (p *deepgramProvider) withStaticKey(fn func(apiKey string) error) error {
+	if p.apiKey == "" {
+		zap.S().Errorw("Deepgram client init failed", "provider", p.name, "error", errMissingAPIKey)
+		return errMissingAPIKey
+	}
+	return fn(p.apiKey)
+}
@@ -0,0 +1,65 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"video-transcript/internal/config"
+)
+
+// ErrMissingSecret trả về khi chưa cấu hình PROVIDER_KEY_SECRET.
+var ErrMissingSecret = errors.New("PROVIDER_KEY_SECRET is not configured")
+
+// secretCipher tạo AES-256-GCM từ PROVIDER_KEY_SECRET (sha256 để secret độ dài nào cũng dùng được).
+func secretCipher() (cipher.AEAD, error) {
+	if config.SvcCfg.ProviderKeySecret == "" {
+		return nil, ErrMissingSecret
+	}
+	sum := sha256.Sum256([]byte(config.SvcCfg.ProviderKeySecret))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret mã hoá plaintext, trả về base64(nonce || ciphertext).
+func EncryptSecret(plaintext string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret giải mã chuỗi do EncryptSecret tạo ra.
+func DecryptSecret(encoded string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode secret: %w", err)
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("encrypted secret is too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
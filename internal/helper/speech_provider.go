@@ -0,0 +1,80 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"video-transcript/internal/model"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/v3/pkg/api/listen/v1/rest/interfaces"
+	interfaces "github.com/deepgram/deepgram-go-sdk/v3/pkg/client/interfaces"
+)
+
+// errMissingAPIKey trả về khi provider chưa được cấu hình API key.
+var errMissingAPIKey = errors.New("missing Deepgram API key")
+
+// SpeechAudio là audio provider TTS trả về.
+type SpeechAudio struct {
+	Data        []byte
+	ContentType string
+}
+
+// SpeechProvider là 1 nhà cung cấp speech (STT + TTS) có thể đứng sau circuit breaker.
+type SpeechProvider interface {
+	Name() string
+	Transcribe(ctx context.Context, fileURL string, opts *model.STTOptions) (*interfacesv1.PreRecordedResponse, error)
+	Speak(ctx context.Context, text string, opts *model.TTSOptions) (*SpeechAudio, error)
+}
+
+// deepgramProvider gọi Deepgram (cloud hoặc endpoint Deepgram-compatible qua host).
+type deepgramProvider struct {
+	name   string
+	apiKey string // key tĩnh, dùng khi pool chưa có key
+	host   string // rỗng = api.deepgram.com
+}
+
+// NewDeepgramProvider tạo SpeechProvider dùng Deepgram SDK.
+func NewDeepgramProvider(name, apiKey, host string) SpeechProvider {
+	return &deepgramProvider{name: name, apiKey: apiKey, host: host}
+}
+
+func (p *deepgramProvider) Name() string {
+	return p.name
+}
+
+func (p *deepgramProvider) clientOptions() *interfaces.ClientOptions {
+	return &interfaces.ClientOptions{Host: p.host}
+}
+
+// pinClientOptions ghi lại host / key của provider lên options sau khi SDK tạo client: ClientOptions.Parse
+// (chạy trong constructor của SDK) lấy DEEPGRAM_HOST đè lên Host và ưu tiên DEEPGRAM_ACCESS_TOKEN hơn API key
+// truyền vào, khi đó mọi provider cùng gọi 1 endpoint và failover không có tác dụng. Client đọc options
+// lúc gửi request nên ghi lại sau khi tạo là đủ.
+func (p *deepgramProvider) pinClientOptions(o *interfaces.ClientOptions, apiKey string) {
+	o.Host = p.host
+	o.APIVersion = ""
+	o.Path = ""
+	o.SetAccessToken("")
+	o.SetAPIKey(apiKey)
+}
+
+// IsProviderFailure phân biệt lỗi do provider (5xx, 429, timeout, mất kết nối) với lỗi do request
+// (4xx) hoặc do caller huỷ context. Chỉ lỗi provider mới tính vào circuit breaker và được failover.
+func IsProviderFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var statusErr *interfaces.StatusError
+	if errors.As(err, &statusErr) && statusErr.Resp != nil {
+		code := statusErr.Resp.StatusCode
+		return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// Lỗi không rõ nguồn gốc (SDK trả về lỗi thường) thì coi như lỗi provider.
+	return true
+}
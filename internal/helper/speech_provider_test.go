@@ -0,0 +1,39 @@
+package helper
+
+import (
+	"testing"
+
+	client "github.com/deepgram/deepgram-go-sdk/v3/pkg/client/listen"
+)
+
+func TestPinClientOptionsIgnoresSDKEnv(t *testing.T) {
+	t.Setenv("DEEPGRAM_HOST", "env.example.com")
+	t.Setenv("DEEPGRAM_ACCESS_TOKEN", "env-token")
+
+	tests := []struct {
+		name string
+		host string
+	}{
+		{"primary", ""},
+		{"secondary", "stt.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &deepgramProvider{name: tt.name, host: tt.host}
+			opts := p.clientOptions()
+			c := client.NewREST("provider-key", opts)
+			if c == nil {
+				t.Fatal("client is nil")
+			}
+			p.pinClientOptions(opts, "provider-key")
+
+			if c.Options.Host != tt.host {
+				t.Errorf("host = %q, want %q", c.Options.Host, tt.host)
+			}
+			token, isBearer := c.Options.GetAuthToken()
+			if isBearer || token != "provider-key" {
+				t.Errorf("auth = %q (bearer %v), want provider key", token, isBearer)
+			}
+		})
+	}
+}
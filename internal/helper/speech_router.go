@@ -0,0 +1,155 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/model"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/v3/pkg/api/listen/v1/rest/interfaces"
+	"go.uber.org/zap"
+)
+
+// routedProvider gắn provider với breaker riêng cho từng loại request (STT / TTS).
+type routedProvider struct {
+	provider SpeechProvider
+	stt      *CircuitBreaker
+	tts      *CircuitBreaker
+}
+
+// speechRouter gọi provider theo thứ tự ưu tiên: provider nào đang mở mạch thì bỏ qua
+// (fail fast) và chuyển sang provider tiếp theo.
+type speechRouter struct {
+	providers []*routedProvider
+}
+
+var (
+	router     *speechRouter
+	routerOnce sync.Once
+)
+
+// getRouter khởi tạo router từ config ở lần gọi đầu tiên.
+func getRouter() *speechRouter {
+	routerOnce.Do(func() {
+		cfg := BreakerConfig{
+			Window:         config.SvcCfg.BreakerWindow,
+			MinRequests:    config.SvcCfg.BreakerMinRequests,
+			ErrorRate:      config.SvcCfg.BreakerErrorRate,
+			OpenDuration:   time.Duration(config.SvcCfg.BreakerOpenSeconds) * time.Second,
+			HalfOpenProbes: config.SvcCfg.BreakerHalfOpenProbes,
+		}
+		providers := []SpeechProvider{
+			NewDeepgramProvider("deepgram", config.SvcCfg.DeepgramAPIKey, ""),
+		}
+		if config.SvcCfg.SpeechSecondaryHost != "" {
+			apiKey := config.SvcCfg.SpeechSecondaryAPIKey
+			if apiKey == "" {
+				apiKey = config.SvcCfg.DeepgramAPIKey
+			}
+			providers = append(providers, NewDeepgramProvider("deepgram-secondary", apiKey, config.SvcCfg.SpeechSecondaryHost))
+		}
+		router = newSpeechRouter(cfg, providers...)
+	})
+	return router
+}
+
+func newSpeechRouter(cfg BreakerConfig, providers ...SpeechProvider) *speechRouter {
+	r := &speechRouter{}
+	for _, p := range providers {
+		r.providers = append(r.providers, &routedProvider{
+			provider: p,
+			stt:      NewCircuitBreaker(cfg),
+			tts:      NewCircuitBreaker(cfg),
+		})
+	}
+	return r
+}
+
+// Transcribe gọi STT qua router. Trả về response và tên provider đã phục vụ request.
+func Transcribe(ctx context.Context, fileURL string, opts *model.STTOptions) (*interfacesv1.PreRecordedResponse, string, error) {
+	return getRouter().transcribe(ctx, fileURL, opts)
+}
+
+// Speak gọi TTS qua router. Trả về audio và tên provider đã phục vụ request.
+func Speak(ctx context.Context, text string, opts *model.TTSOptions) (*SpeechAudio, string, error) {
+	return getRouter().speak(ctx, text, opts)
+}
+
+func (r *speechRouter) transcribe(ctx context.Context, fileURL string, opts *model.STTOptions) (*interfacesv1.PreRecordedResponse, string, error) {
+	var lastErr error
+	for _, rp := range r.providers {
+		done, ok := rp.stt.Allow()
+		if !ok {
+			zap.S().Warnw("stt provider circuit open, skipping", "provider", rp.provider.Name())
+			lastErr = fmt.Errorf("%s: %w", rp.provider.Name(), ErrCircuitOpen)
+			continue
+		}
+
+		res, err := rp.provider.Transcribe(ctx, fileURL, opts)
+		if err == nil {
+			done(nil)
+			return res, rp.provider.Name(), nil
+		}
+		if !IsProviderFailure(ctx, err) {
+			// Lỗi do request (4xx) hoặc context bị huỷ: provider vẫn khoẻ, không failover.
+			done(nil)
+			return nil, rp.provider.Name(), err
+		}
+		done(err)
+		zap.S().Errorw("stt provider failed, trying next provider", "provider", rp.provider.Name(), "error", err)
+		lastErr = err
+	}
+	return nil, "", fmt.Errorf("all stt providers failed: %w", lastErr)
+}
+
+func (r *speechRouter) speak(ctx context.Context, text string, opts *model.TTSOptions) (*SpeechAudio, string, error) {
+	var lastErr error
+	for _, rp := range r.providers {
+		done, ok := rp.tts.Allow()
+		if !ok {
+			zap.S().Warnw("tts provider circuit open, skipping", "provider", rp.provider.Name())
+			lastErr = fmt.Errorf("%s: %w", rp.provider.Name(), ErrCircuitOpen)
+			continue
+		}
+
+		audio, err := rp.provider.Speak(ctx, text, opts)
+		if err == nil {
+			done(nil)
+			return audio, rp.provider.Name(), nil
+		}
+		if !IsProviderFailure(ctx, err) {
+			done(nil)
+			return nil, rp.provider.Name(), err
+		}
+		done(err)
+		zap.S().Errorw("tts provider failed, trying next provider", "provider", rp.provider.Name(), "error", err)
+		lastErr = err
+	}
+	return nil, "", fmt.Errorf("all tts providers failed: %w", lastErr)
+}
+
+// ProviderHealth là trạng thái breaker của 1 provider, dùng cho health endpoint.
+type ProviderHealth struct {
+	Provider string          `json:"provider"`
+	Priority int             `json:"priority"`
+	STT      BreakerSnapshot `json:"stt"`
+	TTS      BreakerSnapshot `json:"tts"`
+}
+
+// ProvidersHealth trả về trạng thái breaker của tất cả provider theo thứ tự ưu tiên.
+func ProvidersHealth() []ProviderHealth {
+	r := getRouter()
+	out := make([]ProviderHealth, 0, len(r.providers))
+	for i, rp := range r.providers {
+		out = append(out, ProviderHealth{
+			Provider: rp.provider.Name(),
+			Priority: i,
+			STT:      rp.stt.Snapshot(),
+			TTS:      rp.tts.Snapshot(),
+		})
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// MaxAudiobookChapters là số chương tối đa của 1 audiobook.
+	MaxAudiobookChapters = 300
+	// DefaultAudiobookChapterGapMs là khoảng lặng cuối mỗi chương.
+	DefaultAudiobookChapterGapMs = 1500
+	maxAudiobookTitleLength      = 200
+)
+
+// Định dạng tài liệu nguồn của audiobook.
+const (
+	DocumentFormatText     = "txt"
+	DocumentFormatMarkdown = "md"
+	DocumentFormatHTML     = "html"
+	DocumentFormatEPUB     = "epub"
+)
+
+// documentFormats map đuôi file sang định dạng tài liệu.
+var documentFormats = map[string]string{
+	".txt":      DocumentFormatText,
+	".text":     DocumentFormatText,
+	".md":       DocumentFormatMarkdown,
+	".markdown": DocumentFormatMarkdown,
+	".html":     DocumentFormatHTML,
+	".htm":      DocumentFormatHTML,
+	".xhtml":    DocumentFormatHTML,
+	".epub":     DocumentFormatEPUB,
+}
+
+// DocumentFormatByName trả về định dạng tài liệu theo đuôi file (vd "book.epub" -> "epub").
+func DocumentFormatByName(name string) (string, bool) {
+	format, ok := documentFormats[strings.ToLower(path.Ext(name))]
+	return format, ok
+}
+
+// Document là tài liệu đã tách thành các chương.
+type Document struct {
+	Title    string            `json:"title"`
+	Format   string            `json:"format"`
+	Chapters []DocumentChapter `json:"chapters"`
+}
+
+// DocumentChapter là 1 chương: Text gồm các đoạn văn phân cách bằng dòng trống.
+type DocumentChapter struct {
+	Title string `json:"title"`
+	Text  string `json:"-"`
+}
+
+// AudiobookRequest là tuỳ chọn tạo audiobook, gửi dạng JSON trong field "options" của form cùng file tài liệu.
+type AudiobookRequest struct {
+	Title        string      `json:"title,omitempty"`          // tên sách, rỗng = lấy từ tài liệu / tên file
+	Options      *TTSOptions `json:"options,omitempty"`        // voice, bit_rate, sentence_gap_ms, paragraph_gap_ms, skip_lexicon, tempo, pitch_semitones, loudness
+	ChapterGapMs *int        `json:"chapter_gap_ms,omitempty"` // khoảng lặng cuối mỗi chương
+	SkipTitles   bool        `json:"skip_titles,omitempty"`    // không đọc tên chương ở đầu chương
+}
+
+// Validate kiểm tra request. Các chương luôn là MP3 (bản M4B encode AAC) và text tài liệu không phải markup.
+func (r *AudiobookRequest) Validate() error {
+	if len([]rune(r.Title)) > maxAudiobookTitleLength {
+		return fmt.Errorf("title is too long (max %d characters)", maxAudiobookTitleLength)
+	}
+	if r.Options != nil {
+		if r.Options.Encoding != "" && r.Options.Encoding != "mp3" {
+			return errors.New("audiobook chapters are always mp3, encoding cannot be set")
+		}
+		if r.Options.Markup {
+			return errors.New("markup is not supported for audiobooks")
+		}
+		if r.Options.Timings != "" {
+			return errors.New("timings are not supported for audiobooks")
+		}
+		if r.Options.TrimSilence {
+			return errors.New("trim_silence is not supported for audiobooks (it would remove the chapter gaps)")
+		}
+	}
+	if err := r.Options.Validate(); err != nil {
+		return err
+	}
+	if r.ChapterGapMs != nil && (*r.ChapterGapMs < 0 || *r.ChapterGapMs > maxTTSGapMs) {
+		return fmt.Errorf("chapter_gap_ms must be between 0 and %d", maxTTSGapMs)
+	}
+	return nil
+}
+
+// ChapterGap trả về khoảng lặng cuối mỗi chương (giây).
+func (r *AudiobookRequest) ChapterGap() float64 {
+	if r.ChapterGapMs != nil {
+		return float64(*r.ChapterGapMs) / 1000
+	}
+	return float64(DefaultAudiobookChapterGapMs) / 1000
+}
+
+// Audiobook là kết quả của 1 task audiobook: các chương MP3 và file M4B gộp có chapter marker.
+type Audiobook struct {
+	Task     *Task    `json:"task"`
+	Chapters []*Video `json:"chapters"`
+	Book     *Video   `json:"book,omitempty"` // nil khi task chưa xong
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	maxClipsPerRequest = 20
+	maxClipTitleRunes  = 120
+	minClipSec         = 0.5
+	// DefaultTitleCardSec là thời lượng mặc định của title card trước mỗi clip trong highlight reel.
+	DefaultTitleCardSec = 2.0
+)
+
+// ClipRange là 1 clip cần cắt: theo thời gian (Start / End, giây) hoặc theo khoảng utterance
+// (chỉ số trong SimpleTranscript.Utterances, tính cả 2 đầu).
+type ClipRange struct {
+	Start         *float64 `json:"start,omitempty"`
+	End           *float64 `json:"end,omitempty"`
+	UtteranceFrom *int     `json:"utterance_from,omitempty"`
+	UtteranceTo   *int     `json:"utterance_to,omitempty"`
+	Title         string   `json:"title,omitempty"` // chữ trên title card trước clip khi ghép highlight reel
+}
+
+// ByUtterance cho biết clip được chọn theo utterance thay vì theo thời gian.
+func (c ClipRange) ByUtterance() bool {
+	return c.UtteranceFrom != nil || c.UtteranceTo != nil
+}
+
+// ClipsRequest là body của POST /api/videos/:id/clips.
+type ClipsRequest struct {
+	TaskID       int64       `json:"task_id" binding:"required"` // task STT chứa transcript
+	Clips        []ClipRange `json:"clips" binding:"required"`
+	Reel         bool        `json:"reel,omitempty"`           // ghép các clip thành 1 highlight reel
+	TitleCardSec *float64    `json:"title_card_sec,omitempty"` // thời lượng title card trong reel (clip có title)
+	PaddingSec   *float64    `json:"padding_sec,omitempty"`    // nới thêm 2 đầu clip chọn theo utterance
+}
+
+// TitleCardDuration trả về thời lượng title card (giây).
+func (r *ClipsRequest) TitleCardDuration() float64 {
+	if r.TitleCardSec == nil {
+		return DefaultTitleCardSec
+	}
+	return *r.TitleCardSec
+}
+
+// Validate kiểm tra các clip (chỉ số utterance được kiểm tra khi đã đọc transcript).
+func (r *ClipsRequest) Validate() error {
+	if len(r.Clips) == 0 {
+		return fmt.Errorf("clips must not be empty")
+	}
+	if len(r.Clips) > maxClipsPerRequest {
+		return fmt.Errorf("at most %d clips per request", maxClipsPerRequest)
+	}
+	for i, c := range r.Clips {
+		byTime := c.Start != nil || c.End != nil
+		switch {
+		case byTime && c.ByUtterance():
+			return fmt.Errorf("clip %d: use either start/end or utterance_from/utterance_to", i)
+		case byTime:
+			if c.Start == nil || c.End == nil {
+				return fmt.Errorf("clip %d: start and end are required", i)
+			}
+			if *c.Start < 0 || *c.End-*c.Start < minClipSec {
+				return fmt.Errorf("clip %d: end must be at least %.1fs after start", i, minClipSec)
+			}
+		case c.ByUtterance():
+			if c.UtteranceFrom == nil || c.UtteranceTo == nil {
+				return fmt.Errorf("clip %d: utterance_from and utterance_to are required", i)
+			}
+			if *c.UtteranceFrom < 0 || *c.UtteranceTo < *c.UtteranceFrom {
+				return fmt.Errorf("clip %d: invalid utterance range [%d, %d]", i, *c.UtteranceFrom, *c.UtteranceTo)
+			}
+		default:
+			return fmt.Errorf("clip %d: start/end or utterance_from/utterance_to is required", i)
+		}
+		if utf8.RuneCountInString(c.Title) > maxClipTitleRunes {
+			return fmt.Errorf("clip %d: title must be at most %d characters", i, maxClipTitleRunes)
+		}
+	}
+	if r.TitleCardSec != nil && (*r.TitleCardSec < 0.5 || *r.TitleCardSec > 10) {
+		return fmt.Errorf("title_card_sec must be between 0.5 and 10")
+	}
+	if r.PaddingSec != nil && (*r.PaddingSec < 0 || *r.PaddingSec > 5) {
+		return fmt.Errorf("padding_sec must be between 0 and 5")
+	}
+	return nil
+}
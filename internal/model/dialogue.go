@@ -0,0 +1,162 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxDialogueLines    = 500
+	maxDialogueSpeakers = 10
+	// Khoảng lặng mặc định giữa 2 câu thoại: cùng người nói / đổi người nói.
+	DefaultDialogueLineGapMs = 300
+	DefaultDialogueTurnGapMs = 600
+)
+
+// dialogueLinePattern khớp 1 dòng thoại dạng "[Anna]: text".
+var dialogueLinePattern = regexp.MustCompile(`^(\s*)\[([^\]]+)\]\s*:\s*`)
+
+// DialogueLine là 1 câu thoại trong script. Text có thể gồm nhiều dòng (dòng nối tiếp), dòng thứ k của
+// Text nằm ở dòng Lines[k], cột Columns[k] của script (tính từ 1) - dùng để báo lỗi markup đúng vị trí.
+type DialogueLine struct {
+	Speaker string
+	Text    string
+	Lines   []int
+	Columns []int
+}
+
+// Line là dòng của tag [Speaker] trong script.
+func (l DialogueLine) Line() int {
+	return l.Lines[0]
+}
+
+// Dialogue là script hội thoại đã parse, kèm voice của từng speaker.
+type Dialogue struct {
+	Lines     []DialogueLine    `json:"-"`
+	Speakers  map[string]string `json:"speakers"` // tên speaker -> voice ID
+	LineGapMs int               `json:"line_gap_ms"`
+	TurnGapMs int               `json:"turn_gap_ms"`
+}
+
+// DialogueRequest là payload tổng hợp 1 script hội thoại nhiều người nói thành 1 file audio.
+type DialogueRequest struct {
+	Script    string            `json:"script" binding:"required"`   // mỗi câu thoại bắt đầu bằng "[Tên]: ", dòng không có tag nối vào câu trước
+	Speakers  map[string]string `json:"speakers" binding:"required"` // tên speaker -> voice ID trong catalog
+	Options   *TTSOptions       `json:"options"`                     // encoding, sample_rate, bit_rate, markup, skip_lexicon (timing luôn theo câu thoại)
+	LineGapMs *int              `json:"line_gap_ms"`                 // khoảng lặng giữa 2 câu cùng người nói
+	TurnGapMs *int              `json:"turn_gap_ms"`                 // khoảng lặng khi đổi người nói
+}
+
+// Parse kiểm tra request và parse script thành Dialogue.
+func (r *DialogueRequest) Parse() (*Dialogue, error) {
+	if err := r.Options.Validate(); err != nil {
+		return nil, err
+	}
+	if r.Options != nil && r.Options.Timings == TTSTimingsWords {
+		return nil, errors.New("dialogue timings are per line, timings=words is not supported")
+	}
+	if len(r.Speakers) == 0 {
+		return nil, errors.New("speakers is required")
+	}
+	if len(r.Speakers) > maxDialogueSpeakers {
+		return nil, fmt.Errorf("too many speakers (max %d)", maxDialogueSpeakers)
+	}
+	speakers := make(map[string]string, len(r.Speakers))
+	for name, voice := range r.Speakers {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, errors.New("speaker name must not be empty")
+		}
+		if _, ok := LookupTTSVoice(voice); !ok {
+			return nil, fmt.Errorf("unsupported voice %q for speaker %q", voice, name)
+		}
+		speakers[name] = voice
+	}
+
+	d := &Dialogue{Speakers: speakers, LineGapMs: DefaultDialogueLineGapMs, TurnGapMs: DefaultDialogueTurnGapMs}
+	for field, gap := range map[string]*int{"line_gap_ms": r.LineGapMs, "turn_gap_ms": r.TurnGapMs} {
+		if gap != nil && (*gap < 0 || *gap > maxTTSGapMs) {
+			return nil, fmt.Errorf("%s must be between 0 and %d", field, maxTTSGapMs)
+		}
+	}
+	if r.LineGapMs != nil {
+		d.LineGapMs = *r.LineGapMs
+	}
+	if r.TurnGapMs != nil {
+		d.TurnGapMs = *r.TurnGapMs
+	}
+
+	lines, err := ParseDialogueScript(r.Script)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		if _, ok := speakers[l.Speaker]; !ok {
+			return nil, fmt.Errorf("line %d: speaker %q has no voice in speakers", l.Line(), l.Speaker)
+		}
+	}
+	d.Lines = lines
+	return d, nil
+}
+
+// ParseDialogueScript tách script thành các câu thoại. Dòng trống và dòng bắt đầu bằng "#" được bỏ qua,
+// dòng không có tag [Speaker] được nối vào câu thoại trước đó.
+func ParseDialogueScript(script string) ([]DialogueLine, error) {
+	var lines []DialogueLine
+	for i, raw := range strings.Split(script, "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if m := dialogueLinePattern.FindStringSubmatchIndex(raw); m != nil {
+			speaker := strings.TrimSpace(raw[m[4]:m[5]])
+			if speaker == "" {
+				return nil, fmt.Errorf("line %d: speaker name must not be empty", i+1)
+			}
+			text := strings.TrimSpace(raw[m[1]:])
+			lines = append(lines, DialogueLine{
+				Speaker: speaker,
+				Text:    text,
+				Lines:   []int{i + 1},
+				Columns: []int{utf8.RuneCountInString(raw[:m[1]]) + 1},
+			})
+			continue
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("line %d: expected \"[Speaker]: text\"", i+1)
+		}
+		last := &lines[len(lines)-1]
+		last.Text += "\n" + trimmed
+		last.Lines = append(last.Lines, i+1)
+		last.Columns = append(last.Columns, utf8.RuneCountInString(raw[:strings.Index(raw, trimmed)])+1)
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("script has no dialogue lines")
+	}
+	if len(lines) > maxDialogueLines {
+		return nil, fmt.Errorf("too many dialogue lines: %d (max %d)", len(lines), maxDialogueLines)
+	}
+	for _, l := range lines {
+		if strings.TrimSpace(l.Text) == "" {
+			return nil, fmt.Errorf("line %d: %s has no text", l.Line(), l.Speaker)
+		}
+	}
+	return lines, nil
+}
+
+// SpeakerIndex trả về chỉ số các speaker theo thứ tự xuất hiện đầu tiên trong script.
+func (d *Dialogue) SpeakerIndex() map[string]int {
+	index := make(map[string]int)
+	for _, l := range d.Lines {
+		if _, ok := index[l.Speaker]; !ok {
+			index[l.Speaker] = len(index)
+		}
+	}
+	return index
+}
@@ -0,0 +1,59 @@
+package model
+
+import "fmt"
+
+// WordRange là khoảng từ trong transcript (chỉ số trong SimpleTranscript.Words, tính cả From và To).
+type WordRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// EditVideoRequest là body của POST /api/videos/:id/edit: cắt video theo transcript như sửa văn bản.
+// Remove xoá các khoảng từ, Keep chỉ giữ các khoảng từ (không dùng cùng lúc); có thể kết hợp với
+// tự động xoá từ đệm và rút ngắn khoảng lặng dài.
+type EditVideoRequest struct {
+	TaskID        int64       `json:"task_id" binding:"required"` // task STT chứa transcript
+	Remove        []WordRange `json:"remove,omitempty"`
+	Keep          []WordRange `json:"keep,omitempty"`
+	RemoveFillers bool        `json:"remove_fillers,omitempty"` // xoá từ đệm (uh, um, ờ, ừm, ...)
+	MaxPauseSec   *float64    `json:"max_pause_sec,omitempty"`  // khoảng lặng giữa 2 từ dài hơn sẽ được rút về đúng giá trị này
+	CrossfadeMs   *int        `json:"crossfade_ms,omitempty"`   // độ dài crossfade ở mỗi điểm cắt, 0 = cắt thẳng
+}
+
+const (
+	// DefaultEditCrossfadeMs là crossfade mặc định ở điểm cắt, đủ để không nghe tiếng "bụp".
+	DefaultEditCrossfadeMs = 40
+	maxEditCrossfadeMs     = 500
+)
+
+// Crossfade trả về độ dài crossfade (giây).
+func (r *EditVideoRequest) Crossfade() float64 {
+	if r.CrossfadeMs == nil {
+		return DefaultEditCrossfadeMs / 1000.0
+	}
+	return float64(*r.CrossfadeMs) / 1000
+}
+
+// Validate kiểm tra các option không phụ thuộc transcript (chỉ số từ được kiểm tra khi đã đọc transcript).
+func (r *EditVideoRequest) Validate() error {
+	if len(r.Remove) > 0 && len(r.Keep) > 0 {
+		return fmt.Errorf("remove and keep cannot be used together")
+	}
+	if len(r.Remove) == 0 && len(r.Keep) == 0 && !r.RemoveFillers && r.MaxPauseSec == nil {
+		return fmt.Errorf("nothing to edit: set remove, keep, remove_fillers or max_pause_sec")
+	}
+	for _, ranges := range [][]WordRange{r.Remove, r.Keep} {
+		for _, wr := range ranges {
+			if wr.From < 0 || wr.To < wr.From {
+				return fmt.Errorf("invalid word range [%d, %d]", wr.From, wr.To)
+			}
+		}
+	}
+	if r.MaxPauseSec != nil && (*r.MaxPauseSec < 0.1 || *r.MaxPauseSec > 60) {
+		return fmt.Errorf("max_pause_sec must be between 0.1 and 60")
+	}
+	if r.CrossfadeMs != nil && (*r.CrossfadeMs < 0 || *r.CrossfadeMs > maxEditCrossfadeMs) {
+		return fmt.Errorf("crossfade_ms must be between 0 and %d", maxEditCrossfadeMs)
+	}
+	return nil
+}
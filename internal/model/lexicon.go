@@ -0,0 +1,94 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// MaxLexiconEntries là số entry tối đa trong lexicon của 1 user.
+	MaxLexiconEntries    = 500
+	maxLexiconWordLength = 100
+	maxLexiconReplace    = 300
+)
+
+// Loại entry: respelling = cách viết phiên âm để provider đọc đúng ("Nguyen" -> "Nwin"),
+// alias = đọc 1 cụm khác thay cho từ ("WHO" -> "World Health Organization").
+const (
+	LexiconKindRespelling = "respelling"
+	LexiconKindAlias      = "alias"
+)
+
+var lexiconLanguagePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// LexiconEntry represents a row in the `tts_lexicon_entries` table:
+// 1 từ / cụm từ được thay bằng Replacement trước khi gửi text cho speech provider.
+type LexiconEntry struct {
+	ID          int64     `db:"id" json:"id"`
+	UserID      int64     `db:"user_id" json:"user_id"`
+	Word        string    `db:"word" json:"word"`
+	Replacement string    `db:"replacement" json:"replacement"`
+	Kind        string    `db:"kind" json:"kind"`
+	Language    *string   `db:"language" json:"language,omitempty"` // mã ngôn ngữ 2-3 ký tự (vd "en"), nil = mọi ngôn ngữ
+	MatchCase   bool      `db:"match_case" json:"match_case"`       // true: chỉ khớp đúng hoa / thường (vd "US" khác "us")
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// LexiconEntryRequest là payload tạo / cập nhật lexicon entry.
+type LexiconEntryRequest struct {
+	Word        string  `json:"word" binding:"required"`
+	Replacement string  `json:"replacement" binding:"required"`
+	Kind        string  `json:"kind"` // respelling / alias, mặc định alias
+	Language    *string `json:"language"`
+	MatchCase   bool    `json:"match_case"`
+}
+
+// Normalize trim các field text (đưa về Unicode NFC: "é" gõ tổ hợp hay dựng sẵn đều khớp như nhau),
+// đưa language về chữ thường và áp kind mặc định.
+func (e *LexiconEntry) Normalize() {
+	e.Word = norm.NFC.String(strings.Join(strings.Fields(e.Word), " "))
+	e.Replacement = norm.NFC.String(strings.Join(strings.Fields(e.Replacement), " "))
+	if e.Kind == "" {
+		e.Kind = LexiconKindAlias
+	}
+	if e.Language != nil {
+		lang := strings.ToLower(strings.TrimSpace(*e.Language))
+		if lang == "" {
+			e.Language = nil
+		} else {
+			e.Language = &lang
+		}
+	}
+}
+
+// Validate kiểm tra word, replacement, kind và language.
+func (e *LexiconEntry) Validate() error {
+	if e.Word == "" {
+		return errors.New("word is required")
+	}
+	if len([]rune(e.Word)) > maxLexiconWordLength {
+		return fmt.Errorf("word is too long (max %d characters)", maxLexiconWordLength)
+	}
+	if e.Replacement == "" {
+		return errors.New("replacement is required")
+	}
+	if len([]rune(e.Replacement)) > maxLexiconReplace {
+		return fmt.Errorf("replacement is too long (max %d characters)", maxLexiconReplace)
+	}
+	if strings.ContainsAny(e.Replacement, "<>") {
+		return errors.New("replacement must not contain markup")
+	}
+	if e.Kind != LexiconKindRespelling && e.Kind != LexiconKindAlias {
+		return fmt.Errorf("kind must be %q or %q", LexiconKindRespelling, LexiconKindAlias)
+	}
+	if e.Language != nil && !lexiconLanguagePattern.MatchString(*e.Language) {
+		return fmt.Errorf("language %q must be a 2-3 letter language code (e.g. \"en\")", *e.Language)
+	}
+	return nil
+}
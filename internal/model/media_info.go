@@ -0,0 +1,37 @@
+package model
+
+import "errors"
+
+var (
+	// ErrCorruptMedia trả về khi ffprobe không đọc được file, file không có độ dài hoặc không có
+	// audio / video stream nào.
+	ErrCorruptMedia = errors.New("media file is corrupt or unsupported")
+	// ErrNoAudioStream trả về khi file không có audio, không thể transcribe.
+	ErrNoAudioStream = errors.New("media file has no audio stream")
+)
+
+// MediaInfo là thông tin kỹ thuật của file media (kết quả ffprobe).
+type MediaInfo struct {
+	DurationSec   float64 `json:"duration_sec"`
+	Container     string  `json:"container"`
+	VideoCodec    string  `json:"video_codec,omitempty"` // rỗng = file chỉ có audio
+	AudioCodec    string  `json:"audio_codec,omitempty"`
+	AudioChannels int     `json:"audio_channels,omitempty"`
+	SampleRate    int     `json:"sample_rate,omitempty"`
+	Width         int     `json:"width,omitempty"`
+	Height        int     `json:"height,omitempty"`
+	BitRate       int64   `json:"bit_rate,omitempty"`
+	SizeBytes     int64   `json:"size_bytes,omitempty"`
+}
+
+// Validate kiểm tra file có thể đưa vào STT: phải có độ dài và có audio stream. File không có cả audio
+// lẫn video thì coi là hỏng (render / cắt clip cũng không dùng được).
+func (m *MediaInfo) Validate() error {
+	if m.DurationSec <= 0 || (m.AudioCodec == "" && m.VideoCodec == "") {
+		return ErrCorruptMedia
+	}
+	if m.AudioCodec == "" {
+		return ErrNoAudioStream
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package model
+
+import "fmt"
+
+// Mức chuẩn hoá loudness (bản mix nhạc nền, hậu xử lý audio TTS).
+const (
+	LoudnessWeb       = "web"       // -16 LUFS: web / podcast / mạng xã hội
+	LoudnessBroadcast = "broadcast" // -23 LUFS: EBU R128 cho truyền hình
+	LoudnessNone      = "none"      // không chuẩn hoá loudness
+)
+
+// LoudnessTarget là mục tiêu chuẩn hoá loudness (filter loudnorm).
+type LoudnessTarget struct {
+	Integrated float64 // LUFS
+	TruePeak   float64 // dBTP
+	Range      float64 // LU
+}
+
+var loudnessTargets = map[string]LoudnessTarget{
+	LoudnessWeb:       {Integrated: -16, TruePeak: -1.5, Range: 11},
+	LoudnessBroadcast: {Integrated: -23, TruePeak: -1, Range: 15},
+}
+
+// Định dạng file mix đầu ra.
+var mixFormats = map[string]string{
+	"m4a": ".m4a",
+	"mp3": ".mp3",
+	"wav": ".wav",
+}
+
+const (
+	DefaultMixMusicVolumeDb = -12.0
+	DefaultMixDuckRatio     = 8.0
+	DefaultMixFadeInMs      = 2000
+	DefaultMixFadeOutMs     = 3000
+	maxMixFadeMs            = 30000
+	maxMixPadMs             = 60000
+)
+
+// MixMusicRequest là body của POST /api/tts/:id/mix: trộn nhạc nền (1 file user đã upload) dưới giọng
+// đọc của task TTS. Nhạc tự nhỏ xuống khi có tiếng nói (sidechain ducking), fade in / out ở 2 đầu, bản
+// mix được chuẩn hoá loudness theo mục tiêu web hoặc broadcast.
+type MixMusicRequest struct {
+	MusicVideoID  int64    `json:"music_video_id" binding:"required"` // video / audio nhạc nền (của user)
+	MusicVolumeDb *float64 `json:"music_volume_db,omitempty"`         // mức nhạc so với gốc khi không có giọng đọc, mặc định -12
+	Duck          *bool    `json:"duck,omitempty"`                    // nhỏ nhạc khi có giọng đọc, mặc định true
+	DuckRatio     *float64 `json:"duck_ratio,omitempty"`              // độ nén của sidechain (1..20), càng lớn nhạc càng nhỏ dưới giọng đọc
+	FadeInMs      *int     `json:"fade_in_ms,omitempty"`
+	FadeOutMs     *int     `json:"fade_out_ms,omitempty"`
+	LeadInMs      int      `json:"lead_in_ms,omitempty"` // nhạc chạy trước khi giọng đọc bắt đầu
+	TailMs        int      `json:"tail_ms,omitempty"`    // nhạc còn chạy sau khi giọng đọc kết thúc
+	Loop          *bool    `json:"loop,omitempty"`       // lặp nhạc khi ngắn hơn bản mix, mặc định true
+	Loudness      string   `json:"loudness,omitempty"`   // web / broadcast / none, mặc định web
+	Format        string   `json:"format,omitempty"`     // m4a / mp3 / wav, mặc định m4a
+}
+
+// WithDefaults trả về bản copy của r với các giá trị mặc định.
+func (r *MixMusicRequest) WithDefaults() *MixMusicRequest {
+	out := *r
+	if out.MusicVolumeDb == nil {
+		v := DefaultMixMusicVolumeDb
+		out.MusicVolumeDb = &v
+	}
+	if out.Duck == nil {
+		on := true
+		out.Duck = &on
+	}
+	if out.DuckRatio == nil {
+		v := DefaultMixDuckRatio
+		out.DuckRatio = &v
+	}
+	if out.FadeInMs == nil {
+		v := DefaultMixFadeInMs
+		out.FadeInMs = &v
+	}
+	if out.FadeOutMs == nil {
+		v := DefaultMixFadeOutMs
+		out.FadeOutMs = &v
+	}
+	if out.Loop == nil {
+		on := true
+		out.Loop = &on
+	}
+	if out.Loudness == "" {
+		out.Loudness = LoudnessWeb
+	}
+	if out.Format == "" {
+		out.Format = "m4a"
+	}
+	return &out
+}
+
+// Validate kiểm tra các option của request.
+func (r *MixMusicRequest) Validate() error {
+	if r.MusicVolumeDb != nil && (*r.MusicVolumeDb < -40 || *r.MusicVolumeDb > 6) {
+		return fmt.Errorf("music_volume_db must be between -40 and 6")
+	}
+	if r.DuckRatio != nil && (*r.DuckRatio < 1 || *r.DuckRatio > 20) {
+		return fmt.Errorf("duck_ratio must be between 1 and 20")
+	}
+	for field, ms := range map[string]*int{"fade_in_ms": r.FadeInMs, "fade_out_ms": r.FadeOutMs} {
+		if ms != nil && (*ms < 0 || *ms > maxMixFadeMs) {
+			return fmt.Errorf("%s must be between 0 and %d", field, maxMixFadeMs)
+		}
+	}
+	for field, ms := range map[string]int{"lead_in_ms": r.LeadInMs, "tail_ms": r.TailMs} {
+		if ms < 0 || ms > maxMixPadMs {
+			return fmt.Errorf("%s must be between 0 and %d", field, maxMixPadMs)
+		}
+	}
+	switch r.Loudness {
+	case "", LoudnessWeb, LoudnessBroadcast, LoudnessNone:
+	default:
+		return fmt.Errorf("loudness must be %q, %q or %q", LoudnessWeb, LoudnessBroadcast, LoudnessNone)
+	}
+	if _, ok := mixFormats[r.Format]; r.Format != "" && !ok {
+		return fmt.Errorf("format must be m4a, mp3 or wav")
+	}
+	return nil
+}
+
+// LoudnessTarget trả về mục tiêu loudness, false khi không chuẩn hoá.
+func (r *MixMusicRequest) LoudnessTarget() (LoudnessTarget, bool) {
+	return LookupLoudnessTarget(r.Loudness)
+}
+
+// LookupLoudnessTarget trả về mục tiêu loudness theo tên (web / broadcast), false với none / rỗng.
+func LookupLoudnessTarget(name string) (LoudnessTarget, bool) {
+	t, ok := loudnessTargets[name]
+	return t, ok
+}
+
+// Extension trả về đuôi file của bản mix.
+func (r *MixMusicRequest) Extension() string {
+	if ext, ok := mixFormats[r.Format]; ok {
+		return ext
+	}
+	return ".m4a"
+}
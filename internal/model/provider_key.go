@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// ProviderKeyStatus là trạng thái của 1 API key trong pool.
+type ProviderKeyStatus string
+
+const (
+	ProviderKeyStatusActive   ProviderKeyStatus = "active"
+	ProviderKeyStatusDisabled ProviderKeyStatus = "disabled"
+)
+
+// ProviderKey represents a row in the `provider_keys` table.
+// Key thật được mã hoá (AES-GCM) trong KeyEncrypted và không bao giờ trả ra API.
+type ProviderKey struct {
+	ID             int64             `db:"id" json:"id"`
+	Provider       string            `db:"provider" json:"provider"` // tên provider trong speech router (vd: "deepgram")
+	Name           string            `db:"name" json:"name"`
+	KeyEncrypted   string            `db:"key_encrypted" json:"-"`
+	KeyHint        string            `db:"key_hint" json:"key_hint"` // 4 ký tự cuối để nhận diện key
+	Weight         int               `db:"weight" json:"weight"`
+	QuotaLimit     *int64            `db:"quota_limit" json:"quota_limit,omitempty"` // số request tối đa, NULL = không giới hạn
+	UsageCount     int64             `db:"usage_count" json:"usage_count"`
+	Status         ProviderKeyStatus `db:"status" json:"status"`
+	DisabledReason *string           `db:"disabled_reason" json:"disabled_reason,omitempty"`
+	CooldownUntil  *time.Time        `db:"cooldown_until" json:"cooldown_until,omitempty"`
+	LastUsedAt     *time.Time        `db:"last_used_at" json:"last_used_at,omitempty"`
+	LastError      *string           `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt      time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time         `db:"updated_at" json:"updated_at"`
+
+	RemainingQuota *int64 `db:"-" json:"remaining_quota,omitempty"`
+}
+
+// Remaining trả về số request còn lại trong quota (nil = không giới hạn).
+func (k *ProviderKey) Remaining() *int64 {
+	if k.QuotaLimit == nil {
+		return nil
+	}
+	remaining := *k.QuotaLimit - k.UsageCount
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
+
+// CreateProviderKeyRequest là payload thêm key mới vào pool.
+type CreateProviderKeyRequest struct {
+	Provider   string `json:"provider"` // mặc định "deepgram"
+	Name       string `json:"name" binding:"required"`
+	Key        string `json:"key" binding:"required"`
+	Weight     int    `json:"weight"`
+	QuotaLimit *int64 `json:"quota_limit"`
+}
+
+// RotateProviderKeyRequest là payload thay key mới cho 1 slot trong pool.
+type RotateProviderKeyRequest struct {
+	Key        string `json:"key" binding:"required"`
+	Weight     *int   `json:"weight"`
+	QuotaLimit *int64 `json:"quota_limit"`
+	ResetUsage bool   `json:"reset_usage"`
+}
+
+// DisableProviderKeyRequest là payload tắt 1 key.
+type DisableProviderKeyRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RenderPreset là kích thước khung hình của video render ra.
+// Width/Height = 0 giữ nguyên kích thước video gốc; Crop = true thì cắt cho đầy khung
+// (video dọc / vuông cho mạng xã hội), ngược lại thu nhỏ và thêm viền đen.
+type RenderPreset struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Crop   bool   `json:"crop"`
+}
+
+// renderPresets là các preset độ phân giải được hỗ trợ.
+var renderPresets = map[string]RenderPreset{
+	"source":        {Name: "source"},
+	"1080p":         {Name: "1080p", Width: 1920, Height: 1080},
+	"720p":          {Name: "720p", Width: 1280, Height: 720},
+	"vertical_1080": {Name: "vertical_1080", Width: 1080, Height: 1920, Crop: true},
+	"square_1080":   {Name: "square_1080", Width: 1080, Height: 1080, Crop: true},
+}
+
+// LookupRenderPreset trả về preset theo tên, rỗng = "source".
+func LookupRenderPreset(name string) (RenderPreset, bool) {
+	if name == "" {
+		name = "source"
+	}
+	p, ok := renderPresets[name]
+	return p, ok
+}
+
+// Vị trí phụ đề trên khung hình.
+const (
+	CaptionPositionBottom = "bottom"
+	CaptionPositionMiddle = "middle"
+	CaptionPositionTop    = "top"
+)
+
+var (
+	hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+	// Tên font nằm trong dòng Style của file ASS (phân cách bằng dấu phẩy) nên chỉ cho phép ký tự an toàn.
+	fontNamePattern = regexp.MustCompile(`^[A-Za-z0-9 ._-]{1,64}$`)
+)
+
+// CaptionStyle là kiểu chữ phụ đề khi burn-in. FontSize / MarginV tính bằng pixel của video đầu ra,
+// 0 = tự tính theo chiều cao khung hình.
+type CaptionStyle struct {
+	Font              string   `json:"font,omitempty"`
+	FontSize          int      `json:"font_size,omitempty"`
+	Bold              *bool    `json:"bold,omitempty"`
+	PrimaryColor      string   `json:"primary_color,omitempty"`   // #RRGGBB màu chữ
+	OutlineColor      string   `json:"outline_color,omitempty"`   // #RRGGBB màu viền chữ
+	HighlightColor    string   `json:"highlight_color,omitempty"` // #RRGGBB màu từ đang đọc (word_highlight)
+	Position          string   `json:"position,omitempty"`        // bottom / middle / top
+	MarginV           int      `json:"margin_v,omitempty"`
+	BackgroundBox     *bool    `json:"background_box,omitempty"`     // nền hộp phía sau chữ thay cho viền
+	BackgroundColor   string   `json:"background_color,omitempty"`   // #RRGGBB màu nền hộp
+	BackgroundOpacity *float64 `json:"background_opacity,omitempty"` // 0..1
+	WordHighlight     *bool    `json:"word_highlight,omitempty"`     // tô màu từng từ theo thời điểm đọc (karaoke)
+}
+
+// DefaultCaptionStyle là kiểu phụ đề mặc định: chữ trắng viền đen ở cạnh dưới.
+func DefaultCaptionStyle() CaptionStyle {
+	on, off := true, false
+	opacity := 0.6
+	return CaptionStyle{
+		Font:              "Arial",
+		Bold:              &on,
+		PrimaryColor:      "#FFFFFF",
+		OutlineColor:      "#000000",
+		HighlightColor:    "#FFD700",
+		Position:          CaptionPositionBottom,
+		BackgroundBox:     &off,
+		BackgroundColor:   "#000000",
+		BackgroundOpacity: &opacity,
+		WordHighlight:     &off,
+	}
+}
+
+// WithDefaults trả về bản copy của s, field chưa set lấy từ DefaultCaptionStyle.
+func (s CaptionStyle) WithDefaults() CaptionStyle {
+	d := DefaultCaptionStyle()
+	if s.Font == "" {
+		s.Font = d.Font
+	}
+	if s.Bold == nil {
+		s.Bold = d.Bold
+	}
+	if s.PrimaryColor == "" {
+		s.PrimaryColor = d.PrimaryColor
+	}
+	if s.OutlineColor == "" {
+		s.OutlineColor = d.OutlineColor
+	}
+	if s.HighlightColor == "" {
+		s.HighlightColor = d.HighlightColor
+	}
+	if s.Position == "" {
+		s.Position = d.Position
+	}
+	if s.BackgroundBox == nil {
+		s.BackgroundBox = d.BackgroundBox
+	}
+	if s.BackgroundColor == "" {
+		s.BackgroundColor = d.BackgroundColor
+	}
+	if s.BackgroundOpacity == nil {
+		s.BackgroundOpacity = d.BackgroundOpacity
+	}
+	if s.WordHighlight == nil {
+		s.WordHighlight = d.WordHighlight
+	}
+	return s
+}
+
+// Validate kiểm tra style do user gửi lên.
+func (s CaptionStyle) Validate() error {
+	if s.Font != "" && !fontNamePattern.MatchString(s.Font) {
+		return fmt.Errorf("invalid font %q", s.Font)
+	}
+	if s.FontSize < 0 || s.FontSize > 300 {
+		return fmt.Errorf("font_size must be between 1 and 300")
+	}
+	if s.MarginV < 0 || s.MarginV > 1000 {
+		return fmt.Errorf("margin_v must be between 0 and 1000")
+	}
+	for name, c := range map[string]string{
+		"primary_color":    s.PrimaryColor,
+		"outline_color":    s.OutlineColor,
+		"highlight_color":  s.HighlightColor,
+		"background_color": s.BackgroundColor,
+	} {
+		if c != "" && !hexColorPattern.MatchString(c) {
+			return fmt.Errorf("%s must be in #RRGGBB format", name)
+		}
+	}
+	switch s.Position {
+	case "", CaptionPositionBottom, CaptionPositionMiddle, CaptionPositionTop:
+	default:
+		return fmt.Errorf("unsupported position %q", s.Position)
+	}
+	if s.BackgroundOpacity != nil && (*s.BackgroundOpacity < 0 || *s.BackgroundOpacity > 1) {
+		return fmt.Errorf("background_opacity must be between 0 and 1")
+	}
+	return nil
+}
+
+// RenderCaptionsRequest là body của POST /api/videos/:id/render-captions.
+type RenderCaptionsRequest struct {
+	TaskID int64        `json:"task_id" binding:"required"` // task STT chứa transcript
+	Style  CaptionStyle `json:"style"`
+	Preset string       `json:"preset,omitempty"` // xem renderPresets, mặc định "source"
+}
+
+// Validate kiểm tra preset và style.
+func (r *RenderCaptionsRequest) Validate() error {
+	if _, ok := LookupRenderPreset(r.Preset); !ok {
+		names := make([]string, 0, len(renderPresets))
+		for name := range renderPresets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unsupported preset %q (supported: %s)", r.Preset, strings.Join(names, ", "))
+	}
+	return r.Style.Validate()
+}
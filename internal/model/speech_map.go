@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// SpeechSegment là 1 khoảng thời gian (giây) trong file media.
+type SpeechSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Duration trả về độ dài đoạn (giây).
+func (s SpeechSegment) Duration() float64 {
+	return s.End - s.Start
+}
+
+// SpeechMap represents a row in the `video_speech_maps` table:
+// kết quả VAD của 1 video - các đoạn có tiếng nói, phần còn lại là khoảng lặng.
+type SpeechMap struct {
+	VideoID       int64           `db:"video_id" json:"video_id"`
+	DurationSec   float64         `db:"duration_sec" json:"duration_sec"`
+	SpeechSec     float64         `db:"speech_sec" json:"speech_sec"`
+	SpeechRatio   float64         `db:"-" json:"speech_ratio"` // SpeechSec / DurationSec
+	NoiseDB       float64         `db:"noise_db" json:"noise_db"`
+	MinSilenceSec float64         `db:"min_silence_sec" json:"min_silence_sec"`
+	Segments      []SpeechSegment `db:"segments" json:"segments"`
+	Silences      []SpeechSegment `db:"-" json:"silences"` // phần bù của Segments trong [0, DurationSec]
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// Fill tính lại các field dẫn xuất từ Segments (SpeechSec, SpeechRatio, Silences).
+func (m *SpeechMap) Fill() {
+	m.SpeechSec = 0
+	m.Silences = []SpeechSegment{}
+	pos := 0.0
+	for _, s := range m.Segments {
+		m.SpeechSec += s.Duration()
+		if s.Start > pos {
+			m.Silences = append(m.Silences, SpeechSegment{Start: pos, End: s.Start})
+		}
+		pos = s.End
+	}
+	if m.DurationSec > pos {
+		m.Silences = append(m.Silences, SpeechSegment{Start: pos, End: m.DurationSec})
+	}
+	m.SpeechRatio = 0
+	if m.DurationSec > 0 {
+		m.SpeechRatio = m.SpeechSec / m.DurationSec
+	}
+}
+
+// OriginalTime đổi thời điểm t trên audio chỉ gồm các đoạn tiếng nói ghép liền nhau
+// về thời điểm tương ứng trên file gốc. isEnd = true thì t nằm đúng ranh giới 2 đoạn
+// được tính là cuối đoạn trước (dùng cho thời điểm kết thúc của từ).
+func (m *SpeechMap) OriginalTime(t float64, isEnd bool) float64 {
+	if len(m.Segments) == 0 {
+		return t
+	}
+	offset := 0.0
+	for _, s := range m.Segments {
+		d := s.Duration()
+		if t < offset+d || (isEnd && t <= offset+d) {
+			if t < offset {
+				t = offset
+			}
+			return s.Start + (t - offset)
+		}
+		offset += d
+	}
+	last := m.Segments[len(m.Segments)-1]
+	return last.End + (t - offset)
+}
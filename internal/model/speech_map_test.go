@@ -0,0 +1,53 @@
+package model
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSpeechMapOriginalTime(t *testing.T) {
+	// Audio ghép: [0, 2) -> [1, 3), [2, 3) -> [5, 6), [3, 5) -> [10, 12).
+	m := &SpeechMap{Segments: []SpeechSegment{{Start: 1, End: 3}, {Start: 5, End: 6}, {Start: 10, End: 12}}}
+
+	tests := []struct {
+		t     float64
+		isEnd bool
+		want  float64
+	}{
+		{0, false, 1},
+		{1.5, false, 2.5},
+		{2, false, 5},
+		{2, true, 3},
+		{2.5, false, 5.5},
+		{3, false, 10},
+		{3, true, 6},
+		{4, false, 11},
+		{5, false, 12},
+		{5, true, 12},
+		{6, false, 13},
+	}
+	for _, tt := range tests {
+		if got := m.OriginalTime(tt.t, tt.isEnd); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("OriginalTime(%g, %v) = %g, want %g", tt.t, tt.isEnd, got, tt.want)
+		}
+	}
+
+	empty := &SpeechMap{}
+	if got := empty.OriginalTime(4.2, false); got != 4.2 {
+		t.Errorf("empty map OriginalTime(4.2) = %g, want 4.2", got)
+	}
+}
+
+func TestSpeechMapFill(t *testing.T) {
+	m := &SpeechMap{DurationSec: 15, Segments: []SpeechSegment{{Start: 1, End: 3}, {Start: 5, End: 6}, {Start: 10, End: 12}}}
+	m.Fill()
+
+	want := []SpeechSegment{{Start: 0, End: 1}, {Start: 3, End: 5}, {Start: 6, End: 10}, {Start: 12, End: 15}}
+	if !slices.Equal(m.Silences, want) {
+		t.Errorf("silences = %v, want %v", m.Silences, want)
+	}
+	if m.SpeechSec != 5 || math.Abs(m.SpeechRatio-1.0/3) > 1e-9 {
+		t.Errorf("speech = %gs (ratio %g), want 5s (ratio 1/3)", m.SpeechSec, m.SpeechRatio)
+	}
+}
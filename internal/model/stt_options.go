@@ -0,0 +1,302 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LanguageAuto là giá trị language đặc biệt: để provider tự nhận diện ngôn ngữ.
+const LanguageAuto = "auto"
+
+const (
+	maxKeyterms      = 100
+	maxKeytermLength = 100
+	minUttSplit      = 0.1
+	maxUttSplit      = 5.0
+)
+
+// allowedSTTModels là danh sách model Deepgram được phép dùng cho STT.
+var allowedSTTModels = map[string]bool{
+	"nova-3":                  true,
+	"nova-3-general":          true,
+	"nova-3-medical":          true,
+	"nova-2":                  true,
+	"nova-2-general":          true,
+	"nova-2-meeting":          true,
+	"nova-2-phonecall":        true,
+	"nova-2-finance":          true,
+	"nova-2-medical":          true,
+	"nova-2-video":            true,
+	"nova-2-conversationalai": true,
+	"enhanced":                true,
+	"base":                    true,
+}
+
+// allowedSTTLanguages là các mã ngôn ngữ (BCP-47) được phép, cộng thêm LanguageAuto.
+var allowedSTTLanguages = map[string]bool{
+	LanguageAuto: true,
+	"multi":      true,
+	"en":         true,
+	"en-US":      true,
+	"en-GB":      true,
+	"en-AU":      true,
+	"en-IN":      true,
+	"en-NZ":      true,
+	"vi":         true,
+	"zh":         true,
+	"zh-CN":      true,
+	"zh-TW":      true,
+	"ja":         true,
+	"ko":         true,
+	"th":         true,
+	"id":         true,
+	"ms":         true,
+	"hi":         true,
+	"fr":         true,
+	"de":         true,
+	"es":         true,
+	"es-419":     true,
+	"it":         true,
+	"pt":         true,
+	"pt-BR":      true,
+	"nl":         true,
+	"ru":         true,
+	"uk":         true,
+	"pl":         true,
+	"tr":         true,
+	"sv":         true,
+	"da":         true,
+	"no":         true,
+	"fi":         true,
+}
+
+// allowedRedactEntities là các entity Deepgram hỗ trợ che (redact).
+var allowedRedactEntities = map[string]bool{
+	"pci":             true,
+	"pii":             true,
+	"phi":             true,
+	"ssn":             true,
+	"numbers":         true,
+	"name":            true,
+	"email_address":   true,
+	"phone_number":    true,
+	"credit_card":     true,
+	"account_number":  true,
+	"dob":             true,
+	"location":        true,
+	"passport_number": true,
+	"driver_license":  true,
+}
+
+// PreprocessNone tắt tiền xử lý audio (gửi audio đã tách nguyên bản cho provider).
+const PreprocessNone = "none"
+
+// allowedPreprocessProfiles là các profile tiền xử lý audio (bộ filter ffmpeg định nghĩa trong media pipeline).
+var allowedPreprocessProfiles = map[string]bool{
+	PreprocessNone: true,
+	"speech":       true, // lọc ù tần thấp + chuẩn hoá âm lượng
+	"phone":        true, // cuộc gọi điện thoại: băng thông 200-3400 Hz, khử nhiễu
+	"noisy":        true, // ghi âm hiện trường: khử nhiễu mạnh
+	"trim_silence": true, // cắt khoảng lặng cuối file (timestamp vẫn khớp file gốc)
+}
+
+// STTOptions là các tuỳ chọn cho 1 request speech-to-text.
+// Các field dạng con trỏ để phân biệt "không set" với "set = false" khi merge
+// request options lên trên defaults của user.
+type STTOptions struct {
+	Model           string   `json:"model,omitempty"`
+	Language        string   `json:"language,omitempty"` // mã BCP-47 hoặc "auto"
+	Diarize         *bool    `json:"diarize,omitempty"`
+	Redact          []string `json:"redact,omitempty"`
+	SmartFormat     *bool    `json:"smart_format,omitempty"`
+	Numerals        *bool    `json:"numerals,omitempty"`
+	ProfanityFilter *bool    `json:"profanity_filter,omitempty"`
+	Keyterms        []string `json:"keyterms,omitempty"`
+	Keywords        []string `json:"keywords,omitempty"` // "term" hoặc "term:boost", cho các model trước nova-3
+	Utterances      *bool    `json:"utterances,omitempty"`
+	UttSplit        *float64 `json:"utt_split,omitempty"`
+	VocabularyID    *int64   `json:"vocabulary_id,omitempty"`
+	// Khi auto-detect mà độ tin cậy ngôn ngữ < ngưỡng này thì chạy lại lần 2 với ngôn ngữ đã nhận diện.
+	SecondPassThreshold *float64 `json:"second_pass_threshold,omitempty"`
+	// Profile tiền xử lý audio trước khi gửi provider (xem allowedPreprocessProfiles), rỗng = không xử lý.
+	Preprocess string `json:"preprocess,omitempty"`
+	// Chỉ gửi các đoạn có tiếng nói (theo VAD) cho provider, timestamp được đổi lại theo file gốc.
+	SpeechOnly *bool `json:"speech_only,omitempty"`
+}
+
+// DefaultSTTOptions trả về cấu hình mặc định của hệ thống (giữ đúng hành vi cũ).
+func DefaultSTTOptions() *STTOptions {
+	on := true
+	off := false
+	return &STTOptions{
+		Model:           "nova-3",
+		Language:        LanguageAuto,
+		Diarize:         &on,
+		Redact:          []string{"pci", "ssn"},
+		SmartFormat:     &off,
+		Numerals:        &off,
+		ProfanityFilter: &off,
+		Utterances:      &on,
+	}
+}
+
+// Merge trả về bản copy của o, với các field đã set trong over ghi đè lên.
+func (o *STTOptions) Merge(over *STTOptions) *STTOptions {
+	out := &STTOptions{}
+	if o != nil {
+		*out = *o
+	}
+	if over == nil {
+		return out
+	}
+	if over.Model != "" {
+		out.Model = over.Model
+	}
+	if over.Language != "" {
+		out.Language = over.Language
+	}
+	if over.Diarize != nil {
+		out.Diarize = over.Diarize
+	}
+	if over.Redact != nil {
+		out.Redact = over.Redact
+	}
+	if over.SmartFormat != nil {
+		out.SmartFormat = over.SmartFormat
+	}
+	if over.Numerals != nil {
+		out.Numerals = over.Numerals
+	}
+	if over.ProfanityFilter != nil {
+		out.ProfanityFilter = over.ProfanityFilter
+	}
+	if over.Keyterms != nil {
+		out.Keyterms = over.Keyterms
+	}
+	if over.Keywords != nil {
+		out.Keywords = over.Keywords
+	}
+	if over.VocabularyID != nil {
+		out.VocabularyID = over.VocabularyID
+	}
+	if over.SecondPassThreshold != nil {
+		out.SecondPassThreshold = over.SecondPassThreshold
+	}
+	if over.Utterances != nil {
+		out.Utterances = over.Utterances
+	}
+	if over.UttSplit != nil {
+		out.UttSplit = over.UttSplit
+	}
+	if over.Preprocess != "" {
+		out.Preprocess = over.Preprocess
+	}
+	if over.SpeechOnly != nil {
+		out.SpeechOnly = over.SpeechOnly
+	}
+	return out
+}
+
+// Validate kiểm tra từng option theo allowlist.
+func (o *STTOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.Model != "" && !allowedSTTModels[o.Model] {
+		return fmt.Errorf("unsupported model %q", o.Model)
+	}
+	if o.Language != "" && !allowedSTTLanguages[o.Language] {
+		return fmt.Errorf("unsupported language %q", o.Language)
+	}
+	for _, entity := range o.Redact {
+		if !allowedRedactEntities[entity] {
+			return fmt.Errorf("unsupported redact entity %q", entity)
+		}
+	}
+	if len(o.Keyterms) > maxKeyterms {
+		return fmt.Errorf("too many keyterms: %d (max %d)", len(o.Keyterms), maxKeyterms)
+	}
+	for _, term := range o.Keyterms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return fmt.Errorf("keyterm must not be empty")
+		}
+		if len(term) > maxKeytermLength {
+			return fmt.Errorf("keyterm %q is too long (max %d characters)", term, maxKeytermLength)
+		}
+	}
+	if len(o.Keywords) > maxKeyterms {
+		return fmt.Errorf("too many keywords: %d (max %d)", len(o.Keywords), maxKeyterms)
+	}
+	for _, keyword := range o.Keywords {
+		if err := validateKeyword(keyword); err != nil {
+			return err
+		}
+	}
+	if o.UttSplit != nil && (*o.UttSplit < minUttSplit || *o.UttSplit > maxUttSplit) {
+		return fmt.Errorf("utt_split must be between %.1f and %.1f seconds", minUttSplit, maxUttSplit)
+	}
+	if o.SecondPassThreshold != nil && (*o.SecondPassThreshold <= 0 || *o.SecondPassThreshold > 1) {
+		return fmt.Errorf("second_pass_threshold must be in (0, 1]")
+	}
+	if o.Preprocess != "" && !allowedPreprocessProfiles[o.Preprocess] {
+		return fmt.Errorf("unsupported preprocess profile %q", o.Preprocess)
+	}
+	return nil
+}
+
+// validateKeyword kiểm tra keyword dạng "term" hoặc "term:boost".
+func validateKeyword(keyword string) error {
+	term := keyword
+	if idx := strings.LastIndex(keyword, ":"); idx >= 0 {
+		term = keyword[:idx]
+		boost, err := strconv.ParseFloat(keyword[idx+1:], 64)
+		if err != nil {
+			return fmt.Errorf("invalid keyword boost in %q", keyword)
+		}
+		if boost < minKeywordBoost || boost > maxKeywordBoost {
+			return fmt.Errorf("boost of keyword %q must be between %.0f and %.0f", keyword, minKeywordBoost, maxKeywordBoost)
+		}
+	}
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return fmt.Errorf("keyword must not be empty")
+	}
+	if len(term) > maxKeytermLength {
+		return fmt.Errorf("keyword %q is too long (max %d characters)", term, maxKeytermLength)
+	}
+	return nil
+}
+
+// AutoDetectLanguage cho biết request có để provider tự nhận diện ngôn ngữ hay không.
+func (o *STTOptions) AutoDetectLanguage() bool {
+	return o == nil || o.Language == "" || o.Language == LanguageAuto
+}
+
+// SecondPassOptions trả về options cho lần chạy thứ 2 (ép language = ngôn ngữ đã nhận diện)
+// khi lần 1 auto-detect với độ tin cậy thấp hơn ngưỡng. ok = false nếu không cần chạy lại.
+func (o *STTOptions) SecondPassOptions(detected string, confidence float64) (*STTOptions, bool) {
+	if o == nil || o.SecondPassThreshold == nil || !o.AutoDetectLanguage() {
+		return nil, false
+	}
+	if detected == "" || confidence >= *o.SecondPassThreshold || !allowedSTTLanguages[detected] {
+		return nil, false
+	}
+	second := o.Merge(&STTOptions{Language: detected})
+	second.SecondPassThreshold = nil
+	return second, true
+}
+
+// PreprocessProfile trả về profile tiền xử lý audio đã chọn, rỗng nếu không xử lý.
+func (o *STTOptions) PreprocessProfile() string {
+	if o == nil || o.Preprocess == PreprocessNone {
+		return ""
+	}
+	return o.Preprocess
+}
+
+// BoolValue trả về giá trị của 1 option bool, false nếu chưa set.
+func BoolValue(b *bool) bool {
+	return b != nil && *b
+}
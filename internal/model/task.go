@@ -13,8 +13,10 @@ import (
 type TaskType string
 
 const (
-	TaskTypeSTT TaskType = "stt" // Speech-to-Text
-	TaskTypeTTS TaskType = "tts" // Text-to-Speech
+	TaskTypeSTT       TaskType = "stt"       // Speech-to-Text
+	TaskTypeTTS       TaskType = "tts"       // Text-to-Speech
+	TaskTypeRender    TaskType = "render"    // render video dẫn xuất (burn-in phụ đề, ...)
+	TaskTypeAudiobook TaskType = "audiobook" // audiobook từ tài liệu: mỗi chương 1 MP3 + 1 file M4B
 )
 
 // Value implements driver.Valuer interface
@@ -65,37 +67,52 @@ func (s *TaskStatus) Scan(value interface{}) error {
 
 // Task represents a row in the `tasks` table.
 type Task struct {
-	ID             int64           `db:"id" json:"id"`
-	TaskType       TaskType        `db:"task_type" json:"task_type"`
-	Status         TaskStatus      `db:"status_task" json:"status"`
-	InputText      *string         `db:"input_text" json:"input_text,omitempty"`
-	InputURL       *string         `db:"input_url" json:"input_url,omitempty"`
-	OutputURL      *string         `db:"output_url" json:"output_url,omitempty"`
-	TranscriptText *string         `db:"transcript_text" json:"transcript_text,omitempty"`
-	TranscriptJSON json.RawMessage `db:"transcript_json" json:"transcript_json,omitempty"`
-	DurationSec    *float64        `db:"duration_sec" json:"duration_sec,omitempty"`
-	ErrorMessage   *string         `db:"error_message" json:"error_message,omitempty"`
-	UserID         *int64          `db:"user_id" json:"user_id,omitempty"`
-	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+	ID                 int64           `db:"id" json:"id"`
+	TaskType           TaskType        `db:"task_type" json:"task_type"`
+	Status             TaskStatus      `db:"status_task" json:"status"`
+	InputText          *string         `db:"input_text" json:"input_text,omitempty"`
+	InputURL           *string         `db:"input_url" json:"input_url,omitempty"`
+	OutputURL          *string         `db:"output_url" json:"output_url,omitempty"`
+	TranscriptText     *string         `db:"transcript_text" json:"transcript_text,omitempty"`
+	TranscriptJSON     json.RawMessage `db:"transcript_json" json:"transcript_json,omitempty"`
+	DurationSec        *float64        `db:"duration_sec" json:"duration_sec,omitempty"`
+	ErrorMessage       *string         `db:"error_message" json:"error_message,omitempty"`
+	UserID             *int64          `db:"user_id" json:"user_id,omitempty"`
+	Options            json.RawMessage `db:"options" json:"options,omitempty"`                         // options đã resolve của job (để chạy lại được)
+	DetectedLanguage   *string         `db:"detected_language" json:"detected_language,omitempty"`     // ngôn ngữ provider nhận diện được (STT)
+	LanguageConfidence *float64        `db:"language_confidence" json:"language_confidence,omitempty"` // độ tin cậy của detected_language
+	Provider           *string         `db:"provider" json:"provider,omitempty"`                       // speech provider đã phục vụ task
+	VideoID            *int64          `db:"video_id" json:"video_id,omitempty"`                       // video nguồn của task STT
+	AudioURL           *string         `db:"audio_url" json:"audio_url,omitempty"`                     // audio đã tách (media pipeline) được gửi cho STT
+	ProgressCurrent    *int            `db:"progress_current" json:"progress_current,omitempty"`       // số phần đã xong (chunk STT, ...)
+	ProgressTotal      *int            `db:"progress_total" json:"progress_total,omitempty"`           // tổng số phần của job
+	CreatedAt          time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time       `db:"updated_at" json:"updated_at"`
 }
 
 type SimpleWord struct {
-	Word  string  `json:"word"`
-	Start float64 `json:"start"`
-	End   float64 `json:"end"`
+	Word     string  `json:"word"`
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	Language string  `json:"language,omitempty"`
+	Speaker  *int    `json:"speaker,omitempty"` // speaker label khi bật diarize
 }
 
 type SimpleUtterance struct {
 	Start      float64 `json:"start"`
 	End        float64 `json:"end"`
 	Transcript string  `json:"transcript"`
+	Language   string  `json:"language,omitempty"` // ngôn ngữ chiếm đa số trong utterance (nếu provider trả về)
+	Speaker    *int    `json:"speaker,omitempty"`
 }
 
 type SimpleTranscript struct {
-	TranscriptText string            `json:"transcript_text"`
-	Words          []SimpleWord      `json:"words"`
-	Utterances     []SimpleUtterance `json:"utterances"`
+	TranscriptText     string            `json:"transcript_text"`
+	DetectedLanguage   string            `json:"detected_language,omitempty"`
+	LanguageConfidence float64           `json:"language_confidence,omitempty"`
+	Languages          []string          `json:"languages,omitempty"` // các ngôn ngữ xuất hiện (transcript code-switch)
+	Words              []SimpleWord      `json:"words"`
+	Utterances         []SimpleUtterance `json:"utterances"`
 }
 
 func ConvertDeepgramToSimple(resp *interfacesv1.PreRecordedResponse) (*SimpleTranscript, error) {
@@ -107,8 +124,11 @@ func ConvertDeepgramToSimple(resp *interfacesv1.PreRecordedResponse) (*SimpleTra
 
 	// Get transcript text from channels if available
 	if len(resp.Results.Channels) > 0 {
+		out.DetectedLanguage = resp.Results.Channels[0].DetectedLanguage
+		out.LanguageConfidence = resp.Results.Channels[0].LanguageConfidence
 		if len(resp.Results.Channels[0].Alternatives) > 0 {
 			out.TranscriptText = resp.Results.Channels[0].Alternatives[0].Transcript
+			out.Languages = resp.Results.Channels[0].Alternatives[0].Languages
 		}
 	}
 
@@ -118,9 +138,11 @@ func ConvertDeepgramToSimple(resp *interfacesv1.PreRecordedResponse) (*SimpleTra
 		for _, utt := range resp.Results.Utterances {
 			for _, w := range utt.Words {
 				out.Words = append(out.Words, SimpleWord{
-					Word:  w.PunctuatedWord, // dùng chữ có punctuation
-					Start: w.Start,
-					End:   w.End,
+					Word:     w.PunctuatedWord, // dùng chữ có punctuation
+					Start:    w.Start,
+					End:      w.End,
+					Language: w.Language,
+					Speaker:  w.Speaker,
 				})
 			}
 		}
@@ -131,6 +153,8 @@ func ConvertDeepgramToSimple(resp *interfacesv1.PreRecordedResponse) (*SimpleTra
 				Start:      utt.Start,
 				End:        utt.End,
 				Transcript: utt.Transcript,
+				Language:   dominantLanguage(utt.Words, out.DetectedLanguage),
+				Speaker:    utt.Speaker,
 			})
 		}
 		return out, nil
@@ -152,9 +176,11 @@ func ConvertDeepgramToSimple(resp *interfacesv1.PreRecordedResponse) (*SimpleTra
 						word = w.Word
 					}
 					out.Words = append(out.Words, SimpleWord{
-						Word:  word,
-						Start: w.Start,
-						End:   w.End,
+						Word:     word,
+						Start:    w.Start,
+						End:      w.End,
+						Language: w.Language,
+						Speaker:  w.Speaker,
 					})
 				}
 
@@ -169,6 +195,7 @@ func ConvertDeepgramToSimple(resp *interfacesv1.PreRecordedResponse) (*SimpleTra
 						Start:      start,
 						End:        end,
 						Transcript: alt.Transcript,
+						Language:   dominantLanguage(alt.Words, channel.DetectedLanguage),
 					})
 				}
 			}
@@ -186,6 +213,34 @@ func ConvertDeepgramToSimple(resp *interfacesv1.PreRecordedResponse) (*SimpleTra
 	return out, nil
 }
 
+// DetectedLanguage trả về ngôn ngữ Deepgram nhận diện được ở channel đầu tiên và độ tin cậy.
+func DetectedLanguage(resp *interfacesv1.PreRecordedResponse) (string, float64) {
+	if resp == nil || resp.Results == nil || len(resp.Results.Channels) == 0 {
+		return "", 0
+	}
+	return resp.Results.Channels[0].DetectedLanguage, resp.Results.Channels[0].LanguageConfidence
+}
+
+// dominantLanguage trả về ngôn ngữ xuất hiện nhiều nhất trong các từ (nova-3 multi gắn language
+// cho từng từ); nếu provider không gắn thì dùng fallback (ngôn ngữ của channel).
+func dominantLanguage(words []interfacesv1.Word, fallback string) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, w := range words {
+		if w.Language == "" {
+			continue
+		}
+		counts[w.Language]++
+		if best == "" || counts[w.Language] > counts[best] {
+			best = w.Language
+		}
+	}
+	if best == "" {
+		return fallback
+	}
+	return best
+}
+
 type ListTaskByUserIDResponse struct {
 	Page       int     `json:"page"`        // Trang hiện tại
 	PageSize   int     `json:"page_size"`   // Số rows mỗi trang
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Phạm vi cache audio TTS: user = chỉ dùng lại audio của chính user, shared = dùng chung giữa mọi user,
+// none = không đọc / ghi cache.
+const (
+	TTSCacheScopeUser   = "user"
+	TTSCacheScopeShared = "shared"
+	TTSCacheScopeNone   = "none"
+)
+
+// TTSCacheEntry represents a row in the `tts_cache_entries` table: audio TTS đã upload, định danh bằng
+// hash nội dung (text đã chuẩn hoá + voice + định dạng đầu ra + scope).
+type TTSCacheEntry struct {
+	ID          int64      `db:"id" json:"id"`
+	CacheKey    string     `db:"cache_key" json:"cache_key"` // sha256 hex
+	Scope       string     `db:"scope" json:"scope"`
+	UserID      int64      `db:"user_id" json:"user_id"` // user đã tạo audio
+	URL         string     `db:"url" json:"url"`
+	ContentType string     `db:"content_type" json:"content_type"`
+	DurationSec *float64   `db:"duration_sec" json:"duration_sec,omitempty"`
+	Provider    *string    `db:"provider" json:"provider,omitempty"`
+	HitCount    int64      `db:"hit_count" json:"hit_count"`
+	LastHitAt   *time.Time `db:"last_hit_at" json:"last_hit_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
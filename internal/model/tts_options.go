@@ -0,0 +1,321 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultTTSVoice là voice mặc định (giữ đúng hành vi cũ).
+const DefaultTTSVoice = "aura-2-thalia-en"
+
+// Khoảng lặng mặc định chèn giữa các đoạn khi text dài được tổng hợp theo nhiều chunk.
+const (
+	DefaultSentenceGapMs  = 150
+	DefaultParagraphGapMs = 700
+	maxTTSGapMs           = 5000
+)
+
+// Giới hạn hậu xử lý audio TTS: tempo giữ nguyên cao độ, pitch tính bằng semitone.
+const (
+	MinTTSTempo    = 0.8
+	MaxTTSTempo    = 1.5
+	maxTTSPitchAbs = 12.0
+)
+
+// Các mức timing (phụ đề) tạo kèm audio TTS, lưu thành transcript trên task.
+const (
+	TTSTimingsSentences = "sentences" // mỗi câu 1 utterance, lấy từ thời gian các chunk khi nối audio
+	TTSTimingsWords     = "words"     // thời gian từng từ: chạy STT lại trên audio vừa tạo
+)
+
+// TTSVoice là 1 voice trong catalog TTS. ID chính là model Deepgram gửi lên speak API.
+type TTSVoice struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Language string `json:"language"` // BCP-47
+	Gender   string `json:"gender"`   // female / male
+}
+
+// ttsVoices là catalog voice được phép dùng (Deepgram Aura-2).
+var ttsVoices = []TTSVoice{
+	{ID: "aura-2-thalia-en", Name: "Thalia", Language: "en-US", Gender: "female"},
+	{ID: "aura-2-andromeda-en", Name: "Andromeda", Language: "en-US", Gender: "female"},
+	{ID: "aura-2-asteria-en", Name: "Asteria", Language: "en-US", Gender: "female"},
+	{ID: "aura-2-athena-en", Name: "Athena", Language: "en-US", Gender: "female"},
+	{ID: "aura-2-aurora-en", Name: "Aurora", Language: "en-US", Gender: "female"},
+	{ID: "aura-2-cora-en", Name: "Cora", Language: "en-US", Gender: "female"},
+	{ID: "aura-2-helena-en", Name: "Helena", Language: "en-US", Gender: "female"},
+	{ID: "aura-2-luna-en", Name: "Luna", Language: "en-US", Gender: "female"},
+	{ID: "aura-2-ophelia-en", Name: "Ophelia", Language: "en-US", Gender: "female"},
+	{ID: "aura-2-pandora-en", Name: "Pandora", Language: "en-GB", Gender: "female"},
+	{ID: "aura-2-theia-en", Name: "Theia", Language: "en-AU", Gender: "female"},
+	{ID: "aura-2-apollo-en", Name: "Apollo", Language: "en-US", Gender: "male"},
+	{ID: "aura-2-arcas-en", Name: "Arcas", Language: "en-US", Gender: "male"},
+	{ID: "aura-2-aries-en", Name: "Aries", Language: "en-US", Gender: "male"},
+	{ID: "aura-2-atlas-en", Name: "Atlas", Language: "en-US", Gender: "male"},
+	{ID: "aura-2-orion-en", Name: "Orion", Language: "en-US", Gender: "male"},
+	{ID: "aura-2-orpheus-en", Name: "Orpheus", Language: "en-US", Gender: "male"},
+	{ID: "aura-2-zeus-en", Name: "Zeus", Language: "en-US", Gender: "male"},
+	{ID: "aura-2-draco-en", Name: "Draco", Language: "en-GB", Gender: "male"},
+	{ID: "aura-2-hyperion-en", Name: "Hyperion", Language: "en-AU", Gender: "male"},
+	{ID: "aura-2-celeste-es", Name: "Celeste", Language: "es-CO", Gender: "female"},
+	{ID: "aura-2-estrella-es", Name: "Estrella", Language: "es-MX", Gender: "female"},
+	{ID: "aura-2-carina-es", Name: "Carina", Language: "es-ES", Gender: "female"},
+	{ID: "aura-2-nestor-es", Name: "Nestor", Language: "es-ES", Gender: "male"},
+	{ID: "aura-2-sirio-es", Name: "Sirio", Language: "es-MX", Gender: "male"},
+}
+
+// ListTTSVoices trả về các voice trong catalog, lọc theo language (khớp prefix, vd "en" khớp "en-US")
+// và gender nếu khác rỗng.
+func ListTTSVoices(language, gender string) []TTSVoice {
+	out := []TTSVoice{}
+	for _, v := range ttsVoices {
+		if language != "" && !strings.EqualFold(v.Language, language) && !strings.HasPrefix(strings.ToLower(v.Language), strings.ToLower(language)+"-") {
+			continue
+		}
+		if gender != "" && !strings.EqualFold(v.Gender, gender) {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// LookupTTSVoice tìm voice theo ID.
+func LookupTTSVoice(id string) (TTSVoice, bool) {
+	for _, v := range ttsVoices {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return TTSVoice{}, false
+}
+
+// ttsEncoding mô tả 1 định dạng đầu ra: tham số gửi provider và giới hạn sample rate / bitrate.
+type ttsEncoding struct {
+	Encoding    string // encoding gửi provider
+	Container   string // container gửi provider, rỗng = mặc định của provider
+	Extension   string
+	ContentType string
+	SampleRate  int   // sample rate mặc định của provider
+	SampleRates []int // sample rate được phép, rỗng = cố định theo provider
+	BitRate     int   // bitrate mặc định của provider, 0 = lossless
+	MinBitRate  int   // 0 = không chỉnh được bitrate
+	MaxBitRate  int
+	BitRates    []int // bitrate được phép (khi chỉ nhận vài giá trị cố định)
+}
+
+// ttsEncodings là các định dạng audio TTS hỗ trợ (giới hạn theo Deepgram speak API).
+var ttsEncodings = map[string]ttsEncoding{
+	"mp3":  {Encoding: "mp3", Extension: ".mp3", ContentType: "audio/mpeg", SampleRate: 22050, BitRate: 48000, BitRates: []int{32000, 48000}},
+	"wav":  {Encoding: "linear16", Container: "wav", Extension: ".wav", ContentType: "audio/wav", SampleRate: 24000, SampleRates: []int{8000, 16000, 24000, 32000, 48000}},
+	"opus": {Encoding: "opus", Container: "ogg", Extension: ".ogg", ContentType: "audio/ogg", SampleRate: 48000, BitRate: 12000, MinBitRate: 4000, MaxBitRate: 650000},
+	"flac": {Encoding: "flac", Extension: ".flac", ContentType: "audio/flac", SampleRate: 48000, SampleRates: []int{8000, 16000, 22050, 32000, 48000}},
+	"aac":  {Encoding: "aac", Extension: ".aac", ContentType: "audio/aac", SampleRate: 22050, BitRate: 48000, MinBitRate: 4000, MaxBitRate: 192000},
+}
+
+// TTSOptions là các tuỳ chọn cho 1 request text-to-speech.
+type TTSOptions struct {
+	Voice      string `json:"voice,omitempty"`       // ID voice trong catalog (model Deepgram), mặc định DefaultTTSVoice
+	Encoding   string `json:"encoding,omitempty"`    // mp3 / wav / opus / flac / aac, mặc định mp3
+	SampleRate *int   `json:"sample_rate,omitempty"` // Hz, chỉ với wav / flac
+	BitRate    *int   `json:"bit_rate,omitempty"`    // bit/s, chỉ với mp3 / opus / aac
+	// Khoảng lặng (ms) chèn giữa các chunk khi text dài phải tổng hợp nhiều lần: giữa 2 câu / giữa 2 đoạn văn
+	SentenceGapMs  *int `json:"sentence_gap_ms,omitempty"`
+	ParagraphGapMs *int `json:"paragraph_gap_ms,omitempty"`
+	// Markup: text chứa markup kiểu SSML (<break>, <say-as>, <sub>, <voice>)
+	Markup bool `json:"markup,omitempty"`
+	// SkipLexicon: không áp lexicon phát âm của user lên text
+	SkipLexicon bool `json:"skip_lexicon,omitempty"`
+	// Cache: phạm vi cache audio (user / shared / none), rỗng = TTS_CACHE_DEFAULT_SCOPE.
+	// CacheRefresh: bỏ qua audio đã cache, tổng hợp lại và ghi đè cache.
+	Cache        string `json:"cache,omitempty"`
+	CacheRefresh bool   `json:"cache_refresh,omitempty"`
+	// Timings: tạo kèm timing theo câu (sentences) hoặc theo từ (words) để làm phụ đề, rỗng = không tạo
+	Timings string `json:"timings,omitempty"`
+	// Hậu xử lý bằng ffmpeg trước khi upload: tempo (0.8..1.5, giữ cao độ), pitch (semitone, giữ tốc độ),
+	// chuẩn hoá loudness (web -16 LUFS / broadcast -23 LUFS) và cắt khoảng lặng ở đầu / cuối audio.
+	Tempo          *float64 `json:"tempo,omitempty"`
+	PitchSemitones *float64 `json:"pitch_semitones,omitempty"`
+	Loudness       string   `json:"loudness,omitempty"`
+	TrimSilence    bool     `json:"trim_silence,omitempty"`
+}
+
+// WithDefaults trả về bản copy của o với voice / encoding mặc định.
+func (o *TTSOptions) WithDefaults() *TTSOptions {
+	out := &TTSOptions{}
+	if o != nil {
+		*out = *o
+	}
+	if out.Voice == "" {
+		out.Voice = DefaultTTSVoice
+	}
+	if out.Encoding == "" {
+		out.Encoding = "mp3"
+	}
+	if out.SentenceGapMs == nil {
+		gap := DefaultSentenceGapMs
+		out.SentenceGapMs = &gap
+	}
+	if out.ParagraphGapMs == nil {
+		gap := DefaultParagraphGapMs
+		out.ParagraphGapMs = &gap
+	}
+	if out.Loudness == LoudnessNone {
+		out.Loudness = ""
+	}
+	return out
+}
+
+// Validate kiểm tra voice, encoding và sample rate / bitrate có hợp với encoding không.
+func (o *TTSOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.Voice != "" {
+		if _, ok := LookupTTSVoice(o.Voice); !ok {
+			return fmt.Errorf("unsupported voice %q", o.Voice)
+		}
+	}
+	name := o.Encoding
+	if name == "" {
+		name = "mp3"
+	}
+	enc, ok := ttsEncodings[name]
+	if !ok {
+		names := make([]string, 0, len(ttsEncodings))
+		for n := range ttsEncodings {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unsupported encoding %q (supported: %s)", o.Encoding, strings.Join(names, ", "))
+	}
+	if o.SampleRate != nil {
+		if len(enc.SampleRates) == 0 {
+			return fmt.Errorf("sample_rate cannot be set for encoding %s", name)
+		}
+		if !slices.Contains(enc.SampleRates, *o.SampleRate) {
+			return fmt.Errorf("sample_rate for encoding %s must be one of %v", name, enc.SampleRates)
+		}
+	}
+	if o.BitRate != nil {
+		switch {
+		case len(enc.BitRates) > 0:
+			if !slices.Contains(enc.BitRates, *o.BitRate) {
+				return fmt.Errorf("bit_rate for encoding %s must be one of %v", name, enc.BitRates)
+			}
+		case enc.MaxBitRate > 0:
+			if *o.BitRate < enc.MinBitRate || *o.BitRate > enc.MaxBitRate {
+				return fmt.Errorf("bit_rate for encoding %s must be between %d and %d", name, enc.MinBitRate, enc.MaxBitRate)
+			}
+		default:
+			return fmt.Errorf("bit_rate cannot be set for encoding %s", name)
+		}
+	}
+	switch o.Cache {
+	case "", TTSCacheScopeUser, TTSCacheScopeShared, TTSCacheScopeNone:
+	default:
+		return fmt.Errorf("cache must be %q, %q or %q", TTSCacheScopeUser, TTSCacheScopeShared, TTSCacheScopeNone)
+	}
+	switch o.Timings {
+	case "", TTSTimingsSentences, TTSTimingsWords:
+	default:
+		return fmt.Errorf("timings must be %q or %q", TTSTimingsSentences, TTSTimingsWords)
+	}
+	for field, gap := range map[string]*int{"sentence_gap_ms": o.SentenceGapMs, "paragraph_gap_ms": o.ParagraphGapMs} {
+		if gap != nil && (*gap < 0 || *gap > maxTTSGapMs) {
+			return fmt.Errorf("%s must be between 0 and %d", field, maxTTSGapMs)
+		}
+	}
+	if o.Tempo != nil && (*o.Tempo < MinTTSTempo || *o.Tempo > MaxTTSTempo) {
+		return fmt.Errorf("tempo must be between %g and %g", MinTTSTempo, MaxTTSTempo)
+	}
+	if o.PitchSemitones != nil && (*o.PitchSemitones < -maxTTSPitchAbs || *o.PitchSemitones > maxTTSPitchAbs) {
+		return fmt.Errorf("pitch_semitones must be between %g and %g", -maxTTSPitchAbs, maxTTSPitchAbs)
+	}
+	switch o.Loudness {
+	case "", LoudnessWeb, LoudnessBroadcast, LoudnessNone:
+	default:
+		return fmt.Errorf("loudness must be %q, %q or %q", LoudnessWeb, LoudnessBroadcast, LoudnessNone)
+	}
+	return nil
+}
+
+// TempoFactor trả về hệ số tempo, 1 khi không đổi.
+func (o *TTSOptions) TempoFactor() float64 {
+	if o == nil || o.Tempo == nil {
+		return 1
+	}
+	return *o.Tempo
+}
+
+// Pitch trả về độ dịch cao độ (semitone), 0 khi không đổi.
+func (o *TTSOptions) Pitch() float64 {
+	if o == nil || o.PitchSemitones == nil {
+		return 0
+	}
+	return *o.PitchSemitones
+}
+
+// HasPostProcess cho biết audio có cần hậu xử lý (tempo / pitch / loudness / cắt khoảng lặng) không.
+func (o *TTSOptions) HasPostProcess() bool {
+	if o == nil {
+		return false
+	}
+	_, loudness := LookupLoudnessTarget(o.Loudness)
+	return o.TempoFactor() != 1 || o.Pitch() != 0 || loudness || o.TrimSilence
+}
+
+// ProviderEncoding trả về encoding / container gửi provider.
+func (o *TTSOptions) ProviderEncoding() (string, string) {
+	enc := o.encoding()
+	return enc.Encoding, enc.Container
+}
+
+// OutputSampleRate trả về sample rate của audio đầu ra (đã chọn hoặc mặc định của encoding).
+func (o *TTSOptions) OutputSampleRate() int {
+	if o != nil && o.SampleRate != nil {
+		return *o.SampleRate
+	}
+	return o.encoding().SampleRate
+}
+
+// OutputBitRate trả về bitrate của audio đầu ra, 0 với định dạng lossless.
+func (o *TTSOptions) OutputBitRate() int {
+	if o != nil && o.BitRate != nil {
+		return *o.BitRate
+	}
+	return o.encoding().BitRate
+}
+
+// Extension trả về đuôi file của định dạng đầu ra (vd ".mp3").
+func (o *TTSOptions) Extension() string {
+	return o.encoding().Extension
+}
+
+// ContentType trả về MIME type của định dạng đầu ra.
+func (o *TTSOptions) ContentType() string {
+	return o.encoding().ContentType
+}
+
+func (o *TTSOptions) encoding() ttsEncoding {
+	if o != nil {
+		if enc, ok := ttsEncodings[o.Encoding]; ok {
+			return enc
+		}
+	}
+	return ttsEncodings["mp3"]
+}
+
+// FileName đặt tên file audio TTS theo voice và định dạng thật, vd "tts-thalia.wav".
+func (o *TTSOptions) FileName() string {
+	name := "tts"
+	if o != nil {
+		if v, ok := LookupTTSVoice(o.Voice); ok {
+			name += "-" + strings.ToLower(v.Name)
+		}
+	}
+	return name + o.Extension()
+}
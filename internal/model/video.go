@@ -4,13 +4,114 @@ import "time"
 
 // Video represents a row in the `videos` table.
 type Video struct {
-	ID          int64     `db:"id" json:"id"`
-	UserID      int64     `db:"user_id" json:"user_id"`
-	LinkVideo   string    `db:"link_video" json:"link_video"`
-	NameFile    string    `db:"name_file" json:"name_file"`
-	Description *string   `db:"description" json:"description,omitempty"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ID          int64   `db:"id" json:"id"`
+	UserID      int64   `db:"user_id" json:"user_id"`
+	LinkVideo   string  `db:"link_video" json:"link_video"`
+	NameFile    string  `db:"name_file" json:"name_file"`
+	Description *string `db:"description" json:"description,omitempty"`
+
+	// Video dẫn xuất (render phụ đề, cắt ghép, ...): video nguồn, task chứa transcript đã dùng
+	// và task render đã tạo ra video (1 task có thể tạo nhiều video, vd: các clip + highlight reel)
+	SourceVideoID *int64 `db:"source_video_id" json:"source_video_id,omitempty"`
+	SourceTaskID  *int64 `db:"source_task_id" json:"source_task_id,omitempty"`
+	RenderTaskID  *int64 `db:"render_task_id" json:"render_task_id,omitempty"`
+
+	// Thông tin kỹ thuật từ ffprobe (NULL khi video chưa được probe)
+	DurationSec   *float64   `db:"duration_sec" json:"duration_sec,omitempty"`
+	Container     *string    `db:"container" json:"container,omitempty"`
+	VideoCodec    *string    `db:"video_codec" json:"video_codec,omitempty"`
+	AudioCodec    *string    `db:"audio_codec" json:"audio_codec,omitempty"`
+	AudioChannels *int       `db:"audio_channels" json:"audio_channels,omitempty"`
+	SampleRate    *int       `db:"sample_rate" json:"sample_rate,omitempty"`
+	Width         *int       `db:"width" json:"width,omitempty"`
+	Height        *int       `db:"height" json:"height,omitempty"`
+	BitRate       *int64     `db:"bit_rate" json:"bit_rate,omitempty"`
+	SizeBytes     *int64     `db:"size_bytes" json:"size_bytes,omitempty"`
+	ProbedAt      *time.Time `db:"probed_at" json:"probed_at,omitempty"`
+
+	// HLS cho web player: transcode_status dùng chung giá trị với TaskStatus (NULL = chưa transcode)
+	TranscodeStatus *TaskStatus `db:"transcode_status" json:"transcode_status,omitempty"`
+	TranscodeError  *string     `db:"transcode_error" json:"transcode_error,omitempty"`
+	HLSURL          *string     `db:"hls_url" json:"hls_url,omitempty"` // master playlist
+
+	// Waveform / poster / sprite do media pipeline sinh sau khi upload (không lưu trong bảng videos)
+	Assets *VideoAssets `db:"-" json:"assets,omitempty"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// SetMediaInfo gán kết quả ffprobe vào video (giá trị rỗng giữ NULL).
+func (v *Video) SetMediaInfo(info *MediaInfo) {
+	v.DurationSec = &info.DurationSec
+	v.Container = nonEmptyString(info.Container)
+	v.VideoCodec = nonEmptyString(info.VideoCodec)
+	v.AudioCodec = nonEmptyString(info.AudioCodec)
+	v.AudioChannels = nonZeroInt(info.AudioChannels)
+	v.SampleRate = nonZeroInt(info.SampleRate)
+	v.Width = nonZeroInt(info.Width)
+	v.Height = nonZeroInt(info.Height)
+	if info.BitRate > 0 {
+		v.BitRate = &info.BitRate
+	}
+	if info.SizeBytes > 0 {
+		v.SizeBytes = &info.SizeBytes
+	}
+	now := time.Now()
+	v.ProbedAt = &now
+}
+
+// MediaInfo trả về thông tin ffprobe đã lưu, nil nếu video chưa được probe.
+func (v *Video) MediaInfo() *MediaInfo {
+	if v.ProbedAt == nil {
+		return nil
+	}
+	info := &MediaInfo{}
+	if v.DurationSec != nil {
+		info.DurationSec = *v.DurationSec
+	}
+	if v.Container != nil {
+		info.Container = *v.Container
+	}
+	if v.VideoCodec != nil {
+		info.VideoCodec = *v.VideoCodec
+	}
+	if v.AudioCodec != nil {
+		info.AudioCodec = *v.AudioCodec
+	}
+	if v.AudioChannels != nil {
+		info.AudioChannels = *v.AudioChannels
+	}
+	if v.SampleRate != nil {
+		info.SampleRate = *v.SampleRate
+	}
+	if v.Width != nil {
+		info.Width = *v.Width
+	}
+	if v.Height != nil {
+		info.Height = *v.Height
+	}
+	if v.BitRate != nil {
+		info.BitRate = *v.BitRate
+	}
+	if v.SizeBytes != nil {
+		info.SizeBytes = *v.SizeBytes
+	}
+	return info
+}
+
+func nonEmptyString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func nonZeroInt(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
 }
 
 type ListVideoByUserIDResponse struct {
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ArtifactKind là loại file dẫn xuất từ 1 video (audio đã tách, ...).
+type ArtifactKind string
+
+const (
+	ArtifactKindAudio    ArtifactKind = "audio"    // audio mono 16 kHz dùng cho STT
+	ArtifactKindWaveform ArtifactKind = "waveform" // JSON peak data cho web player
+	ArtifactKindPoster   ArtifactKind = "poster"   // ảnh poster (1 frame của video)
+	ArtifactKindSprite   ArtifactKind = "sprite"   // sprite sheet thumbnail, layout trong Meta (SpriteSheet)
+	ArtifactKindCaptions ArtifactKind = "captions" // phụ đề WebVTT sinh kèm file (vd audio hội thoại TTS)
+)
+
+// ProcessedAudioKind là kind của audio đã qua profile tiền xử lý (mỗi profile 1 artifact).
+func ProcessedAudioKind(profile string) ArtifactKind {
+	return ArtifactKind("audio_" + profile)
+}
+
+// VideoArtifact represents a row in the `video_artifacts` table:
+// file dẫn xuất của video, lưu trên R2 dưới videos/<id>/.
+type VideoArtifact struct {
+	ID          int64           `db:"id" json:"id"`
+	VideoID     int64           `db:"video_id" json:"video_id"`
+	Kind        ArtifactKind    `db:"kind" json:"kind"`
+	URL         string          `db:"url" json:"url"`
+	StorageKey  string          `db:"storage_key" json:"-"`
+	ContentType string          `db:"content_type" json:"content_type"`
+	SizeBytes   int64           `db:"size_bytes" json:"size_bytes"`
+	DurationSec *float64        `db:"duration_sec" json:"duration_sec,omitempty"` // chỉ có với artifact audio
+	Meta        json.RawMessage `db:"meta" json:"meta,omitempty"`                 // thông tin thêm theo kind (vd: layout sprite)
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// SpriteSheet mô tả layout của sprite sheet: Count thumbnail ThumbWidth x ThumbHeight,
+// xếp Columns cột, thumbnail thứ i ứng với thời điểm i * IntervalSec.
+type SpriteSheet struct {
+	IntervalSec float64 `json:"interval_sec"`
+	Columns     int     `json:"columns"`
+	Rows        int     `json:"rows"`
+	Count       int     `json:"count"`
+	ThumbWidth  int     `json:"thumb_width"`
+	ThumbHeight int     `json:"thumb_height"`
+}
+
+// VideoAssets là các asset cho web player, trả kèm Video trong API.
+type VideoAssets struct {
+	WaveformURL string       `json:"waveform_url,omitempty"`
+	PosterURL   string       `json:"poster_url,omitempty"`
+	SpriteURL   string       `json:"sprite_url,omitempty"`
+	Sprite      *SpriteSheet `json:"sprite,omitempty"`
+	CaptionsURL string       `json:"captions_url,omitempty"`
+}
+
+// AssetsFromArtifacts gom các artifact asset của 1 video, nil nếu video chưa có asset nào.
+func AssetsFromArtifacts(artifacts []*VideoArtifact) *VideoAssets {
+	var assets *VideoAssets
+	get := func() *VideoAssets {
+		if assets == nil {
+			assets = &VideoAssets{}
+		}
+		return assets
+	}
+	for _, a := range artifacts {
+		switch a.Kind {
+		case ArtifactKindWaveform:
+			get().WaveformURL = a.URL
+		case ArtifactKindPoster:
+			get().PosterURL = a.URL
+		case ArtifactKindSprite:
+			get().SpriteURL = a.URL
+			var sheet SpriteSheet
+			if err := json.Unmarshal(a.Meta, &sheet); err == nil {
+				assets.Sprite = &sheet
+			}
+		case ArtifactKindCaptions:
+			get().CaptionsURL = a.URL
+		}
+	}
+	return assets
+}
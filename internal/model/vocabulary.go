@@ -0,0 +1,137 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxVocabularyTerms   = 100
+	maxSoundsLikePerTerm = 10
+	minKeywordBoost      = -10.0
+	maxKeywordBoost      = 10.0
+)
+
+// VocabularyTerm là 1 từ/cụm từ trong vocabulary.
+type VocabularyTerm struct {
+	Term       string   `json:"term"`                  // cách viết đúng (vd: "atorvastatin")
+	Boost      *float64 `json:"boost,omitempty"`       // intensifier gửi cho provider (-10..10)
+	SoundsLike []string `json:"sounds_like,omitempty"` // các cách provider hay nhận sai, dùng cho bước sửa lỗi local
+}
+
+// VocabularyTerms là danh sách term, lưu dạng JSONB.
+type VocabularyTerms []VocabularyTerm
+
+// Value implements driver.Valuer interface
+func (t VocabularyTerms) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t)
+}
+
+// Scan implements sql.Scanner interface
+func (t *VocabularyTerms) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("failed to scan VocabularyTerms")
+	}
+	return json.Unmarshal(b, t)
+}
+
+// Vocabulary represents a row in the `vocabularies` table.
+// UserID = nil nghĩa là vocabulary dùng chung (do admin quản lý).
+type Vocabulary struct {
+	ID        int64           `db:"id" json:"id"`
+	UserID    *int64          `db:"user_id" json:"user_id,omitempty"`
+	Name      string          `db:"name" json:"name"`
+	Terms     VocabularyTerms `db:"terms" json:"terms"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// VocabularyRequest là payload tạo / cập nhật vocabulary.
+type VocabularyRequest struct {
+	Name   string          `json:"name" binding:"required"`
+	Terms  VocabularyTerms `json:"terms"`
+	Shared bool            `json:"shared"` // chỉ admin được tạo vocabulary dùng chung
+}
+
+// Validate kiểm tra tên và danh sách term.
+func (v *Vocabulary) Validate() error {
+	if strings.TrimSpace(v.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(v.Terms) > maxVocabularyTerms {
+		return fmt.Errorf("too many terms: %d (max %d)", len(v.Terms), maxVocabularyTerms)
+	}
+	for _, t := range v.Terms {
+		term := strings.TrimSpace(t.Term)
+		if term == "" {
+			return errors.New("term must not be empty")
+		}
+		if len(term) > maxKeytermLength {
+			return fmt.Errorf("term %q is too long (max %d characters)", term, maxKeytermLength)
+		}
+		if t.Boost != nil && (*t.Boost < minKeywordBoost || *t.Boost > maxKeywordBoost) {
+			return fmt.Errorf("boost of term %q must be between %.0f and %.0f", term, minKeywordBoost, maxKeywordBoost)
+		}
+		if len(t.SoundsLike) > maxSoundsLikePerTerm {
+			return fmt.Errorf("term %q has too many sounds_like hints (max %d)", term, maxSoundsLikePerTerm)
+		}
+		for _, s := range t.SoundsLike {
+			if strings.TrimSpace(s) == "" {
+				return fmt.Errorf("sounds_like of term %q must not be empty", term)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyTo đưa các term của vocabulary vào STT options để gửi lên provider.
+// nova-3 chỉ hỗ trợ keyterm (không có boost), các model cũ dùng keywords "term:boost".
+func (v *Vocabulary) ApplyTo(opts *STTOptions) {
+	if v == nil || opts == nil {
+		return
+	}
+	nova3 := strings.HasPrefix(opts.Model, "nova-3")
+	for _, t := range v.Terms {
+		term := strings.TrimSpace(t.Term)
+		if nova3 {
+			if !containsString(opts.Keyterms, term) {
+				opts.Keyterms = append(opts.Keyterms, term)
+			}
+			continue
+		}
+		keyword := term
+		if t.Boost != nil {
+			keyword = term + ":" + strconv.FormatFloat(*t.Boost, 'f', -1, 64)
+		}
+		if !containsString(opts.Keywords, keyword) {
+			opts.Keywords = append(opts.Keywords, keyword)
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
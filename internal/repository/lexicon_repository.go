@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// LexiconRepository defines operations for TTS pronunciation lexicon entries.
+type LexiconRepository interface {
+	Create(ctx context.Context, e *model.LexiconEntry) error
+	GetByID(ctx context.Context, id int64) (*model.LexiconEntry, error)
+	ListByUser(ctx context.Context, userID int64) ([]*model.LexiconEntry, error)
+	CountByUser(ctx context.Context, userID int64) (int, error)
+	Update(ctx context.Context, e *model.LexiconEntry) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type lexiconRepository struct {
+	db *sql.DB
+}
+
+// NewLexiconRepository returns a concrete implementation of LexiconRepository.
+func NewLexiconRepository(db *sql.DB) LexiconRepository {
+	return &lexiconRepository{db: db}
+}
+
+const lexiconColumns = `id, user_id, word, replacement, kind, language, match_case, created_at, updated_at`
+
+func scanLexiconEntry(row rowScanner) (*model.LexiconEntry, error) {
+	e := &model.LexiconEntry{}
+	err := row.Scan(&e.ID, &e.UserID, &e.Word, &e.Replacement, &e.Kind, &e.Language, &e.MatchCase, &e.CreatedAt, &e.UpdatedAt)
+	return e, err
+}
+
+func (r *lexiconRepository) Create(ctx context.Context, e *model.LexiconEntry) error {
+	query := `
+		INSERT INTO tts_lexicon_entries (user_id, word, replacement, kind, language, match_case)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.
+		QueryRowContext(ctx, query, e.UserID, e.Word, e.Replacement, e.Kind, e.Language, e.MatchCase).
+		Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+}
+
+func (r *lexiconRepository) GetByID(ctx context.Context, id int64) (*model.LexiconEntry, error) {
+	query := `SELECT ` + lexiconColumns + ` FROM tts_lexicon_entries WHERE id = $1`
+	e, err := scanLexiconEntry(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			zap.S().Infow("lexicon entry not found", "id", id)
+			return nil, errors.New("lexicon entry not found")
+		}
+		zap.S().Errorw("get lexicon entry by id failed", "id", id, "error", err)
+		return nil, err
+	}
+	return e, nil
+}
+
+// ListByUser trả về lexicon của user, sắp theo word.
+func (r *lexiconRepository) ListByUser(ctx context.Context, userID int64) ([]*model.LexiconEntry, error) {
+	query := `SELECT ` + lexiconColumns + ` FROM tts_lexicon_entries WHERE user_id = $1 ORDER BY lower(word), id`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		zap.S().Errorw("list lexicon entries failed", "user_id", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*model.LexiconEntry, 0)
+	for rows.Next() {
+		e, err := scanLexiconEntry(rows)
+		if err != nil {
+			zap.S().Errorw("scan lexicon entry failed", "user_id", userID, "error", err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *lexiconRepository) CountByUser(ctx context.Context, userID int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tts_lexicon_entries WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
+
+func (r *lexiconRepository) Update(ctx context.Context, e *model.LexiconEntry) error {
+	query := `
+		UPDATE tts_lexicon_entries
+		SET word = $1, replacement = $2, kind = $3, language = $4, match_case = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING updated_at
+	`
+	if err := r.db.QueryRowContext(ctx, query, e.Word, e.Replacement, e.Kind, e.Language, e.MatchCase, e.ID).Scan(&e.UpdatedAt); err != nil {
+		zap.S().Errorw("update lexicon entry failed", "id", e.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *lexiconRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM tts_lexicon_entries WHERE id = $1`, id)
+	return err
+}
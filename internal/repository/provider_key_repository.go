@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// ProviderKeyRepository defines operations for provider API keys.
+type ProviderKeyRepository interface {
+	Create(ctx context.Context, k *model.ProviderKey) error
+	GetByID(ctx context.Context, id int64) (*model.ProviderKey, error)
+	List(ctx context.Context) ([]*model.ProviderKey, error)
+	ListActive(ctx context.Context) ([]*model.ProviderKey, error)
+	Rotate(ctx context.Context, id int64, keyEncrypted, keyHint string, weight int, quotaLimit *int64, resetUsage bool) error
+	UpdateStatus(ctx context.Context, id int64, status model.ProviderKeyStatus, reason *string) error
+	SetCooldown(ctx context.Context, id int64, until time.Time, lastError string) error
+	IncrementUsage(ctx context.Context, id int64) error
+}
+
+type providerKeyRepository struct {
+	db *sql.DB
+}
+
+// NewProviderKeyRepository returns a concrete implementation of ProviderKeyRepository.
+func NewProviderKeyRepository(db *sql.DB) ProviderKeyRepository {
+	return &providerKeyRepository{db: db}
+}
+
+const providerKeyColumns = `id, provider, name, key_encrypted, key_hint, weight, quota_limit, usage_count, status, disabled_reason, cooldown_until, last_used_at, last_error, created_at, updated_at`
+
+func scanProviderKey(row rowScanner) (*model.ProviderKey, error) {
+	k := &model.ProviderKey{}
+	if err := row.Scan(
+		&k.ID,
+		&k.Provider,
+		&k.Name,
+		&k.KeyEncrypted,
+		&k.KeyHint,
+		&k.Weight,
+		&k.QuotaLimit,
+		&k.UsageCount,
+		&k.Status,
+		&k.DisabledReason,
+		&k.CooldownUntil,
+		&k.LastUsedAt,
+		&k.LastError,
+		&k.CreatedAt,
+		&k.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	k.RemainingQuota = k.Remaining()
+	return k, nil
+}
+
+func (r *providerKeyRepository) Create(ctx context.Context, k *model.ProviderKey) error {
+	query := `
+		INSERT INTO provider_keys (provider, name, key_encrypted, key_hint, weight, quota_limit, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, usage_count, created_at, updated_at
+	`
+	err := r.db.
+		QueryRowContext(ctx, query, k.Provider, k.Name, k.KeyEncrypted, k.KeyHint, k.Weight, k.QuotaLimit, k.Status).
+		Scan(&k.ID, &k.UsageCount, &k.CreatedAt, &k.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	k.RemainingQuota = k.Remaining()
+	return nil
+}
+
+func (r *providerKeyRepository) GetByID(ctx context.Context, id int64) (*model.ProviderKey, error) {
+	query := `SELECT ` + providerKeyColumns + ` FROM provider_keys WHERE id = $1`
+	k, err := scanProviderKey(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("provider key not found")
+		}
+		zap.S().Errorw("get provider key by id failed", "id", id, "error", err)
+		return nil, err
+	}
+	return k, nil
+}
+
+func (r *providerKeyRepository) List(ctx context.Context) ([]*model.ProviderKey, error) {
+	return r.list(ctx, `SELECT `+providerKeyColumns+` FROM provider_keys ORDER BY provider, id`)
+}
+
+// ListActive trả về các key chưa bị disable (key đang cooldown vẫn được trả về, pool tự lọc).
+func (r *providerKeyRepository) ListActive(ctx context.Context) ([]*model.ProviderKey, error) {
+	return r.list(ctx, `SELECT `+providerKeyColumns+` FROM provider_keys WHERE status = 'active' ORDER BY provider, id`)
+}
+
+func (r *providerKeyRepository) list(ctx context.Context, query string) ([]*model.ProviderKey, error) {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		zap.S().Errorw("list provider keys failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*model.ProviderKey, 0)
+	for rows.Next() {
+		k, err := scanProviderKey(rows)
+		if err != nil {
+			zap.S().Errorw("scan provider key failed", "error", err)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Rotate thay key mới cho slot và kích hoạt lại slot đó.
+func (r *providerKeyRepository) Rotate(ctx context.Context, id int64, keyEncrypted, keyHint string, weight int, quotaLimit *int64, resetUsage bool) error {
+	query := `
+		UPDATE provider_keys
+		SET key_encrypted = $2,
+			key_hint = $3,
+			weight = $4,
+			quota_limit = $5,
+			usage_count = CASE WHEN $6 THEN 0 ELSE usage_count END,
+			status = 'active',
+			disabled_reason = NULL,
+			cooldown_until = NULL,
+			last_error = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, keyEncrypted, keyHint, weight, quotaLimit, resetUsage)
+	if err != nil {
+		zap.S().Errorw("rotate provider key failed", "id", id, "error", err)
+	}
+	return err
+}
+
+func (r *providerKeyRepository) UpdateStatus(ctx context.Context, id int64, status model.ProviderKeyStatus, reason *string) error {
+	query := `
+		UPDATE provider_keys
+		SET status = $2,
+			disabled_reason = $3,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, status, reason)
+	if err != nil {
+		zap.S().Errorw("update provider key status failed", "id", id, "error", err)
+	}
+	return err
+}
+
+func (r *providerKeyRepository) SetCooldown(ctx context.Context, id int64, until time.Time, lastError string) error {
+	query := `
+		UPDATE provider_keys
+		SET cooldown_until = $2,
+			last_error = $3,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, until, lastError)
+	if err != nil {
+		zap.S().Errorw("set provider key cooldown failed", "id", id, "error", err)
+	}
+	return err
+}
+
+func (r *providerKeyRepository) IncrementUsage(ctx context.Context, id int64) error {
+	query := `
+		UPDATE provider_keys
+		SET usage_count = usage_count + 1,
+			last_used_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
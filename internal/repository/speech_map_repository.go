@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// ErrSpeechMapNotFound trả về khi video chưa chạy VAD.
+var ErrSpeechMapNotFound = errors.New("speech map not found")
+
+// SpeechMapRepository defines operations for per-video speech/silence maps.
+type SpeechMapRepository interface {
+	Upsert(ctx context.Context, m *model.SpeechMap) error
+	GetByVideoID(ctx context.Context, videoID int64) (*model.SpeechMap, error)
+}
+
+type speechMapRepository struct {
+	db *sql.DB
+}
+
+// NewSpeechMapRepository returns a concrete implementation of SpeechMapRepository.
+func NewSpeechMapRepository(db *sql.DB) SpeechMapRepository {
+	return &speechMapRepository{db: db}
+}
+
+// Upsert lưu speech map; mỗi video chỉ giữ 1 bản (chạy lại thì ghi đè).
+func (r *speechMapRepository) Upsert(ctx context.Context, m *model.SpeechMap) error {
+	segments, err := json.Marshal(m.Segments)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO video_speech_maps (video_id, duration_sec, speech_sec, noise_db, min_silence_sec, segments)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (video_id) DO UPDATE
+		SET duration_sec = EXCLUDED.duration_sec,
+			speech_sec = EXCLUDED.speech_sec,
+			noise_db = EXCLUDED.noise_db,
+			min_silence_sec = EXCLUDED.min_silence_sec,
+			segments = EXCLUDED.segments,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+	err = r.db.
+		QueryRowContext(ctx, query, m.VideoID, m.DurationSec, m.SpeechSec, m.NoiseDB, m.MinSilenceSec, segments).
+		Scan(&m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		zap.S().Errorw("upsert speech map failed", "video_id", m.VideoID, "error", err)
+	}
+	return err
+}
+
+func (r *speechMapRepository) GetByVideoID(ctx context.Context, videoID int64) (*model.SpeechMap, error) {
+	query := `
+		SELECT video_id, duration_sec, speech_sec, noise_db, min_silence_sec, segments, created_at, updated_at
+		FROM video_speech_maps
+		WHERE video_id = $1
+	`
+	m := &model.SpeechMap{}
+	var segments []byte
+	err := r.db.QueryRowContext(ctx, query, videoID).Scan(
+		&m.VideoID,
+		&m.DurationSec,
+		&m.SpeechSec,
+		&m.NoiseDB,
+		&m.MinSilenceSec,
+		&segments,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSpeechMapNotFound
+		}
+		zap.S().Errorw("get speech map failed", "video_id", videoID, "error", err)
+		return nil, err
+	}
+	if err := json.Unmarshal(segments, &m.Segments); err != nil {
+		return nil, err
+	}
+	m.Fill()
+	return m, nil
+}
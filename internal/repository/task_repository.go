@@ -19,6 +19,15 @@ type TaskRepository interface {
 	UpdateStatus(ctx context.Context, id int64, status model.TaskStatus, outputURL *string, durationSec *float64, errorMessage *string) error
 	UpdateTranscript(ctx context.Context, id int64, status model.TaskStatus, transcriptText *string, transcriptJSON []byte) error
 	ListTaskByUserID(ctx context.Context, userID int64, limit, offset int, search string, status string) (*model.ListTaskByUserIDResponse, error)
+	UpdateDetectedLanguage(ctx context.Context, id int64, language *string, confidence *float64) error
+	UpdateProvider(ctx context.Context, id int64, provider string) error
+	UpdateAudioURL(ctx context.Context, id int64, audioURL string) error
+	UpdateDuration(ctx context.Context, id int64, durationSec float64) error
+	UpdateProgress(ctx context.Context, id int64, current, total int) error
+	// UpdateVideoID gắn task với video (vd task TTS với Video audio nó tạo ra).
+	UpdateVideoID(ctx context.Context, id int64, videoID int64) error
+	// GetLatestCompletedByVideo trả về task completed mới nhất của video theo loại task.
+	GetLatestCompletedByVideo(ctx context.Context, videoID int64, taskType model.TaskType) (*model.Task, error)
 }
 
 type taskRepository struct {
@@ -30,19 +39,73 @@ func NewTaskRepository(db *sql.DB) TaskRepository {
 	return &taskRepository{db: db}
 }
 
+// taskColumns là danh sách cột dùng chung cho mọi câu SELECT trên bảng tasks,
+// phải giữ đúng thứ tự với scanTask.
+const taskColumns = `id, task_type, status_task, input_text, input_url, output_url, transcript_text, transcript_json, duration_sec, error_message, user_id, options, detected_language, language_confidence, provider, video_id, audio_url, progress_current, progress_total, created_at, updated_at`
+
+// rowScanner là phần chung giữa *sql.Row và *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask đọc 1 row (theo thứ tự taskColumns) thành model.Task.
+func scanTask(row rowScanner) (*model.Task, error) {
+	t := &model.Task{}
+	var transcriptJSON sql.NullString
+	var options sql.NullString
+	if err := row.Scan(
+		&t.ID,
+		&t.TaskType,
+		&t.Status,
+		&t.InputText,
+		&t.InputURL,
+		&t.OutputURL,
+		&t.TranscriptText,
+		&transcriptJSON,
+		&t.DurationSec,
+		&t.ErrorMessage,
+		&t.UserID,
+		&options,
+		&t.DetectedLanguage,
+		&t.LanguageConfidence,
+		&t.Provider,
+		&t.VideoID,
+		&t.AudioURL,
+		&t.ProgressCurrent,
+		&t.ProgressTotal,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	// Convert sql.NullString to json.RawMessage
+	if transcriptJSON.Valid {
+		t.TranscriptJSON = json.RawMessage(transcriptJSON.String)
+	}
+	if options.Valid {
+		t.Options = json.RawMessage(options.String)
+	}
+	return t, nil
+}
+
 func (r *taskRepository) Create(ctx context.Context, t *model.Task) error {
 	query := `
-		INSERT INTO tasks (task_type, status_task, input_text, input_url, output_url, transcript_text, transcript_json, duration_sec, error_message, user_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO tasks (task_type, status_task, input_text, input_url, output_url, transcript_text, transcript_json, duration_sec, error_message, user_id, options, video_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, created_at, updated_at
 	`
-	// Xử lý transcript_json: nếu nil hoặc rỗng thì truyền NULL
+	// Xử lý transcript_json / options: nếu nil hoặc rỗng thì truyền NULL
 	var transcriptJSON interface{}
 	if len(t.TranscriptJSON) == 0 {
 		transcriptJSON = nil
 	} else {
 		transcriptJSON = t.TranscriptJSON
 	}
+	var options interface{}
+	if len(t.Options) > 0 {
+		options = []byte(t.Options)
+	}
 
 	return r.db.
 		QueryRowContext(
@@ -58,35 +121,19 @@ func (r *taskRepository) Create(ctx context.Context, t *model.Task) error {
 			t.DurationSec,
 			t.ErrorMessage,
 			t.UserID,
+			options,
+			t.VideoID,
 		).
 		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
 }
 
 func (r *taskRepository) GetByID(ctx context.Context, id int64) (*model.Task, error) {
 	query := `
-		SELECT id, task_type, status_task, input_text, input_url, output_url, transcript_text, transcript_json, duration_sec, error_message, user_id, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
 		WHERE id = $1
 	`
-	t := &model.Task{}
-	var transcriptJSON sql.NullString
-	err := r.db.
-		QueryRowContext(ctx, query, id).
-		Scan(
-			&t.ID,
-			&t.TaskType,
-			&t.Status,
-			&t.InputText,
-			&t.InputURL,
-			&t.OutputURL,
-			&t.TranscriptText,
-			&transcriptJSON,
-			&t.DurationSec,
-			&t.ErrorMessage,
-			&t.UserID,
-			&t.CreatedAt,
-			&t.UpdatedAt,
-		)
+	t, err := scanTask(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			zap.S().Infow("task not found", "id", id)
@@ -96,19 +143,12 @@ func (r *taskRepository) GetByID(ctx context.Context, id int64) (*model.Task, er
 		return nil, err
 	}
 
-	// Convert sql.NullString to json.RawMessage
-	if transcriptJSON.Valid {
-		t.TranscriptJSON = json.RawMessage(transcriptJSON.String)
-	} else {
-		t.TranscriptJSON = nil
-	}
-
 	return t, nil
 }
 
 func (r *taskRepository) ListByUser(ctx context.Context, userID int64, limit, offset int) ([]*model.Task, error) {
 	query := `
-		SELECT id, task_type, status_task, input_text, input_url, output_url, transcript_text, transcript_json, duration_sec, error_message, user_id, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -123,34 +163,12 @@ func (r *taskRepository) ListByUser(ctx context.Context, userID int64, limit, of
 
 	tasks := make([]*model.Task, 0)
 	for rows.Next() {
-		t := &model.Task{}
-		var transcriptJSON sql.NullString
-		if err := rows.Scan(
-			&t.ID,
-			&t.TaskType,
-			&t.Status,
-			&t.InputText,
-			&t.InputURL,
-			&t.OutputURL,
-			&t.TranscriptText,
-			&transcriptJSON,
-			&t.DurationSec,
-			&t.ErrorMessage,
-			&t.UserID,
-			&t.CreatedAt,
-			&t.UpdatedAt,
-		); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			zap.S().Errorw("scan task failed", "user_id", userID, "error", err)
 			continue
 		}
 
-		// Convert sql.NullString to json.RawMessage
-		if transcriptJSON.Valid {
-			t.TranscriptJSON = json.RawMessage(transcriptJSON.String)
-		} else {
-			t.TranscriptJSON = nil
-		}
-
 		tasks = append(tasks, t)
 	}
 	return tasks, nil
@@ -201,6 +219,92 @@ func (r *taskRepository) UpdateTranscript(ctx context.Context, id int64, status
 	return nil
 }
 
+func (r *taskRepository) UpdateDetectedLanguage(ctx context.Context, id int64, language *string, confidence *float64) error {
+	query := `
+		UPDATE tasks
+		SET detected_language = $2,
+			language_confidence = $3,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, language, confidence); err != nil {
+		zap.S().Errorw("update task detected language failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *taskRepository) UpdateProvider(ctx context.Context, id int64, provider string) error {
+	query := `
+		UPDATE tasks
+		SET provider = $2,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, provider); err != nil {
+		zap.S().Errorw("update task provider failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *taskRepository) UpdateAudioURL(ctx context.Context, id int64, audioURL string) error {
+	query := `
+		UPDATE tasks
+		SET audio_url = $2,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, audioURL); err != nil {
+		zap.S().Errorw("update task audio url failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *taskRepository) UpdateVideoID(ctx context.Context, id int64, videoID int64) error {
+	query := `
+		UPDATE tasks
+		SET video_id = $2,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, videoID); err != nil {
+		zap.S().Errorw("update task video id failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *taskRepository) UpdateDuration(ctx context.Context, id int64, durationSec float64) error {
+	query := `
+		UPDATE tasks
+		SET duration_sec = $2,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, durationSec); err != nil {
+		zap.S().Errorw("update task duration failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *taskRepository) UpdateProgress(ctx context.Context, id int64, current, total int) error {
+	query := `
+		UPDATE tasks
+		SET progress_current = $2,
+			progress_total = $3,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, current, total); err != nil {
+		zap.S().Errorw("update task progress failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
 func (r *taskRepository) ListTaskByUserID(ctx context.Context, userID int64, limit, offset int, search string, status string) (*model.ListTaskByUserIDResponse, error) {
 	var query string
 	var queryCount string
@@ -211,7 +315,7 @@ func (r *taskRepository) ListTaskByUserID(ctx context.Context, userID int64, lim
 	if search != "" && status != "" {
 		// Both search and status
 		query = `
-			SELECT id, task_type, status_task, input_text, input_url, output_url, transcript_text, transcript_json, duration_sec, error_message, user_id, created_at, updated_at
+			SELECT ` + taskColumns + `
 			FROM tasks
 			WHERE user_id = $1 AND task_type = $2 AND status_task = $3
 			ORDER BY created_at DESC
@@ -223,7 +327,7 @@ func (r *taskRepository) ListTaskByUserID(ctx context.Context, userID int64, lim
 	} else if search != "" {
 		// Only search (task_type)
 		query = `
-			SELECT id, task_type, status_task, input_text, input_url, output_url, transcript_text, transcript_json, duration_sec, error_message, user_id, created_at, updated_at
+			SELECT ` + taskColumns + `
 			FROM tasks
 			WHERE user_id = $1 AND task_type = $2
 			ORDER BY created_at DESC
@@ -235,7 +339,7 @@ func (r *taskRepository) ListTaskByUserID(ctx context.Context, userID int64, lim
 	} else if status != "" {
 		// Only status
 		query = `
-			SELECT id, task_type, status_task, input_text, input_url, output_url, transcript_text, transcript_json, duration_sec, error_message, user_id, created_at, updated_at
+			SELECT ` + taskColumns + `
 			FROM tasks
 			WHERE user_id = $1 AND status_task = $2
 			ORDER BY created_at DESC
@@ -247,7 +351,7 @@ func (r *taskRepository) ListTaskByUserID(ctx context.Context, userID int64, lim
 	} else {
 		// Neither search nor status
 		query = `
-			SELECT id, task_type, status_task, input_text, input_url, output_url, transcript_text, transcript_json, duration_sec, error_message, user_id, created_at, updated_at
+			SELECT ` + taskColumns + `
 			FROM tasks
 			WHERE user_id = $1
 			ORDER BY created_at DESC
@@ -267,34 +371,12 @@ func (r *taskRepository) ListTaskByUserID(ctx context.Context, userID int64, lim
 
 	tasks := make([]*model.Task, 0)
 	for rows.Next() {
-		t := &model.Task{}
-		var transcriptJSON sql.NullString
-		if err := rows.Scan(
-			&t.ID,
-			&t.TaskType,
-			&t.Status,
-			&t.InputText,
-			&t.InputURL,
-			&t.OutputURL,
-			&t.TranscriptText,
-			&transcriptJSON,
-			&t.DurationSec,
-			&t.ErrorMessage,
-			&t.UserID,
-			&t.CreatedAt,
-			&t.UpdatedAt,
-		); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			zap.S().Errorw("scan task failed", "user_id", userID, "error", err)
 			continue
 		}
 
-		// Convert sql.NullString to json.RawMessage
-		if transcriptJSON.Valid {
-			t.TranscriptJSON = json.RawMessage(transcriptJSON.String)
-		} else {
-			t.TranscriptJSON = nil
-		}
-
 		tasks = append(tasks, t)
 	}
 
@@ -323,3 +405,22 @@ func (r *taskRepository) ListTaskByUserID(ctx context.Context, userID int64, lim
 		Tasks:      tasks,
 	}, nil
 }
+
+func (r *taskRepository) GetLatestCompletedByVideo(ctx context.Context, videoID int64, taskType model.TaskType) (*model.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE video_id = $1 AND task_type = $2 AND status_task = $3
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	t, err := scanTask(r.db.QueryRowContext(ctx, query, videoID, taskType, model.TaskStatusCompleted))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("task not found")
+		}
+		zap.S().Errorw("get latest completed task by video failed", "video_id", videoID, "task_type", taskType, "error", err)
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// TTSCacheRepository defines operations for cached TTS audio.
+type TTSCacheRepository interface {
+	GetByKey(ctx context.Context, key string) (*model.TTSCacheEntry, error)
+	// Upsert lưu entry, key đã có (2 request giống nhau cùng lúc, cache_refresh) thì ghi đè audio.
+	Upsert(ctx context.Context, e *model.TTSCacheEntry) error
+	MarkHit(ctx context.Context, id int64) error
+}
+
+type ttsCacheRepository struct {
+	db *sql.DB
+}
+
+// NewTTSCacheRepository returns a concrete implementation of TTSCacheRepository.
+func NewTTSCacheRepository(db *sql.DB) TTSCacheRepository {
+	return &ttsCacheRepository{db: db}
+}
+
+const ttsCacheColumns = `id, cache_key, scope, user_id, url, content_type, duration_sec, provider, hit_count, last_hit_at, created_at`
+
+func scanTTSCacheEntry(row rowScanner) (*model.TTSCacheEntry, error) {
+	e := &model.TTSCacheEntry{}
+	err := row.Scan(&e.ID, &e.CacheKey, &e.Scope, &e.UserID, &e.URL, &e.ContentType, &e.DurationSec, &e.Provider, &e.HitCount, &e.LastHitAt, &e.CreatedAt)
+	return e, err
+}
+
+func (r *ttsCacheRepository) GetByKey(ctx context.Context, key string) (*model.TTSCacheEntry, error) {
+	query := `SELECT ` + ttsCacheColumns + ` FROM tts_cache_entries WHERE cache_key = $1`
+	e, err := scanTTSCacheEntry(r.db.QueryRowContext(ctx, query, key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("tts cache entry not found")
+		}
+		zap.S().Errorw("get tts cache entry failed", "cache_key", key, "error", err)
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *ttsCacheRepository) Upsert(ctx context.Context, e *model.TTSCacheEntry) error {
+	query := `
+		INSERT INTO tts_cache_entries (cache_key, scope, user_id, url, content_type, duration_sec, provider)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (cache_key) DO UPDATE
+		SET url = EXCLUDED.url, content_type = EXCLUDED.content_type, duration_sec = EXCLUDED.duration_sec,
+			provider = EXCLUDED.provider, created_at = NOW()
+		RETURNING id, hit_count, created_at
+	`
+	err := r.db.
+		QueryRowContext(ctx, query, e.CacheKey, e.Scope, e.UserID, e.URL, e.ContentType, e.DurationSec, e.Provider).
+		Scan(&e.ID, &e.HitCount, &e.CreatedAt)
+	if err != nil {
+		zap.S().Errorw("upsert tts cache entry failed", "cache_key", e.CacheKey, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *ttsCacheRepository) MarkHit(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE tts_cache_entries SET hit_count = hit_count + 1, last_hit_at = NOW() WHERE id = $1`, id)
+	return err
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"video-transcript/internal/model"
 )
@@ -15,6 +16,8 @@ type UserRepository interface {
 	List(ctx context.Context) ([]*model.User, error)
 	Update(ctx context.Context, u *model.User) error
 	Delete(ctx context.Context, id int64) error
+	GetSTTDefaults(ctx context.Context, id int64) (*model.STTOptions, error)
+	UpdateSTTDefaults(ctx context.Context, id int64, opts *model.STTOptions) error
 }
 
 type userRepository struct {
@@ -218,3 +221,34 @@ func (r *userRepository) Delete(ctx context.Context, id int64) error {
 	_, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
 	return err
 }
+
+// GetSTTDefaults trả về STT options mặc định user đã lưu (nil nếu chưa lưu).
+func (r *userRepository) GetSTTDefaults(ctx context.Context, id int64) (*model.STTOptions, error) {
+	var raw sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT stt_defaults FROM users WHERE id = $1`, id).Scan(&raw)
+	if err != nil {
+		return nil, err
+	}
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+	opts := &model.STTOptions{}
+	if err := json.Unmarshal([]byte(raw.String), opts); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// UpdateSTTDefaults lưu STT options mặc định của user (opts = nil để xoá).
+func (r *userRepository) UpdateSTTDefaults(ctx context.Context, id int64, opts *model.STTOptions) error {
+	var raw interface{}
+	if opts != nil {
+		b, err := json.Marshal(opts)
+		if err != nil {
+			return err
+		}
+		raw = b
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET stt_defaults = $1, updated_at = NOW() WHERE id = $2`, raw, id)
+	return err
+}
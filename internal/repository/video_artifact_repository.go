@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"video-transcript/internal/model"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// ErrArtifactNotFound trả về khi video chưa có artifact loại tương ứng.
+var ErrArtifactNotFound = errors.New("video artifact not found")
+
+// VideoArtifactRepository defines operations for derived video artifacts.
+type VideoArtifactRepository interface {
+	Upsert(ctx context.Context, a *model.VideoArtifact) error
+	GetByVideoAndKind(ctx context.Context, videoID int64, kind model.ArtifactKind) (*model.VideoArtifact, error)
+	ListByVideo(ctx context.Context, videoID int64) ([]*model.VideoArtifact, error)
+	// ListByVideoIDs trả về artifact thuộc các kind cho trước của nhiều video, nhóm theo video_id.
+	ListByVideoIDs(ctx context.Context, videoIDs []int64, kinds []model.ArtifactKind) (map[int64][]*model.VideoArtifact, error)
+}
+
+type videoArtifactRepository struct {
+	db *sql.DB
+}
+
+// NewVideoArtifactRepository returns a concrete implementation of VideoArtifactRepository.
+func NewVideoArtifactRepository(db *sql.DB) VideoArtifactRepository {
+	return &videoArtifactRepository{db: db}
+}
+
+const videoArtifactColumns = `id, video_id, kind, url, storage_key, content_type, size_bytes, duration_sec, meta, created_at, updated_at`
+
+func scanVideoArtifact(row rowScanner) (*model.VideoArtifact, error) {
+	a := &model.VideoArtifact{}
+	var meta sql.NullString
+	if err := row.Scan(
+		&a.ID,
+		&a.VideoID,
+		&a.Kind,
+		&a.URL,
+		&a.StorageKey,
+		&a.ContentType,
+		&a.SizeBytes,
+		&a.DurationSec,
+		&meta,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if meta.Valid {
+		a.Meta = json.RawMessage(meta.String)
+	}
+	return a, nil
+}
+
+// Upsert lưu artifact; mỗi video chỉ giữ 1 artifact cho mỗi kind (tạo lại thì ghi đè).
+func (r *videoArtifactRepository) Upsert(ctx context.Context, a *model.VideoArtifact) error {
+	query := `
+		INSERT INTO video_artifacts (video_id, kind, url, storage_key, content_type, size_bytes, duration_sec, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (video_id, kind) DO UPDATE
+		SET url = EXCLUDED.url,
+			storage_key = EXCLUDED.storage_key,
+			content_type = EXCLUDED.content_type,
+			size_bytes = EXCLUDED.size_bytes,
+			duration_sec = EXCLUDED.duration_sec,
+			meta = EXCLUDED.meta,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+	// meta rỗng thì lưu NULL
+	var meta interface{}
+	if len(a.Meta) > 0 {
+		meta = []byte(a.Meta)
+	}
+
+	err := r.db.
+		QueryRowContext(ctx, query, a.VideoID, a.Kind, a.URL, a.StorageKey, a.ContentType, a.SizeBytes, a.DurationSec, meta).
+		Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		zap.S().Errorw("upsert video artifact failed", "video_id", a.VideoID, "kind", a.Kind, "error", err)
+	}
+	return err
+}
+
+func (r *videoArtifactRepository) GetByVideoAndKind(ctx context.Context, videoID int64, kind model.ArtifactKind) (*model.VideoArtifact, error) {
+	query := `SELECT ` + videoArtifactColumns + ` FROM video_artifacts WHERE video_id = $1 AND kind = $2`
+	a, err := scanVideoArtifact(r.db.QueryRowContext(ctx, query, videoID, kind))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrArtifactNotFound
+		}
+		zap.S().Errorw("get video artifact failed", "video_id", videoID, "kind", kind, "error", err)
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *videoArtifactRepository) ListByVideo(ctx context.Context, videoID int64) ([]*model.VideoArtifact, error) {
+	query := `SELECT ` + videoArtifactColumns + ` FROM video_artifacts WHERE video_id = $1 ORDER BY kind`
+	rows, err := r.db.QueryContext(ctx, query, videoID)
+	if err != nil {
+		zap.S().Errorw("list video artifacts failed", "video_id", videoID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	artifacts := make([]*model.VideoArtifact, 0)
+	for rows.Next() {
+		a, err := scanVideoArtifact(rows)
+		if err != nil {
+			zap.S().Errorw("scan video artifact failed", "video_id", videoID, "error", err)
+			continue
+		}
+		artifacts = append(artifacts, a)
+	}
+	return artifacts, rows.Err()
+}
+
+func (r *videoArtifactRepository) ListByVideoIDs(ctx context.Context, videoIDs []int64, kinds []model.ArtifactKind) (map[int64][]*model.VideoArtifact, error) {
+	result := make(map[int64][]*model.VideoArtifact)
+	if len(videoIDs) == 0 {
+		return result, nil
+	}
+
+	kindNames := make([]string, len(kinds))
+	for i, k := range kinds {
+		kindNames[i] = string(k)
+	}
+
+	query := `SELECT ` + videoArtifactColumns + ` FROM video_artifacts WHERE video_id = ANY($1) AND kind = ANY($2) ORDER BY video_id, kind`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(videoIDs), pq.Array(kindNames))
+	if err != nil {
+		zap.S().Errorw("list video artifacts by videos failed", "video_ids", videoIDs, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a, err := scanVideoArtifact(rows)
+		if err != nil {
+			zap.S().Errorw("scan video artifact failed", "error", err)
+			continue
+		}
+		result[a.VideoID] = append(result[a.VideoID], a)
+	}
+	return result, rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"video-transcript/internal/model"
 
@@ -16,7 +17,16 @@ type VideoRepository interface {
 	GetByID(ctx context.Context, id int64) (*model.Video, error)
 	GetVideoByUserIDAndURL(ctx context.Context, userID int64, url string) ([]*model.Video, error)
 	ListVideoByUserID(ctx context.Context, userID int64, limit, offset int, search string) (*model.ListVideoByUserIDResponse, error)
+	// ListBySourceVideoID trả về các video dẫn xuất từ video sourceID, mới nhất trước.
+	ListBySourceVideoID(ctx context.Context, sourceID int64) ([]*model.Video, error)
+	// ListByRenderTaskID trả về các video do task taskID tạo ra, theo thứ tự tạo.
+	ListByRenderTaskID(ctx context.Context, taskID int64) ([]*model.Video, error)
 	UpdateDescription(ctx context.Context, id int64, description *string) error
+	UpdateMediaInfo(ctx context.Context, v *model.Video) error
+	// ClaimTranscode chuyển video sang transcode_status = pending; false nếu video đang được transcode
+	// (job pending/processing chưa quá staleAfter, job quá hạn coi như đã chết và được chạy lại).
+	ClaimTranscode(ctx context.Context, id int64, staleAfter time.Duration) (bool, error)
+	UpdateTranscodeStatus(ctx context.Context, id int64, status model.TaskStatus, hlsURL *string, errorMessage *string) error
 }
 
 type videoRepository struct {
@@ -28,10 +38,48 @@ func NewVideoRepository(db *sql.DB) VideoRepository {
 	return &videoRepository{db: db}
 }
 
+// videoColumns là danh sách cột dùng chung cho mọi câu SELECT trên bảng videos,
+// phải giữ đúng thứ tự với scanVideo.
+const videoColumns = `id, user_id, link_video, name_file, description, source_video_id, source_task_id, render_task_id, duration_sec, container, video_codec, audio_codec, audio_channels, sample_rate, width, height, bit_rate, size_bytes, probed_at, transcode_status, transcode_error, hls_url, created_at, updated_at`
+
+// scanVideo đọc 1 row (theo thứ tự videoColumns) thành model.Video.
+func scanVideo(row rowScanner) (*model.Video, error) {
+	v := &model.Video{}
+	if err := row.Scan(
+		&v.ID,
+		&v.UserID,
+		&v.LinkVideo,
+		&v.NameFile,
+		&v.Description,
+		&v.SourceVideoID,
+		&v.SourceTaskID,
+		&v.RenderTaskID,
+		&v.DurationSec,
+		&v.Container,
+		&v.VideoCodec,
+		&v.AudioCodec,
+		&v.AudioChannels,
+		&v.SampleRate,
+		&v.Width,
+		&v.Height,
+		&v.BitRate,
+		&v.SizeBytes,
+		&v.ProbedAt,
+		&v.TranscodeStatus,
+		&v.TranscodeError,
+		&v.HLSURL,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 func (r *videoRepository) Create(ctx context.Context, v *model.Video) error {
 	query := `
-		INSERT INTO videos (user_id, link_video, name_file, description)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO videos (user_id, link_video, name_file, description, duration_sec, container, video_codec, audio_codec, audio_channels, sample_rate, width, height, bit_rate, size_bytes, probed_at, source_video_id, source_task_id, render_task_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING id, created_at, updated_at
 	`
 	return r.db.
@@ -42,28 +90,31 @@ func (r *videoRepository) Create(ctx context.Context, v *model.Video) error {
 			v.LinkVideo,
 			v.NameFile,
 			v.Description,
+			v.DurationSec,
+			v.Container,
+			v.VideoCodec,
+			v.AudioCodec,
+			v.AudioChannels,
+			v.SampleRate,
+			v.Width,
+			v.Height,
+			v.BitRate,
+			v.SizeBytes,
+			v.ProbedAt,
+			v.SourceVideoID,
+			v.SourceTaskID,
+			v.RenderTaskID,
 		).
 		Scan(&v.ID, &v.CreatedAt, &v.UpdatedAt)
 }
 
 func (r *videoRepository) GetByID(ctx context.Context, id int64) (*model.Video, error) {
 	query := `
-		SELECT id, user_id, link_video, name_file, description, created_at, updated_at
+		SELECT ` + videoColumns + `
 		FROM videos
 		WHERE id = $1
 	`
-	v := &model.Video{}
-	err := r.db.
-		QueryRowContext(ctx, query, id).
-		Scan(
-			&v.ID,
-			&v.UserID,
-			&v.LinkVideo,
-			&v.NameFile,
-			&v.Description,
-			&v.CreatedAt,
-			&v.UpdatedAt,
-		)
+	v, err := scanVideo(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			zap.S().Errorw("video not found", "error", err)
@@ -77,7 +128,7 @@ func (r *videoRepository) GetByID(ctx context.Context, id int64) (*model.Video,
 
 func (r *videoRepository) GetVideoByUserIDAndURL(ctx context.Context, userID int64, url string) ([]*model.Video, error) {
 	query := `
-		SELECT id, user_id, link_video, name_file, description, created_at, updated_at
+		SELECT ` + videoColumns + `
 		FROM videos
 		WHERE user_id = $1 AND link_video = $2
 	`
@@ -93,21 +144,12 @@ func (r *videoRepository) GetVideoByUserIDAndURL(ctx context.Context, userID int
 	}
 	defer rows.Close()
 	for rows.Next() {
-		v := &model.Video{}
-		err := rows.Scan(&v.ID, &v.UserID, &v.LinkVideo, &v.NameFile, &v.Description, &v.CreatedAt, &v.UpdatedAt)
+		v, err := scanVideo(rows)
 		if err != nil {
 			zap.S().Errorw("scan video by user id and url failed", "error", err)
 			continue
 		}
-		videos = append(videos, &model.Video{
-			ID:          v.ID,
-			UserID:      v.UserID,
-			LinkVideo:   v.LinkVideo,
-			NameFile:    v.NameFile,
-			Description: v.Description,
-			CreatedAt:   v.CreatedAt,
-			UpdatedAt:   v.UpdatedAt,
-		})
+		videos = append(videos, v)
 	}
 	if len(videos) == 0 {
 		zap.S().Errorw("video not found", "error", errors.New("video not found"))
@@ -116,6 +158,58 @@ func (r *videoRepository) GetVideoByUserIDAndURL(ctx context.Context, userID int
 	return videos, nil
 }
 
+func (r *videoRepository) ListBySourceVideoID(ctx context.Context, sourceID int64) ([]*model.Video, error) {
+	query := `
+		SELECT ` + videoColumns + `
+		FROM videos
+		WHERE source_video_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, sourceID)
+	if err != nil {
+		zap.S().Errorw("list videos by source video id failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	videos := []*model.Video{}
+	for rows.Next() {
+		v, err := scanVideo(rows)
+		if err != nil {
+			zap.S().Errorw("scan video by source video id failed", "error", err)
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
+func (r *videoRepository) ListByRenderTaskID(ctx context.Context, taskID int64) ([]*model.Video, error) {
+	query := `
+		SELECT ` + videoColumns + `
+		FROM videos
+		WHERE render_task_id = $1
+		ORDER BY id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, taskID)
+	if err != nil {
+		zap.S().Errorw("list videos by render task id failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	videos := []*model.Video{}
+	for rows.Next() {
+		v, err := scanVideo(rows)
+		if err != nil {
+			zap.S().Errorw("scan video by render task id failed", "error", err)
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
 func (r *videoRepository) ListVideoByUserID(ctx context.Context, userID int64, limit, offset int, search string) (*model.ListVideoByUserIDResponse, error) {
 	var query string
 	var queryCount string
@@ -124,7 +218,7 @@ func (r *videoRepository) ListVideoByUserID(ctx context.Context, userID int64, l
 
 	if search != "" {
 		query = `
-			SELECT id, user_id, link_video, name_file, description, created_at, updated_at
+			SELECT ` + videoColumns + `
 			FROM videos
 			WHERE user_id = $1 AND description ILIKE $2
 			ORDER BY updated_at DESC
@@ -137,7 +231,7 @@ func (r *videoRepository) ListVideoByUserID(ctx context.Context, userID int64, l
 		countArgs = []interface{}{userID, searchPattern}
 	} else {
 		query = `
-			SELECT id, user_id, link_video, name_file, description, created_at, updated_at
+			SELECT ` + videoColumns + `
 			FROM videos
 			WHERE user_id = $1
 			ORDER BY updated_at DESC
@@ -158,8 +252,7 @@ func (r *videoRepository) ListVideoByUserID(ctx context.Context, userID int64, l
 
 	videos := []*model.Video{}
 	for rows.Next() {
-		v := &model.Video{}
-		err := rows.Scan(&v.ID, &v.UserID, &v.LinkVideo, &v.NameFile, &v.Description, &v.CreatedAt, &v.UpdatedAt)
+		v, err := scanVideo(rows)
 		if err != nil {
 			zap.S().Errorw("scan video by user id failed", "error", err)
 			continue
@@ -205,3 +298,81 @@ func (r *videoRepository) UpdateDescription(ctx context.Context, id int64, descr
 	}
 	return nil
 }
+
+// UpdateMediaInfo lưu thông tin ffprobe của video.
+func (r *videoRepository) UpdateMediaInfo(ctx context.Context, v *model.Video) error {
+	query := `
+		UPDATE videos
+		SET duration_sec = $2,
+			container = $3,
+			video_codec = $4,
+			audio_codec = $5,
+			audio_channels = $6,
+			sample_rate = $7,
+			width = $8,
+			height = $9,
+			bit_rate = $10,
+			size_bytes = $11,
+			probed_at = $12,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		v.ID,
+		v.DurationSec,
+		v.Container,
+		v.VideoCodec,
+		v.AudioCodec,
+		v.AudioChannels,
+		v.SampleRate,
+		v.Width,
+		v.Height,
+		v.BitRate,
+		v.SizeBytes,
+		v.ProbedAt,
+	)
+	if err != nil {
+		zap.S().Errorw("update video media info failed", "id", v.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *videoRepository) ClaimTranscode(ctx context.Context, id int64, staleAfter time.Duration) (bool, error) {
+	query := `
+		UPDATE videos
+		SET transcode_status = 'pending', transcode_error = NULL, updated_at = NOW()
+		WHERE id = $1
+			AND (transcode_status IS NULL
+				OR transcode_status NOT IN ('pending', 'processing')
+				OR updated_at < NOW() - make_interval(secs => $2))
+	`
+	res, err := r.db.ExecContext(ctx, query, id, staleAfter.Seconds())
+	if err != nil {
+		zap.S().Errorw("claim video transcode failed", "id", id, "error", err)
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// UpdateTranscodeStatus cập nhật trạng thái transcode; hlsURL nil thì giữ URL cũ (bản HLS trước vẫn xem được).
+func (r *videoRepository) UpdateTranscodeStatus(ctx context.Context, id int64, status model.TaskStatus, hlsURL *string, errorMessage *string) error {
+	query := `
+		UPDATE videos
+		SET transcode_status = $2,
+			hls_url = COALESCE($3, hls_url),
+			transcode_error = $4,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id, status, hlsURL, errorMessage)
+	if err != nil {
+		zap.S().Errorw("update video transcode status failed", "id", id, "status", status, "error", err)
+		return err
+	}
+	return nil
+}
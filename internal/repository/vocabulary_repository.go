@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// VocabularyRepository defines operations for vocabularies.
+type VocabularyRepository interface {
+	Create(ctx context.Context, v *model.Vocabulary) error
+	GetByID(ctx context.Context, id int64) (*model.Vocabulary, error)
+	ListForUser(ctx context.Context, userID int64) ([]*model.Vocabulary, error)
+	Update(ctx context.Context, v *model.Vocabulary) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type vocabularyRepository struct {
+	db *sql.DB
+}
+
+// NewVocabularyRepository returns a concrete implementation of VocabularyRepository.
+func NewVocabularyRepository(db *sql.DB) VocabularyRepository {
+	return &vocabularyRepository{db: db}
+}
+
+func (r *vocabularyRepository) Create(ctx context.Context, v *model.Vocabulary) error {
+	query := `
+		INSERT INTO vocabularies (user_id, name, terms)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.
+		QueryRowContext(ctx, query, v.UserID, v.Name, v.Terms).
+		Scan(&v.ID, &v.CreatedAt, &v.UpdatedAt)
+}
+
+func (r *vocabularyRepository) GetByID(ctx context.Context, id int64) (*model.Vocabulary, error) {
+	query := `
+		SELECT id, user_id, name, terms, created_at, updated_at
+		FROM vocabularies
+		WHERE id = $1
+	`
+	v := &model.Vocabulary{}
+	err := r.db.
+		QueryRowContext(ctx, query, id).
+		Scan(&v.ID, &v.UserID, &v.Name, &v.Terms, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			zap.S().Infow("vocabulary not found", "id", id)
+			return nil, errors.New("vocabulary not found")
+		}
+		zap.S().Errorw("get vocabulary by id failed", "id", id, "error", err)
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListForUser trả về vocabulary của user cùng các vocabulary dùng chung.
+func (r *vocabularyRepository) ListForUser(ctx context.Context, userID int64) ([]*model.Vocabulary, error) {
+	query := `
+		SELECT id, user_id, name, terms, created_at, updated_at
+		FROM vocabularies
+		WHERE user_id = $1 OR user_id IS NULL
+		ORDER BY name
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		zap.S().Errorw("list vocabularies failed", "user_id", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	vocabularies := make([]*model.Vocabulary, 0)
+	for rows.Next() {
+		v := &model.Vocabulary{}
+		if err := rows.Scan(&v.ID, &v.UserID, &v.Name, &v.Terms, &v.CreatedAt, &v.UpdatedAt); err != nil {
+			zap.S().Errorw("scan vocabulary failed", "user_id", userID, "error", err)
+			continue
+		}
+		vocabularies = append(vocabularies, v)
+	}
+	return vocabularies, rows.Err()
+}
+
+func (r *vocabularyRepository) Update(ctx context.Context, v *model.Vocabulary) error {
+	query := `
+		UPDATE vocabularies
+		SET name = $1, terms = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING updated_at
+	`
+	if err := r.db.QueryRowContext(ctx, query, v.Name, v.Terms, v.ID).Scan(&v.UpdatedAt); err != nil {
+		zap.S().Errorw("update vocabulary failed", "id", v.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *vocabularyRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM vocabularies WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"video-transcript/internal/model"
+)
+
+// BuildASS tạo file phụ đề ASS (dùng cho filter ass của ffmpeg) kích thước width x height theo style.
+// Bật word_highlight thì mỗi từ là 1 event riêng, cả cue hiển thị và từ đang đọc được tô HighlightColor.
+func BuildASS(cues []Cue, style model.CaptionStyle, width, height int) string {
+	style = style.WithDefaults()
+
+	fontSize := style.FontSize
+	if fontSize == 0 {
+		fontSize = int(math.Round(float64(height) / 20))
+	}
+	marginV := style.MarginV
+	if marginV == 0 {
+		marginV = int(math.Round(float64(height) / 18))
+	}
+	alignment := 2 // bottom center (numpad)
+	switch style.Position {
+	case model.CaptionPositionMiddle:
+		alignment = 5
+	case model.CaptionPositionTop:
+		alignment = 8
+	}
+
+	// BorderStyle 3 = hộp nền đục, màu hộp lấy từ OutlineColour.
+	borderStyle, outline, shadow := 1, math.Max(2, float64(fontSize)/16), 0.0
+	outlineColour := assColor(style.OutlineColor, 1)
+	if model.BoolValue(style.BackgroundBox) {
+		borderStyle, outline = 3, math.Max(4, float64(fontSize)/6)
+		outlineColour = assColor(style.BackgroundColor, *style.BackgroundOpacity)
+	}
+	bold := 0
+	if model.BoolValue(style.Bold) {
+		bold = -1
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Script Info]\nScriptType: v4.00+\nPlayResX: %d\nPlayResY: %d\nWrapStyle: 0\nScaledBorderAndShadow: yes\n\n", width, height)
+	b.WriteString("[V4+ Styles]\n")
+	b.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	fmt.Fprintf(&b, "Style: Default,%s,%d,%s,%s,%s,%s,%d,0,0,0,100,100,0,0,%d,%.1f,%.1f,%d,%d,%d,%d,1\n\n",
+		style.Font, fontSize,
+		assColor(style.PrimaryColor, 1), assColor(style.HighlightColor, 1), outlineColour, assColor("#000000", 0.5),
+		bold, borderStyle, outline, shadow, alignment, width/20, width/20, marginV)
+
+	b.WriteString("[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	highlight := model.BoolValue(style.WordHighlight)
+	for _, c := range cues {
+		if !highlight || len(c.Words) == 0 {
+			writeASSEvent(&b, c.Start, c.End, assText(c.Text))
+			continue
+		}
+		// Mỗi từ hiển thị từ lúc bắt đầu tới khi từ sau bắt đầu, để cue không bị nháy giữa 2 từ.
+		for i, w := range c.Words {
+			end := c.End
+			if i+1 < len(c.Words) {
+				end = c.Words[i+1].Start
+			}
+			if i == 0 {
+				writeASSEvent(&b, c.Start, w.Start, assWords(c.Words, -1, style.HighlightColor))
+			}
+			writeASSEvent(&b, w.Start, end, assWords(c.Words, i, style.HighlightColor))
+		}
+	}
+	return b.String()
+}
+
+func writeASSEvent(b *strings.Builder, start, end float64, text string) {
+	if end <= start {
+		return
+	}
+	fmt.Fprintf(b, "Dialogue: 0,%s,%s,Default,,0,0,0,,%s\n", assTime(start), assTime(end), text)
+}
+
+// assWords nối các từ của cue, từ thứ active được tô màu highlight (active < 0 = không tô).
+func assWords(words []model.SimpleWord, active int, highlight string) string {
+	parts := make([]string, len(words))
+	for i, w := range words {
+		text := assText(w.Word)
+		if i == active {
+			text = fmt.Sprintf(`{\c%s}%s{\r}`, assColorTag(highlight), text)
+		}
+		parts[i] = text
+	}
+	return strings.Join(parts, " ")
+}
+
+// assText bỏ các ký tự điều khiển của ASS ({...} là override tag, \ là escape) và đổi xuống dòng thành \N.
+func assText(s string) string {
+	s = strings.NewReplacer("{", "(", "}", ")", `\`, "/").Replace(s)
+	return strings.ReplaceAll(s, "\n", `\N`)
+}
+
+// assColor đổi #RRGGBB + độ đục (0..1) thành &HAABBGGRR (alpha của ASS: 00 = đục, FF = trong suốt).
+func assColor(hex string, opacity float64) string {
+	r, g, bl := hexRGB(hex)
+	alpha := int(math.Round((1 - opacity) * 255))
+	return fmt.Sprintf("&H%02X%02X%02X%02X", alpha, bl, g, r)
+}
+
+// assColorTag đổi #RRGGBB thành giá trị cho override tag \c (&HBBGGRR&).
+func assColorTag(hex string) string {
+	r, g, b := hexRGB(hex)
+	return fmt.Sprintf("&H%02X%02X%02X&", b, g, r)
+}
+
+func hexRGB(hex string) (r, g, b int) {
+	_, _ = fmt.Sscanf(strings.TrimPrefix(hex, "#"), "%02x%02x%02x", &r, &g, &b)
+	return r, g, b
+}
+
+// assTime định dạng giây thành H:MM:SS.cc (ASS dùng centisecond).
+func assTime(sec float64) string {
+	if sec < 0 {
+		sec = 0
+	}
+	cs := int64(math.Round(sec * 100))
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
@@ -1,20 +1,41 @@
 package service
 
 import (
-	"os/exec"
+	"context"
+	"strconv"
 )
 
-// ExtractAudio extracts audio from video to MP3 format
-func ExtractAudio(videoPath, audioPath string) error {
-	cmd := exec.Command("ffmpeg",
+// ExtractAudio extracts mono 16 kHz MP3 audio from video.
+// ctx bị huỷ thì ffmpeg bị dừng; lỗi trả về kèm stderr của ffmpeg (*FFmpegError).
+func ExtractAudio(ctx context.Context, videoPath, audioPath string) error {
+	return runFFmpeg(ctx,
 		"-i", videoPath,
 		"-vn",                   // No video
 		"-acodec", "libmp3lame", // MP3 codec
-		"-b:a", "192k", // Audio bitrate 192kbps (chất lượng tốt)
+		"-b:a", "64k", // 16 kHz mono (MPEG-2 layer III) tối đa 160k, 64k đủ cho giọng nói
 		"-ar", "16000", // Sample rate 16kHz (Whisper khuyến nghị)
 		"-ac", "1", // Mono
+		"-y",
 		audioPath,
+	)
+}
+
+// CutAudio cắt đoạn [start, end) giây của audioPath ra outPath (encode lại để cắt chính xác, không phụ thuộc frame MP3).
+func CutAudio(ctx context.Context, audioPath, outPath string, start, end float64) error {
+	return runFFmpeg(ctx,
+		"-ss", formatSeconds(start),
+		"-t", formatSeconds(end-start),
+		"-i", audioPath,
+		"-vn",
+		"-acodec", "libmp3lame",
+		"-b:a", "64k",
+		"-ar", "16000",
+		"-ac", "1",
 		"-y",
+		outPath,
 	)
-	return cmd.Run()
+}
+
+func formatSeconds(sec float64) string {
+	return strconv.FormatFloat(sec, 'f', 3, 64)
 }
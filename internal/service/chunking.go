@@ -0,0 +1,282 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"video-transcript/internal/model"
+)
+
+// AudioChunk là 1 đoạn audio cắt từ file dài để transcribe riêng.
+// Start/End là đoạn thực sự được cắt (đã gồm overlap); CutStart/CutEnd là phần chunk
+// chịu trách nhiệm khi ghép transcript (không gồm overlap). Thời gian tính theo file gốc.
+type AudioChunk struct {
+	Index      int
+	Start      float64
+	End        float64
+	CutStart   float64
+	CutEnd     float64
+	URL        string
+	StorageKey string
+}
+
+// chunkTranscript là transcript của 1 chunk, thời gian đã cộng offset về file gốc.
+type chunkTranscript struct {
+	chunk      AudioChunk
+	transcript *model.SimpleTranscript
+}
+
+const (
+	// wordMatchWindow: 2 từ giống nhau ở 2 chunk lệch nhau ít hơn khoảng này coi là cùng 1 từ.
+	wordMatchWindow = 0.3
+	timeEpsilon     = 0.001
+)
+
+// planChunks chia file dài duration giây thành các chunk dài khoảng target giây,
+// ưu tiên cắt ở giữa khoảng lặng gần điểm mục tiêu nhất; mỗi chunk nới thêm overlap giây 2 đầu.
+func planChunks(duration float64, silences []Silence, target, overlap float64) []AudioChunk {
+	bounds := []float64{0}
+	pos := 0.0
+	for duration-pos > target*1.5 {
+		desired := pos + target
+		cut := desired
+		bestDist := math.MaxFloat64
+		for _, s := range silences {
+			mid := s.Mid()
+			if mid <= pos+target/2 || mid >= pos+target*1.5 {
+				continue
+			}
+			if d := math.Abs(mid - desired); d < bestDist {
+				cut, bestDist = mid, d
+			}
+		}
+		bounds = append(bounds, cut)
+		pos = cut
+	}
+	bounds = append(bounds, duration)
+
+	chunks := make([]AudioChunk, 0, len(bounds)-1)
+	for i := 0; i+1 < len(bounds); i++ {
+		chunks = append(chunks, AudioChunk{
+			Index:    i,
+			CutStart: bounds[i],
+			CutEnd:   bounds[i+1],
+			Start:    math.Max(0, bounds[i]-overlap),
+			End:      math.Min(duration, bounds[i+1]+overlap),
+		})
+	}
+	return chunks
+}
+
+// shiftTranscript cộng offset (giây) vào mọi mốc thời gian của transcript.
+func shiftTranscript(t *model.SimpleTranscript, offset float64) {
+	for i := range t.Words {
+		t.Words[i].Start += offset
+		t.Words[i].End += offset
+	}
+	for i := range t.Utterances {
+		t.Utterances[i].Start += offset
+		t.Utterances[i].End += offset
+	}
+}
+
+// mergeChunkTranscripts ghép transcript các chunk (đã sắp theo thứ tự, thời gian tuyệt đối) thành 1:
+// mỗi chunk chỉ giữ từ nằm trong [CutStart, CutEnd), từ trùng ở ranh giới bị loại, speaker label
+// của chunk sau được map về label của chunk trước dựa trên các từ khớp nhau trong vùng overlap.
+func mergeChunkTranscripts(parts []chunkTranscript) *model.SimpleTranscript {
+	out := &model.SimpleTranscript{Words: []model.SimpleWord{}, Utterances: []model.SimpleUtterance{}}
+
+	nextSpeaker := 0
+	var prevWords []model.SimpleWord // từ của chunk trước, speaker đã map
+	languageSeconds := make(map[string]float64)
+	languageConfidence := make(map[string]float64)
+	seenLanguages := make(map[string]bool)
+
+	for i, part := range parts {
+		t := part.transcript
+		if t == nil {
+			continue
+		}
+
+		mapping := mapSpeakers(prevWords, t.Words, part.chunk, &nextSpeaker)
+		words := make([]model.SimpleWord, len(t.Words))
+		for j, w := range t.Words {
+			w.Speaker = mappedSpeaker(mapping, w.Speaker)
+			words[j] = w
+		}
+		prevWords = words
+
+		// Chọn từ thuộc phần chunk sở hữu, bỏ từ trùng với từ cuối đã ghép ở ranh giới.
+		last := i == len(parts)-1
+		kept := make([]model.SimpleWord, 0, len(words))
+		for _, w := range words {
+			if w.Start < part.chunk.CutStart-timeEpsilon {
+				continue
+			}
+			if w.Start >= part.chunk.CutEnd && !last {
+				continue
+			}
+			if n := len(out.Words); n > 0 && isDuplicateWord(out.Words[n-1], w) {
+				continue
+			}
+			kept = append(kept, w)
+			out.Words = append(out.Words, w)
+		}
+
+		out.Utterances = append(out.Utterances, rebuildUtterances(t.Utterances, kept, mapping)...)
+
+		if t.DetectedLanguage != "" {
+			languageSeconds[t.DetectedLanguage] += part.chunk.CutEnd - part.chunk.CutStart
+			languageConfidence[t.DetectedLanguage] += t.LanguageConfidence * (part.chunk.CutEnd - part.chunk.CutStart)
+		}
+		for _, lang := range t.Languages {
+			if !seenLanguages[lang] {
+				seenLanguages[lang] = true
+				out.Languages = append(out.Languages, lang)
+			}
+		}
+	}
+
+	// Ngôn ngữ chính = ngôn ngữ được nhận diện ở nhiều thời lượng nhất.
+	for lang, seconds := range languageSeconds {
+		if out.DetectedLanguage == "" || seconds > languageSeconds[out.DetectedLanguage] ||
+			(seconds == languageSeconds[out.DetectedLanguage] && lang < out.DetectedLanguage) {
+			out.DetectedLanguage = lang
+		}
+	}
+	if out.DetectedLanguage != "" && languageSeconds[out.DetectedLanguage] > 0 {
+		out.LanguageConfidence = languageConfidence[out.DetectedLanguage] / languageSeconds[out.DetectedLanguage]
+	}
+
+	text := make([]string, 0, len(out.Words))
+	for _, w := range out.Words {
+		text = append(text, w.Word)
+	}
+	out.TranscriptText = strings.Join(text, " ")
+	return out
+}
+
+// isDuplicateWord: từ ở chunk sau trùng từ cuối đã ghép (cùng chữ, gần cùng thời điểm)
+// hoặc bắt đầu trước khi từ cuối kết thúc.
+func isDuplicateWord(last, w model.SimpleWord) bool {
+	if compactWord(last.Word) == compactWord(w.Word) && math.Abs(w.Start-last.Start) < wordMatchWindow {
+		return true
+	}
+	return w.Start < last.End-0.1
+}
+
+// mapSpeakers map speaker label của chunk hiện tại sang label toàn cục. Các cặp từ khớp nhau
+// (cùng chữ, lệch < wordMatchWindow) trong vùng overlap với chunk trước là phiếu bầu; speaker
+// không khớp được nhận label mới.
+func mapSpeakers(prevWords, words []model.SimpleWord, chunk AudioChunk, nextSpeaker *int) map[int]int {
+	votes := make(map[[2]int]int) // {local, global} -> số từ khớp
+	for _, w := range words {
+		if w.Speaker == nil || w.Start > chunk.CutStart+(chunk.CutStart-chunk.Start)+wordMatchWindow {
+			continue
+		}
+		for _, p := range prevWords {
+			if p.Speaker == nil || math.Abs(p.Start-w.Start) >= wordMatchWindow {
+				continue
+			}
+			if compactWord(p.Word) == compactWord(w.Word) {
+				votes[[2]int{*w.Speaker, *p.Speaker}]++
+				break
+			}
+		}
+	}
+
+	type vote struct {
+		local, global, count int
+	}
+	ranked := make([]vote, 0, len(votes))
+	for k, c := range votes {
+		ranked = append(ranked, vote{local: k[0], global: k[1], count: c})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].count != ranked[j].count {
+			return ranked[i].count > ranked[j].count
+		}
+		if ranked[i].local != ranked[j].local {
+			return ranked[i].local < ranked[j].local
+		}
+		return ranked[i].global < ranked[j].global
+	})
+
+	mapping := make(map[int]int)
+	usedGlobal := make(map[int]bool)
+	for _, v := range ranked {
+		if _, ok := mapping[v.local]; ok || usedGlobal[v.global] {
+			continue
+		}
+		mapping[v.local] = v.global
+		usedGlobal[v.global] = true
+	}
+
+	// Speaker chưa map (người mới xuất hiện, hoặc chunk đầu tiên) nhận label mới theo thứ tự xuất hiện.
+	for _, w := range words {
+		if w.Speaker == nil {
+			continue
+		}
+		if _, ok := mapping[*w.Speaker]; !ok {
+			mapping[*w.Speaker] = *nextSpeaker
+			*nextSpeaker++
+		}
+	}
+	for g := range usedGlobal {
+		if g >= *nextSpeaker {
+			*nextSpeaker = g + 1
+		}
+	}
+	return mapping
+}
+
+func mappedSpeaker(mapping map[int]int, speaker *int) *int {
+	if speaker == nil {
+		return nil
+	}
+	g, ok := mapping[*speaker]
+	if !ok {
+		return speaker
+	}
+	return &g
+}
+
+// rebuildUtterances dựng lại utterance của 1 chunk chỉ từ các từ được giữ lại sau khi ghép.
+func rebuildUtterances(utterances []model.SimpleUtterance, kept []model.SimpleWord, mapping map[int]int) []model.SimpleUtterance {
+	if len(kept) == 0 {
+		return nil
+	}
+	if len(utterances) == 0 {
+		return []model.SimpleUtterance{utteranceFromWords(kept, "", kept[0].Speaker)}
+	}
+
+	out := make([]model.SimpleUtterance, 0, len(utterances))
+	for _, u := range utterances {
+		var words []model.SimpleWord
+		for _, w := range kept {
+			if w.Start >= u.Start-timeEpsilon && w.Start < u.End+timeEpsilon {
+				words = append(words, w)
+			}
+		}
+		if len(words) == 0 {
+			continue
+		}
+		out = append(out, utteranceFromWords(words, u.Language, mappedSpeaker(mapping, u.Speaker)))
+	}
+	return out
+}
+
+func utteranceFromWords(words []model.SimpleWord, language string, speaker *int) model.SimpleUtterance {
+	text := make([]string, 0, len(words))
+	for _, w := range words {
+		text = append(text, w.Word)
+	}
+	return model.SimpleUtterance{
+		Start:      words[0].Start,
+		End:        words[len(words)-1].End,
+		Transcript: strings.Join(text, " "),
+		Language:   language,
+		Speaker:    speaker,
+	}
+}
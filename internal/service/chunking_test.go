@@ -0,0 +1,129 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"video-transcript/internal/model"
+)
+
+func TestPlanChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration float64
+		silences []Silence
+		want     [][2]float64 // {CutStart, CutEnd}
+	}{
+		{"short file is one chunk", 80, nil, [][2]float64{{0, 80}}},
+		{"no silences cuts at target", 200, nil, [][2]float64{{0, 60}, {60, 120}, {120, 200}}},
+		{
+			"cuts at nearest silence",
+			200,
+			[]Silence{{Start: 10, End: 12}, {Start: 50, End: 52}, {Start: 70, End: 74}, {Start: 100, End: 130}},
+			[][2]float64{{0, 51}, {51, 115}, {115, 200}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := planChunks(tt.duration, tt.silences, 60, 2)
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("got %d chunks, want %d: %+v", len(chunks), len(tt.want), chunks)
+			}
+			for i, c := range chunks {
+				if c.Index != i || c.CutStart != tt.want[i][0] || c.CutEnd != tt.want[i][1] {
+					t.Errorf("chunk %d = %+v, want cut %v", i, c, tt.want[i])
+				}
+				if c.Start != math.Max(0, c.CutStart-2) || c.End != math.Min(tt.duration, c.CutEnd+2) {
+					t.Errorf("chunk %d = [%g, %g], want overlap of 2s clamped to the file", i, c.Start, c.End)
+				}
+			}
+		})
+	}
+}
+
+func speakerPtr(s int) *int { return &s }
+
+func chunkWord(word string, start, end float64, speaker int) model.SimpleWord {
+	return model.SimpleWord{Word: word, Start: start, End: end, Speaker: speakerPtr(speaker)}
+}
+
+func TestMergeChunkTranscripts(t *testing.T) {
+	first := AudioChunk{Index: 0, Start: 0, End: 12, CutStart: 0, CutEnd: 10}
+	second := AudioChunk{Index: 1, Start: 8, End: 20, CutStart: 10, CutEnd: 20}
+
+	tests := []struct {
+		name     string
+		parts    []chunkTranscript
+		text     string
+		speakers []int
+	}{
+		{
+			"speakers mapped through overlap",
+			[]chunkTranscript{
+				{chunk: first, transcript: &model.SimpleTranscript{Words: []model.SimpleWord{
+					chunkWord("hello", 1, 1.5, 0), chunkWord("there", 9, 9.5, 0), chunkWord("friend", 10.2, 10.6, 0),
+				}}},
+				// Provider đánh label khác ở chunk sau: speaker 1 ở đây là speaker 0 của chunk trước.
+				{chunk: second, transcript: &model.SimpleTranscript{Words: []model.SimpleWord{
+					chunkWord("there", 9.05, 9.5, 1), chunkWord("friend", 10.25, 10.6, 1), chunkWord("how", 12, 12.3, 0), chunkWord("are", 13, 13.2, 1),
+				}}},
+			},
+			"hello there friend how are",
+			[]int{0, 0, 0, 1, 0},
+		},
+		{
+			"duplicate word at boundary dropped",
+			[]chunkTranscript{
+				{chunk: first, transcript: &model.SimpleTranscript{Words: []model.SimpleWord{
+					chunkWord("one", 5, 5.4, 0), chunkWord("two", 9.9, 10.3, 0),
+				}}},
+				{chunk: second, transcript: &model.SimpleTranscript{Words: []model.SimpleWord{
+					chunkWord("two", 10.0, 10.3, 0), chunkWord("three", 11, 11.4, 0),
+				}}},
+			},
+			"one two three",
+			[]int{0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := mergeChunkTranscripts(tt.parts)
+			if out.TranscriptText != tt.text {
+				t.Errorf("text = %q, want %q", out.TranscriptText, tt.text)
+			}
+			if len(out.Words) != len(tt.speakers) {
+				t.Fatalf("got %d words, want %d", len(out.Words), len(tt.speakers))
+			}
+			for i, w := range out.Words {
+				if w.Speaker == nil || *w.Speaker != tt.speakers[i] {
+					t.Errorf("word %d (%s) speaker = %v, want %d", i, w.Word, w.Speaker, tt.speakers[i])
+				}
+			}
+			var utterances []string
+			for _, u := range out.Utterances {
+				utterances = append(utterances, u.Transcript)
+			}
+			if strings.Join(utterances, " ") != tt.text {
+				t.Errorf("utterances = %q, want them to cover %q", utterances, tt.text)
+			}
+		})
+	}
+}
+
+func TestMergeChunkTranscriptsLanguage(t *testing.T) {
+	out := mergeChunkTranscripts([]chunkTranscript{
+		{chunk: AudioChunk{CutStart: 0, CutEnd: 30}, transcript: &model.SimpleTranscript{DetectedLanguage: "en", LanguageConfidence: 0.9, Languages: []string{"en"}}},
+		{chunk: AudioChunk{CutStart: 30, CutEnd: 40}, transcript: &model.SimpleTranscript{DetectedLanguage: "es", LanguageConfidence: 0.6, Languages: []string{"es", "en"}}},
+		{chunk: AudioChunk{CutStart: 40, CutEnd: 60}, transcript: &model.SimpleTranscript{DetectedLanguage: "en", LanguageConfidence: 0.6, Languages: []string{"en"}}},
+	})
+	if out.DetectedLanguage != "en" {
+		t.Errorf("detected language = %q, want en", out.DetectedLanguage)
+	}
+	if math.Abs(out.LanguageConfidence-0.78) > 1e-9 {
+		t.Errorf("confidence = %g, want 0.78 (weighted by chunk length)", out.LanguageConfidence)
+	}
+	if strings.Join(out.Languages, ",") != "en,es" {
+		t.Errorf("languages = %v, want [en es]", out.Languages)
+	}
+}
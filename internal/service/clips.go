@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"video-transcript/internal/model"
+)
+
+const (
+	// defaultClipPaddingSec: nới thêm 2 đầu clip chọn theo utterance để không mất hơi đầu / cuối câu.
+	defaultClipPaddingSec = 0.2
+	// titleCardLineChars: số ký tự tối đa 1 dòng chữ trên title card.
+	titleCardLineChars = 40
+	// Audio của reel được đưa về chung 1 format để nối với khoảng lặng của title card.
+	reelSampleRate    = 48000
+	reelChannelLayout = "stereo"
+)
+
+// clipPlan là 1 clip đã xác định thời gian trên video nguồn, kèm transcript riêng của clip.
+type clipPlan struct {
+	Segment    EditSegment
+	Title      string
+	Transcript *model.SimpleTranscript // timestamp tính từ đầu clip
+}
+
+// planClips đổi các ClipRange (thời gian hoặc utterance) thành đoạn trên video nguồn và cắt transcript tương ứng.
+func planClips(t *model.SimpleTranscript, duration float64, req *model.ClipsRequest) ([]clipPlan, error) {
+	padding := defaultClipPaddingSec
+	if req.PaddingSec != nil {
+		padding = *req.PaddingSec
+	}
+
+	clips := make([]clipPlan, 0, len(req.Clips))
+	for i, c := range req.Clips {
+		var seg EditSegment
+		if c.ByUtterance() {
+			from, to := *c.UtteranceFrom, *c.UtteranceTo
+			if to >= len(t.Utterances) {
+				return nil, fmt.Errorf("%w: clip %d: utterance range [%d, %d] out of transcript (%d utterances)", ErrInvalidEdit, i, from, to, len(t.Utterances))
+			}
+			seg = EditSegment{Start: t.Utterances[from].Start - padding, End: t.Utterances[to].End + padding}
+		} else {
+			seg = EditSegment{Start: *c.Start, End: *c.End}
+		}
+		seg.Start, seg.End = max(0, seg.Start), min(duration, seg.End)
+		if seg.End-seg.Start < editMinSegmentSec {
+			return nil, fmt.Errorf("%w: clip %d is outside the video", ErrInvalidEdit, i)
+		}
+
+		clips = append(clips, clipPlan{
+			Segment:    seg,
+			Title:      strings.TrimSpace(c.Title),
+			Transcript: retimeTranscript(t, t.Words, []EditSegment{seg}, 0),
+		})
+	}
+	return clips, nil
+}
+
+// reelTranscript ghép transcript các clip theo thứ tự trong reel (title card chỉ có khi withTitles).
+func reelTranscript(clips []clipPlan, titleSec float64, withTitles bool) *model.SimpleTranscript {
+	out := &model.SimpleTranscript{Words: []model.SimpleWord{}, Utterances: []model.SimpleUtterance{}}
+	offset := 0.0
+	text := []string{}
+	for _, c := range clips {
+		if withTitles && c.Title != "" {
+			offset += titleSec
+		}
+		part := *c.Transcript
+		part.Words = append([]model.SimpleWord(nil), c.Transcript.Words...)
+		part.Utterances = append([]model.SimpleUtterance(nil), c.Transcript.Utterances...)
+		shiftTranscript(&part, offset)
+
+		out.Words = append(out.Words, part.Words...)
+		out.Utterances = append(out.Utterances, part.Utterances...)
+		if part.TranscriptText != "" {
+			text = append(text, part.TranscriptText)
+		}
+		if out.DetectedLanguage == "" {
+			out.DetectedLanguage, out.LanguageConfidence, out.Languages = part.DetectedLanguage, part.LanguageConfidence, part.Languages
+		}
+		offset += c.Segment.End - c.Segment.Start
+	}
+	out.TranscriptText = strings.Join(text, " ")
+	return out
+}
+
+// RenderReel ghép các clip thành 1 highlight reel, clip có Title được mở đầu bằng title card
+// (nền đen, chữ trắng ở giữa, titleSec giây, audio im lặng). File chỉ có audio thì bỏ qua title card.
+func RenderReel(ctx context.Context, sourcePath, outPath string, clips []clipPlan, titleSec float64, info *model.MediaInfo) error {
+	hasVideo, hasAudio := info.VideoCodec != "", info.AudioCodec != ""
+	width, height := renderSize(model.RenderPreset{}, info)
+	audioFormat := fmt.Sprintf("aformat=sample_rates=%d:channel_layouts=%s", reelSampleRate, reelChannelLayout)
+
+	var chains, parts []string
+	for i, c := range clips {
+		if hasVideo && c.Title != "" {
+			textPath := fmt.Sprintf("%s.title%d.txt", outPath, i)
+			if err := os.WriteFile(textPath, []byte(wrapTitle(c.Title, titleCardLineChars)), 0o600); err != nil {
+				return err
+			}
+			defer os.Remove(textPath)
+
+			chains = append(chains, fmt.Sprintf(
+				"color=c=black:s=%dx%d:r=30:d=%s,format=yuv420p,drawtext=textfile=%s:fontcolor=white:fontsize=h/18:line_spacing=h/60:x=(w-text_w)/2:y=(h-text_h)/2[tv%d]",
+				width, height, formatSeconds(titleSec), escapeFilterPath(textPath), i))
+			parts = append(parts, fmt.Sprintf("[tv%d]", i))
+			if hasAudio {
+				chains = append(chains, fmt.Sprintf("anullsrc=r=%d:cl=%s,atrim=duration=%s[ta%d]", reelSampleRate, reelChannelLayout, formatSeconds(titleSec), i))
+				parts = append(parts, fmt.Sprintf("[ta%d]", i))
+			}
+		}
+
+		start, end := formatSeconds(c.Segment.Start), formatSeconds(c.Segment.End)
+		if hasVideo {
+			chains = append(chains, fmt.Sprintf("[0:v]trim=start=%s:end=%s,setpts=PTS-STARTPTS,scale=%d:%d,setsar=1,format=yuv420p[cv%d]", start, end, width, height, i))
+			parts = append(parts, fmt.Sprintf("[cv%d]", i))
+		}
+		if hasAudio {
+			chains = append(chains, fmt.Sprintf("[0:a]atrim=start=%s:end=%s,asetpts=PTS-STARTPTS,%s[ca%d]", start, end, audioFormat, i))
+			parts = append(parts, fmt.Sprintf("[ca%d]", i))
+		}
+	}
+
+	streams := boolToInt(hasVideo) + boolToInt(hasAudio)
+	if streams == 0 {
+		return model.ErrCorruptMedia
+	}
+	var outputs []string
+	if hasVideo {
+		outputs = append(outputs, "[vout]")
+	}
+	if hasAudio {
+		outputs = append(outputs, "[aout]")
+	}
+	chains = append(chains, fmt.Sprintf("%sconcat=n=%d:v=%d:a=%d%s",
+		strings.Join(parts, ""), len(parts)/streams, boolToInt(hasVideo), boolToInt(hasAudio), strings.Join(outputs, "")))
+
+	return runFilterScript(ctx, sourcePath, outPath, strings.Join(chains, ";\n")+"\n", outputs, hasVideo, hasAudio)
+}
+
+// wrapTitle ngắt title thành các dòng không quá lineChars ký tự (ngắt tại khoảng trắng).
+func wrapTitle(title string, lineChars int) string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(title) {
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > lineChars {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// clipFileName là tên file tạm của clip thứ i trong workDir.
+func clipFileName(workDir string, i int, hasVideo bool) string {
+	return filepath.Join(workDir, fmt.Sprintf("clip-%d%s", i+1, renderExt(hasVideo)))
+}
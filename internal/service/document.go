@@ -0,0 +1,415 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"video-transcript/internal/model"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Tài liệu nguồn của audiobook được đưa về chuỗi docBlock (heading / đoạn văn) rồi tách chương theo heading.
+
+// docBlock là 1 heading (Level 1-6) hoặc 1 đoạn văn (Level 0) của tài liệu.
+type docBlock struct {
+	Level int
+	Text  string
+}
+
+// ErrUnsupportedDocument trả về khi đuôi file không phải txt / md / html / epub.
+var ErrUnsupportedDocument = errors.New("unsupported document format (supported: .txt, .md, .html, .epub)")
+
+// ParseDocument đọc tài liệu (định dạng theo đuôi file name) và tách thành các chương.
+// Tên sách lấy theo metadata (<title>, dc:title), heading cấp cao nhất chỉ xuất hiện 1 lần, cuối cùng là tên file.
+func ParseDocument(name string, data []byte) (*model.Document, error) {
+	format, ok := model.DocumentFormatByName(name)
+	if !ok {
+		return nil, ErrUnsupportedDocument
+	}
+
+	var (
+		title  string
+		blocks []docBlock
+		err    error
+	)
+	switch format {
+	case model.DocumentFormatEPUB:
+		title, blocks, err = epubBlocks(data)
+	case model.DocumentFormatHTML:
+		title, blocks, err = htmlBlocks(data)
+	default:
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		if !utf8.Valid(data) {
+			return nil, errors.New("document must be UTF-8 text")
+		}
+		if format == model.DocumentFormatMarkdown {
+			blocks = markdownBlocks(string(data))
+		} else {
+			blocks = textBlocks(string(data))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	headingTitle, chapters := buildChapters(blocks)
+	if title == "" {
+		title = headingTitle
+	}
+	if title == "" {
+		title = strings.TrimSuffix(path.Base(strings.ReplaceAll(name, "\\", "/")), path.Ext(name))
+	}
+	if len(chapters) == 0 {
+		return nil, errors.New("document has no text")
+	}
+	if len(chapters) > model.MaxAudiobookChapters {
+		return nil, fmt.Errorf("document has too many chapters: %d (max %d)", len(chapters), model.MaxAudiobookChapters)
+	}
+	for i := range chapters {
+		if chapters[i].Title != "" {
+			continue
+		}
+		// Chỉ có phần mở đầu trước chương đầu tiên là chưa có tên.
+		if len(chapters) == 1 {
+			chapters[i].Title = title
+		} else {
+			chapters[i].Title = "Introduction"
+		}
+	}
+	return &model.Document{Title: title, Format: format, Chapters: chapters}, nil
+}
+
+// buildChapters tách chương tại cấp heading nhỏ nhất xuất hiện ít nhất 2 lần (tài liệu chỉ có 1 heading thì
+// dùng heading đó). Heading cấp cao hơn đứng trước chương đầu tiên là tên sách, các heading cấp cao hơn
+// khác (vd "Part One") được đọc ở đầu chương kế tiếp, heading cấp thấp hơn được đọc như 1 đoạn văn.
+func buildChapters(blocks []docBlock) (string, []model.DocumentChapter) {
+	var counts [7]int
+	for _, b := range blocks {
+		counts[b.Level]++
+	}
+	level := 0
+	for l := 1; l <= 6 && level == 0; l++ {
+		if counts[l] >= 2 {
+			level = l
+		}
+	}
+	for l := 1; l <= 6 && level == 0; l++ {
+		if counts[l] > 0 {
+			level = l
+		}
+	}
+
+	var (
+		title    string
+		chapters []model.DocumentChapter
+		current  *model.DocumentChapter
+		paras    []string
+		pending  []string
+	)
+	flush := func() {
+		text := strings.Join(paras, "\n\n")
+		if current != nil {
+			current.Text = text
+			chapters = append(chapters, *current)
+		} else if text != "" {
+			chapters = append(chapters, model.DocumentChapter{Text: text})
+		}
+		paras = nil
+	}
+	for _, b := range blocks {
+		switch {
+		case b.Level == 0 || b.Level > level:
+			paras = append(paras, b.Text)
+		case b.Level == level:
+			flush()
+			current = &model.DocumentChapter{Title: b.Text}
+			paras, pending = pending, nil
+			if title == "" && counts[level] == 1 {
+				title = b.Text
+			}
+		case title == "" && current == nil && len(paras) == 0:
+			title = b.Text
+		default:
+			pending = append(pending, b.Text)
+		}
+	}
+	paras = append(paras, pending...)
+	flush()
+	return title, chapters
+}
+
+// joinWords gộp khoảng trắng (kể cả xuống dòng) thành 1 dấu cách.
+func joinWords(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var (
+	// Dòng tên chương trong text thường: "Chapter 3", "CHAPTER IV: The Storm", "Chương 2 - ...", "Part One".
+	textPartPattern    = regexp.MustCompile(`(?i)^(part|book|phần|quyển)\s+([0-9]+|[ivxlcdm]+|\p{L}+)\s*([.:\-–—]\s*.*)?$`)
+	textChapterPattern = regexp.MustCompile(`(?i)^(chapter|chương)\s+([0-9]+|[ivxlcdm]+|\p{L}+)\s*([.:\-–—]\s*.*)?$`)
+	textSectionPattern = regexp.MustCompile(`(?i)^(prologue|epilogue|preface|foreword|afterword|lời mở đầu|lời tựa|lời kết|phần kết)\s*([.:\-–—]\s*.*)?$`)
+)
+
+const maxTextHeadingLength = 80
+
+// textBlocks tách text thường thành đoạn văn (phân cách bằng dòng trống); dòng ngắn có dạng tên chương
+// là heading dù không có dòng trống bao quanh.
+func textBlocks(text string) []docBlock {
+	var (
+		blocks []docBlock
+		para   []string
+	)
+	flush := func() {
+		if s := joinWords(strings.Join(para, " ")); s != "" {
+			blocks = append(blocks, docBlock{Text: s})
+		}
+		para = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		if utf8.RuneCountInString(line) <= maxTextHeadingLength {
+			level := 0
+			switch {
+			case textPartPattern.MatchString(line):
+				level = 1
+			case textChapterPattern.MatchString(line), textSectionPattern.MatchString(line):
+				level = 2
+			}
+			if level > 0 {
+				flush()
+				blocks = append(blocks, docBlock{Level: level, Text: joinWords(line)})
+				continue
+			}
+		}
+		para = append(para, line)
+	}
+	flush()
+	return blocks
+}
+
+var (
+	mdATXHeadingPattern    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdSetextPattern        = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdRulePattern          = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	mdFencePattern         = regexp.MustCompile("^ {0,3}(```|~~~)")
+	mdListItemPattern      = regexp.MustCompile(`^\s*(?:[-*+]|\d{1,9}[.)])\s+`)
+	mdQuotePattern         = regexp.MustCompile(`^\s*(?:>\s?)+`)
+	mdLinkDefPattern       = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s`)
+	mdTableRulePattern     = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdImagePattern         = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLinkPattern          = regexp.MustCompile(`\[([^\]]+)\](?:\([^)]*\)|\[[^\]]*\])`)
+	mdAutolinkPattern      = regexp.MustCompile(`<(?:https?|mailto):[^>]*>`)
+	mdHTMLTagPattern       = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	mdCodePattern          = regexp.MustCompile("`+([^`]*)`+")
+	mdEmphasisPattern      = regexp.MustCompile(`\*+|~~|(^|\W)_+|_+(\W|$)`)
+	mdEscapePattern        = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!|])`)
+	mdFrontMatterDelimiter = "---"
+)
+
+// markdownBlocks tách Markdown thành heading (ATX "# ..." và setext) và đoạn văn đã bỏ cú pháp inline.
+// Khối code, front matter, định nghĩa link và đường kẻ ngang không được đọc; mỗi mục danh sách là 1 đoạn.
+func markdownBlocks(text string) []docBlock {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == mdFrontMatterDelimiter {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == mdFrontMatterDelimiter {
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+
+	var (
+		blocks []docBlock
+		para   []string
+		fence  string
+	)
+	flush := func() {
+		if s := markdownInline(strings.Join(para, " ")); s != "" {
+			blocks = append(blocks, docBlock{Text: s})
+		}
+		para = nil
+	}
+	for _, line := range lines {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+			continue
+		}
+		if m := mdFencePattern.FindStringSubmatch(line); m != nil {
+			flush()
+			fence = m[1]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if m := mdSetextPattern.FindStringSubmatch(line); m != nil && len(para) > 0 {
+			level := 1
+			if m[1][0] == '-' {
+				level = 2
+			}
+			if s := markdownInline(strings.Join(para, " ")); s != "" {
+				blocks = append(blocks, docBlock{Level: level, Text: s})
+			}
+			para = nil
+			continue
+		}
+		if mdRulePattern.MatchString(line) || mdLinkDefPattern.MatchString(line) || mdTableRulePattern.MatchString(line) && strings.Contains(line, "|") {
+			flush()
+			continue
+		}
+		if m := mdATXHeadingPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			if s := markdownInline(m[2]); s != "" {
+				blocks = append(blocks, docBlock{Level: len(m[1]), Text: s})
+			}
+			continue
+		}
+
+		line = mdQuotePattern.ReplaceAllString(line, "")
+		if mdListItemPattern.MatchString(line) {
+			flush()
+			line = mdListItemPattern.ReplaceAllString(line, "")
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "|") {
+			// Hàng của bảng: mỗi hàng 1 đoạn, các ô đọc cách nhau bằng dấu phẩy.
+			flush()
+			cells := strings.Split(strings.Trim(strings.TrimSpace(line), "|"), "|")
+			for i := range cells {
+				cells[i] = strings.TrimSpace(cells[i])
+			}
+			para = append(para, strings.Join(cells, ", "))
+			flush()
+			continue
+		}
+		para = append(para, line)
+	}
+	flush()
+	return blocks
+}
+
+// markdownInline bỏ cú pháp inline Markdown (ảnh, link, code, nhấn mạnh, thẻ HTML), giữ lại text sẽ đọc.
+func markdownInline(s string) string {
+	s = mdImagePattern.ReplaceAllString(s, "$1")
+	s = mdLinkPattern.ReplaceAllString(s, "$1")
+	s = mdAutolinkPattern.ReplaceAllString(s, "")
+	s = mdHTMLTagPattern.ReplaceAllString(s, "")
+	s = mdCodePattern.ReplaceAllString(s, "$1")
+	s = mdEmphasisPattern.ReplaceAllString(s, "$1$2")
+	s = mdEscapePattern.ReplaceAllString(s, "$1")
+	return joinWords(html.UnescapeString(s))
+}
+
+// htmlSkipElements là các phần tử không được đọc (script, mục lục <nav>, ...).
+var htmlSkipElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Svg: true, atom.Math: true,
+}
+
+// htmlBlockElements là các phần tử ngắt đoạn văn.
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Aside: true, atom.Header: true,
+	atom.Footer: true, atom.Blockquote: true, atom.Li: true, atom.Dd: true, atom.Dt: true, atom.Pre: true,
+	atom.Tr: true, atom.Figcaption: true, atom.Hr: true, atom.Table: true, atom.Ul: true, atom.Ol: true,
+	atom.Body: true,
+}
+
+// htmlWalker gom text của cây HTML thành docBlock.
+type htmlWalker struct {
+	title  string
+	blocks []docBlock
+	buf    strings.Builder
+}
+
+// htmlBlocks parse 1 trang HTML / XHTML thành heading (h1-h6) và đoạn văn, kèm <title> của trang.
+func htmlBlocks(data []byte) (string, []docBlock, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, fmt.Errorf("parse html: %w", err)
+	}
+	w := &htmlWalker{title: htmlDocumentTitle(root)}
+	w.walk(root)
+	w.flush()
+	return w.title, w.blocks, nil
+}
+
+func (w *htmlWalker) flush() {
+	if s := joinWords(w.buf.String()); s != "" {
+		w.blocks = append(w.blocks, docBlock{Text: s})
+	}
+	w.buf.Reset()
+}
+
+func (w *htmlWalker) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.buf.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if htmlSkipElements[n.DataAtom] {
+			return
+		}
+		switch n.DataAtom {
+		case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+			w.flush()
+			if s := joinWords(htmlText(n)); s != "" {
+				w.blocks = append(w.blocks, docBlock{Level: int(n.Data[1] - '0'), Text: s})
+			}
+			return
+		case atom.Br:
+			w.buf.WriteByte(' ')
+		}
+		if htmlBlockElements[n.DataAtom] {
+			w.flush()
+			defer w.flush()
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+// htmlText nối toàn bộ text bên trong node.
+func htmlText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.ElementNode && htmlSkipElements[c.DataAtom]:
+		case c.Type == html.ElementNode && c.DataAtom == atom.Br:
+			sb.WriteByte(' ')
+		default:
+			sb.WriteString(htmlText(c))
+		}
+	}
+	return sb.String()
+}
+
+// htmlDocumentTitle trả về text của thẻ <title> đầu tiên.
+func htmlDocumentTitle(n *html.Node) string {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Title {
+		return joinWords(htmlText(n))
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if title := htmlDocumentTitle(c); title != "" {
+			return title
+		}
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"video-transcript/internal/config"
+)
+
+// Giới hạn dung lượng giải nén (tránh zip bomb): của 1 file trong EPUB và tổng các trang của cả sách.
+const (
+	maxEPUBEntryBytes = 64 << 20
+	maxEPUBTotalBytes = 256 << 20
+)
+
+// epubContainer là META-INF/container.xml: chỉ tới file OPF của sách.
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage là file OPF: metadata, danh sách file (manifest) và thứ tự đọc (spine).
+type epubPackage struct {
+	Titles   []string `xml:"metadata>title"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef  string `xml:"idref,attr"`
+		Linear string `xml:"linear,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// epubBlocks đọc các trang XHTML theo thứ tự spine và gom heading / đoạn văn của cả sách.
+// Sách không có heading nào thì mỗi trang có text là 1 chương ("Chapter N"). Đọc quá maxEPUBTotalBytes
+// hoặc text vượt AUDIOBOOK_MAX_CHARS thì dừng luôn, không giải nén phần còn lại.
+func epubBlocks(data []byte) (string, []docBlock, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid epub: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var container epubContainer
+	if err := readEPUBXML(files, "META-INF/container.xml", &container); err != nil {
+		return "", nil, err
+	}
+	opfPath := ""
+	for _, rf := range container.Rootfiles {
+		if rf.MediaType == "" || rf.MediaType == "application/oebps-package+xml" {
+			opfPath = rf.FullPath
+			break
+		}
+	}
+	if opfPath == "" {
+		return "", nil, errors.New("invalid epub: container.xml has no package document")
+	}
+	var pkg epubPackage
+	if err := readEPUBXML(files, opfPath, &pkg); err != nil {
+		return "", nil, err
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		if item.MediaType != "application/xhtml+xml" && item.MediaType != "text/html" {
+			continue
+		}
+		if strings.Contains(" "+item.Properties+" ", " nav ") {
+			continue // trang mục lục
+		}
+		href := item.Href
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		hrefs[item.ID] = path.Join(path.Dir(opfPath), href)
+	}
+
+	var pages [][]docBlock
+	headings, totalBytes, totalChars := 0, 0, 0
+	seen := make(map[string]bool, len(pkg.Spine))
+	for _, ref := range pkg.Spine {
+		name, ok := hrefs[ref.IDRef]
+		if !ok || ref.Linear == "no" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		page, err := readEPUBFile(files, name, min(maxEPUBEntryBytes, maxEPUBTotalBytes-totalBytes))
+		if err != nil {
+			return "", nil, err
+		}
+		totalBytes += len(page)
+		_, blocks, err := htmlBlocks(page)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, b := range blocks {
+			if b.Level > 0 {
+				headings++
+			}
+			totalChars += utf8.RuneCountInString(b.Text)
+		}
+		if maxChars := config.SvcCfg.AudiobookMaxChars; maxChars > 0 && totalChars > maxChars {
+			return "", nil, fmt.Errorf("document is too long (more than %d characters)", maxChars)
+		}
+		pages = append(pages, blocks)
+	}
+
+	var blocks []docBlock
+	chapter := 0
+	for _, page := range pages {
+		if headings == 0 && len(page) > 0 {
+			chapter++
+			blocks = append(blocks, docBlock{Level: 1, Text: fmt.Sprintf("Chapter %d", chapter)})
+		}
+		blocks = append(blocks, page...)
+	}
+
+	title := ""
+	if len(pkg.Titles) > 0 {
+		title = joinWords(pkg.Titles[0])
+	}
+	return title, blocks, nil
+}
+
+func readEPUBXML(files map[string]*zip.File, name string, v any) error {
+	data, err := readEPUBFile(files, name, maxEPUBEntryBytes)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid epub: parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// readEPUBFile đọc 1 file trong EPUB, tối đa limit byte sau giải nén.
+func readEPUBFile(files map[string]*zip.File, name string, limit int) ([]byte, error) {
+	f, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid epub: missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("invalid epub: open %s: %w", name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid epub: read %s: %w", name, err)
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("invalid epub: %s is too large", name)
+	}
+	return data, nil
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"video-transcript/internal/model"
+)
+
+// ErrInvalidEdit trả về khi edit decision không áp dụng được cho transcript (chỉ số từ sai, xoá hết nội dung, ...).
+var ErrInvalidEdit = errors.New("invalid edit")
+
+const (
+	// editPadSec: phần khoảng lặng giữ lại sau từ đứng trước / trước từ đứng sau điểm cắt.
+	editPadSec = 0.1
+	// editMinSegmentSec: đoạn giữ lại ngắn hơn (sau khi trừ crossfade) bị bỏ luôn.
+	editMinSegmentSec = 0.1
+)
+
+// fillerWords là các từ đệm bị xoá khi bật remove_fillers (so sánh sau compactWord).
+var fillerWords = map[string]bool{
+	"uh": true, "um": true, "uhm": true, "umm": true, "erm": true, "er": true, "ah": true,
+	"hmm": true, "hm": true, "mm": true, "mhm": true,
+	"ờ": true, "ơ": true, "ừ": true, "ừm": true, "ừmm": true, "à": true, "ậm": true, "hừm": true,
+}
+
+// EditSegment là 1 đoạn [Start, End) (giây, timeline video nguồn) được giữ lại khi render.
+type EditSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// EditPlan là kết quả áp dụng edit decision lên transcript.
+type EditPlan struct {
+	Segments   []EditSegment
+	Crossfade  float64                 // giây, mỗi điểm nối 2 đoạn chồng lên nhau đúng khoảng này
+	Duration   float64                 // độ dài file đầu ra
+	Transcript *model.SimpleTranscript // transcript đã đổi timestamp theo file đầu ra
+}
+
+// planEdit chuyển edit decision (theo chỉ số từ) thành danh sách đoạn giữ lại trên timeline nguồn
+// và transcript mới cho file kết quả.
+func planEdit(t *model.SimpleTranscript, duration float64, req *model.EditVideoRequest) (*EditPlan, error) {
+	words := t.Words
+	n := len(words)
+	if n == 0 {
+		return nil, ErrEmptyTranscript
+	}
+
+	keep := make([]bool, n)
+	for i := range keep {
+		keep[i] = len(req.Keep) == 0
+	}
+	for _, ranges := range [][]model.WordRange{req.Keep, req.Remove} {
+		for _, wr := range ranges {
+			if wr.To >= n {
+				return nil, fmt.Errorf("%w: word range [%d, %d] out of transcript (%d words)", ErrInvalidEdit, wr.From, wr.To, n)
+			}
+		}
+	}
+	for _, wr := range req.Keep {
+		for i := wr.From; i <= wr.To; i++ {
+			keep[i] = true
+		}
+	}
+	for _, wr := range req.Remove {
+		for i := wr.From; i <= wr.To; i++ {
+			keep[i] = false
+		}
+	}
+	if req.RemoveFillers {
+		for i, w := range words {
+			if fillerWords[compactWord(w.Word)] {
+				keep[i] = false
+			}
+		}
+	}
+
+	cuts := editCuts(words, keep, duration, req.MaxPauseSec)
+	crossfade := req.Crossfade()
+	segments := keepSegments(cuts, duration, max(editMinSegmentSec, 2*crossfade+editMinSegmentSec))
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("%w: edit removes the whole video", ErrInvalidEdit)
+	}
+
+	plan := &EditPlan{Segments: segments, Crossfade: crossfade}
+	for _, s := range segments {
+		plan.Duration += s.End - s.Start
+	}
+	plan.Duration -= crossfade * float64(len(segments)-1)
+
+	kept := make([]model.SimpleWord, 0, n)
+	for i, w := range words {
+		if keep[i] {
+			kept = append(kept, w)
+		}
+	}
+	plan.Transcript = retimeTranscript(t, kept, segments, crossfade)
+	return plan, nil
+}
+
+// editCuts trả về các đoạn bị cắt: mỗi cụm từ bị xoá được cắt từ sau từ giữ lại đứng trước tới trước
+// từ giữ lại đứng sau (giữ editPadSec khoảng lặng mỗi bên), cụm ở đầu / cuối cắt tới đầu / cuối file.
+// maxPause != nil thì khoảng lặng giữa 2 từ giữ lại liền nhau dài hơn được rút về maxPause.
+func editCuts(words []model.SimpleWord, keep []bool, duration float64, maxPause *float64) []EditSegment {
+	var cuts []EditSegment
+	prev := -1 // chỉ số từ giữ lại gần nhất
+	for i := 0; i < len(words); i++ {
+		if keep[i] {
+			if maxPause != nil && prev == i-1 && prev >= 0 {
+				if gap := words[i].Start - words[prev].End; gap > *maxPause {
+					cuts = append(cuts, EditSegment{Start: words[prev].End + *maxPause/2, End: words[i].Start - *maxPause/2})
+				}
+			}
+			prev = i
+			continue
+		}
+
+		j := i
+		for j+1 < len(words) && !keep[j+1] {
+			j++
+		}
+		cut := EditSegment{Start: 0, End: duration}
+		if prev >= 0 {
+			cut.Start = min(words[i].Start, words[prev].End+editPadSec)
+		}
+		if j+1 < len(words) {
+			cut.End = max(words[j].End, words[j+1].Start-editPadSec)
+		}
+		if cut.End > cut.Start {
+			cuts = append(cuts, cut)
+		}
+		i = j
+	}
+	return cuts
+}
+
+// keepSegments lấy phần bù của cuts trong [0, duration], bỏ các đoạn ngắn hơn minLen.
+func keepSegments(cuts []EditSegment, duration, minLen float64) []EditSegment {
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i].Start < cuts[j].Start })
+
+	segments := []EditSegment{}
+	pos := 0.0
+	add := func(start, end float64) {
+		if end-start >= minLen {
+			segments = append(segments, EditSegment{Start: start, End: end})
+		}
+	}
+	for _, c := range cuts {
+		if c.Start > pos {
+			add(pos, c.Start)
+		}
+		pos = max(pos, c.End)
+	}
+	if duration > pos {
+		add(pos, duration)
+	}
+	return segments
+}
+
+// retimeTranscript dựng transcript của file kết quả từ các từ được giữ lại (timestamp gốc):
+// từ nằm ngoài mọi đoạn bị bỏ, thời gian đổi sang timeline đầu ra (mỗi điểm nối lùi lại 1 crossfade).
+func retimeTranscript(t *model.SimpleTranscript, kept []model.SimpleWord, segments []EditSegment, crossfade float64) *model.SimpleTranscript {
+	offsets := make([]float64, len(segments))
+	acc := 0.0
+	for i, s := range segments {
+		offsets[i] = acc - crossfade*float64(i)
+		acc += s.End - s.Start
+	}
+	segmentOf := func(sec float64) int {
+		i := sort.Search(len(segments), func(i int) bool { return segments[i].End > sec })
+		if i < len(segments) && segments[i].Start <= sec+timeEpsilon {
+			return i
+		}
+		return -1
+	}
+	// newTime đổi mốc thời gian gốc sang timeline đầu ra; mốc kết thúc (isEnd) thuộc đoạn chứa điểm ngay trước nó.
+	newTime := func(sec float64, isEnd bool) float64 {
+		seg := segmentOf(sec)
+		if isEnd {
+			seg = segmentOf(sec - timeEpsilon)
+		}
+		if seg < 0 {
+			seg = max(0, sort.Search(len(segments), func(i int) bool { return segments[i].Start > sec })-1)
+		}
+		s := segments[seg]
+		return offsets[seg] + min(max(sec, s.Start), s.End) - s.Start
+	}
+
+	out := &model.SimpleTranscript{
+		DetectedLanguage:   t.DetectedLanguage,
+		LanguageConfidence: t.LanguageConfidence,
+		Languages:          t.Languages,
+		Words:              []model.SimpleWord{},
+		Utterances:         []model.SimpleUtterance{},
+	}
+	inside := make([]model.SimpleWord, 0, len(kept))
+	for _, w := range kept {
+		if segmentOf(w.Start) >= 0 {
+			inside = append(inside, w)
+		}
+	}
+
+	// Gom utterance theo timestamp gốc trước rồi mới đổi thời gian.
+	for _, u := range rebuildUtterances(t.Utterances, inside, nil) {
+		u.Start, u.End = newTime(u.Start, false), newTime(u.End, true)
+		out.Utterances = append(out.Utterances, u)
+	}
+
+	text := make([]string, 0, len(inside))
+	for _, w := range inside {
+		w.Start, w.End = newTime(w.Start, false), newTime(w.End, true)
+		out.Words = append(out.Words, w)
+		text = append(text, w.Word)
+	}
+	out.TranscriptText = strings.Join(text, " ")
+	return out
+}
+
+// RenderSegments ghép các đoạn segments của sourcePath thành outPath, nối bằng crossfade (xfade / acrossfade)
+// dài crossfade giây hoặc cắt thẳng (concat) khi crossfade = 0. hasVideo = false thì chỉ render audio (M4A).
+func RenderSegments(ctx context.Context, sourcePath, outPath string, segments []EditSegment, crossfade float64, hasVideo, hasAudio bool) error {
+	graph, outputs := segmentsFilterGraph(segments, crossfade, hasVideo, hasAudio)
+	return runFilterScript(ctx, sourcePath, outPath, graph, outputs, hasVideo, hasAudio)
+}
+
+// runFilterScript chạy filter graph (ghi ra file script, graph dài không vừa command line) trên sourcePath
+// và encode các stream outputs ra outPath (H.264 / AAC).
+func runFilterScript(ctx context.Context, sourcePath, outPath, graph string, outputs []string, hasVideo, hasAudio bool) error {
+	scriptPath := outPath + ".filter"
+	if err := os.WriteFile(scriptPath, []byte(graph), 0o600); err != nil {
+		return err
+	}
+	defer os.Remove(scriptPath)
+
+	args := []string{"-i", sourcePath, "-filter_complex_script", scriptPath}
+	for _, label := range outputs {
+		args = append(args, "-map", label)
+	}
+	if hasVideo {
+		args = append(args, "-c:v", "libx264", "-preset", "medium", "-crf", "20", "-pix_fmt", "yuv420p")
+	}
+	if hasAudio {
+		args = append(args, "-c:a", "aac", "-b:a", "160k")
+	}
+	args = append(args, "-movflags", "+faststart", "-y", outPath)
+	return runFFmpeg(ctx, args...)
+}
+
+// segmentsFilterGraph dựng filter graph cắt + nối các đoạn, trả về graph và label của các stream đầu ra.
+func segmentsFilterGraph(segments []EditSegment, crossfade float64, hasVideo, hasAudio bool) (string, []string) {
+	var chains []string
+	for i, s := range segments {
+		start, end := formatSeconds(s.Start), formatSeconds(s.End)
+		if hasVideo {
+			chains = append(chains, fmt.Sprintf("[0:v]trim=start=%s:end=%s,setpts=PTS-STARTPTS[v%d]", start, end, i))
+		}
+		if hasAudio {
+			chains = append(chains, fmt.Sprintf("[0:a]atrim=start=%s:end=%s,asetpts=PTS-STARTPTS[a%d]", start, end, i))
+		}
+	}
+
+	var outputs []string
+	if len(segments) == 1 || crossfade <= 0 {
+		// Cắt thẳng: concat các đoạn (1 đoạn thì concat n=1 chỉ chuyển tiếp stream).
+		var concat strings.Builder
+		for i := range segments {
+			if hasVideo {
+				fmt.Fprintf(&concat, "[v%d]", i)
+			}
+			if hasAudio {
+				fmt.Fprintf(&concat, "[a%d]", i)
+			}
+		}
+		fmt.Fprintf(&concat, "concat=n=%d:v=%d:a=%d", len(segments), boolToInt(hasVideo), boolToInt(hasAudio))
+		if hasVideo {
+			outputs = append(outputs, "[vout]")
+		}
+		if hasAudio {
+			outputs = append(outputs, "[aout]")
+		}
+		chains = append(chains, concat.String()+strings.Join(outputs, ""))
+		return strings.Join(chains, ";\n") + "\n", outputs
+	}
+
+	xf := formatSeconds(crossfade)
+	vPrev, aPrev := "v0", "a0"
+	length := segments[0].End - segments[0].Start
+	for i := 1; i < len(segments); i++ {
+		if hasVideo {
+			// offset = thời điểm bắt đầu chuyển cảnh trên stream đã ghép tới đoạn trước.
+			chains = append(chains, fmt.Sprintf("[%s][v%d]xfade=transition=fade:duration=%s:offset=%s[vx%d]", vPrev, i, xf, formatSeconds(length-crossfade), i))
+			vPrev = fmt.Sprintf("vx%d", i)
+		}
+		if hasAudio {
+			chains = append(chains, fmt.Sprintf("[%s][a%d]acrossfade=d=%s:c1=tri:c2=tri[ax%d]", aPrev, i, xf, i))
+			aPrev = fmt.Sprintf("ax%d", i)
+		}
+		length += segments[i].End - segments[i].Start - crossfade
+	}
+	if hasVideo {
+		outputs = append(outputs, "["+vPrev+"]")
+	}
+	if hasAudio {
+		outputs = append(outputs, "["+aPrev+"]")
+	}
+	return strings.Join(chains, ";\n") + "\n", outputs
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
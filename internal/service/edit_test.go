@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	"video-transcript/internal/model"
+)
+
+func editTranscript() *model.SimpleTranscript {
+	words := []model.SimpleWord{
+		{Word: "hello", Start: 0.5, End: 1.0},
+		{Word: "um", Start: 1.2, End: 1.5},
+		{Word: "world", Start: 2.0, End: 2.5},
+		{Word: "this", Start: 3.0, End: 3.4},
+		{Word: "is", Start: 3.5, End: 3.7},
+		{Word: "bad", Start: 4.0, End: 4.4},
+		{Word: "ok", Start: 6.0, End: 6.5},
+	}
+	return &model.SimpleTranscript{
+		Words:      words,
+		Utterances: []model.SimpleUtterance{{Start: 0.5, End: 6.5, Transcript: "hello um world this is bad ok"}},
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+func closeTo(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestPlanEdit(t *testing.T) {
+	type wordTime struct {
+		word       string
+		start, end float64
+	}
+	tests := []struct {
+		name     string
+		req      model.EditVideoRequest
+		segments []EditSegment
+		duration float64
+		text     string
+		check    wordTime // thời gian của 1 từ trên file đầu ra
+	}{
+		{
+			"remove word cuts to neighbours",
+			model.EditVideoRequest{Remove: []model.WordRange{{From: 5, To: 5}}, CrossfadeMs: intPtr(0)},
+			[]EditSegment{{Start: 0, End: 3.8}, {Start: 5.9, End: 10}},
+			7.9,
+			"hello um world this is ok",
+			wordTime{"ok", 3.9, 4.4},
+		},
+		{
+			"crossfade shifts later words back",
+			model.EditVideoRequest{Remove: []model.WordRange{{From: 5, To: 5}}, CrossfadeMs: intPtr(40)},
+			[]EditSegment{{Start: 0, End: 3.8}, {Start: 5.9, End: 10}},
+			7.86,
+			"hello um world this is ok",
+			wordTime{"ok", 3.86, 4.36},
+		},
+		{
+			"remove fillers",
+			model.EditVideoRequest{RemoveFillers: true, CrossfadeMs: intPtr(0)},
+			[]EditSegment{{Start: 0, End: 1.1}, {Start: 1.9, End: 10}},
+			9.2,
+			"hello world this is bad ok",
+			wordTime{"world", 1.2, 1.7},
+		},
+		{
+			"keep range drops head and tail",
+			model.EditVideoRequest{Keep: []model.WordRange{{From: 2, To: 4}}, CrossfadeMs: intPtr(0)},
+			[]EditSegment{{Start: 1.9, End: 3.8}},
+			1.9,
+			"world this is",
+			wordTime{"is", 1.6, 1.8},
+		},
+		{
+			"max pause shortens long gaps",
+			model.EditVideoRequest{MaxPauseSec: floatPtr(0.5), CrossfadeMs: intPtr(0)},
+			[]EditSegment{{Start: 0, End: 4.65}, {Start: 5.75, End: 10}},
+			8.9,
+			"hello um world this is bad ok",
+			wordTime{"ok", 4.9, 5.4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planEdit(editTranscript(), 10, &tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.EqualFunc(plan.Segments, tt.segments, func(a, b EditSegment) bool {
+				return closeTo(a.Start, b.Start) && closeTo(a.End, b.End)
+			}) {
+				t.Errorf("segments = %v, want %v", plan.Segments, tt.segments)
+			}
+			if !closeTo(plan.Duration, tt.duration) {
+				t.Errorf("duration = %g, want %g", plan.Duration, tt.duration)
+			}
+			out := plan.Transcript
+			if out.TranscriptText != tt.text {
+				t.Errorf("text = %q, want %q", out.TranscriptText, tt.text)
+			}
+			for _, w := range out.Words {
+				if w.Word == tt.check.word && (!closeTo(w.Start, tt.check.start) || !closeTo(w.End, tt.check.end)) {
+					t.Errorf("%s = [%g, %g], want [%g, %g]", w.Word, w.Start, w.End, tt.check.start, tt.check.end)
+				}
+			}
+			if len(out.Utterances) != 1 || out.Utterances[0].Transcript != tt.text {
+				t.Fatalf("utterances = %+v, want 1 utterance %q", out.Utterances, tt.text)
+			}
+			last := out.Words[len(out.Words)-1]
+			if u := out.Utterances[0]; !closeTo(u.Start, out.Words[0].Start) || !closeTo(u.End, last.End) {
+				t.Errorf("utterance = [%g, %g], want [%g, %g]", u.Start, u.End, out.Words[0].Start, last.End)
+			}
+		})
+	}
+}
+
+func TestPlanEditErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript *model.SimpleTranscript
+		req        model.EditVideoRequest
+		want       error
+	}{
+		{"empty transcript", &model.SimpleTranscript{}, model.EditVideoRequest{}, ErrEmptyTranscript},
+		{"range out of transcript", editTranscript(), model.EditVideoRequest{Remove: []model.WordRange{{From: 5, To: 7}}}, ErrInvalidEdit},
+		{"removes everything", editTranscript(), model.EditVideoRequest{Remove: []model.WordRange{{From: 0, To: 6}}}, ErrInvalidEdit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := planEdit(tt.transcript, 10, &tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetimeTranscript(t *testing.T) {
+	segments := []EditSegment{{Start: 0, End: 2}, {Start: 3, End: 5}, {Start: 8, End: 9}}
+	kept := []model.SimpleWord{
+		{Word: "a", Start: 1.5, End: 2.0}, // kết thúc đúng điểm cắt: vẫn thuộc đoạn đầu
+		{Word: "b", Start: 2.5, End: 2.8}, // nằm trong đoạn bị bỏ
+		{Word: "c", Start: 3.0, End: 3.5},
+		{Word: "d", Start: 8.2, End: 8.6},
+	}
+
+	out := retimeTranscript(&model.SimpleTranscript{}, kept, segments, 0.1)
+	want := []model.SimpleWord{
+		{Word: "a", Start: 1.5, End: 2.0},
+		{Word: "c", Start: 1.9, End: 2.4},
+		{Word: "d", Start: 4.0, End: 4.4},
+	}
+	if !slices.EqualFunc(out.Words, want, func(a, b model.SimpleWord) bool {
+		return a.Word == b.Word && closeTo(a.Start, b.Start) && closeTo(a.End, b.End)
+	}) {
+		t.Errorf("words = %+v, want %+v", out.Words, want)
+	}
+	if out.TranscriptText != "a c d" {
+		t.Errorf("text = %q, want %q", out.TranscriptText, "a c d")
+	}
+}
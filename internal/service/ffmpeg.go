@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// maxStderrBytes giới hạn phần stderr giữ lại (ffmpeg in lỗi thật ở cuối output).
+const maxStderrBytes = 4 << 10
+
+// FFmpegError là lỗi khi chạy ffmpeg/ffprobe, kèm phần cuối stderr để ghi vào error_message.
+type FFmpegError struct {
+	Tool   string
+	Err    error
+	Stderr string
+}
+
+func (e *FFmpegError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("%s failed: %v", e.Tool, e.Err)
+	}
+	return fmt.Sprintf("%s failed: %v: %s", e.Tool, e.Err, e.Stderr)
+}
+
+func (e *FFmpegError) Unwrap() error {
+	return e.Err
+}
+
+// tailBuffer chỉ giữ lại max byte cuối cùng được ghi vào.
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return strings.TrimSpace(string(b.buf))
+}
+
+// runFFmpeg chạy ffmpeg với args, dừng process khi ctx bị huỷ.
+func runFFmpeg(ctx context.Context, args ...string) error {
+	base := []string{"-hide_banner", "-nostdin", "-loglevel", "error"}
+	_, err := runTool(ctx, "ffmpeg", append(base, args...)...)
+	return err
+}
+
+// runFFmpegLog chạy ffmpeg ở loglevel info và trả về toàn bộ stderr
+// (dùng cho filter phân tích như silencedetect, kết quả được in ra log).
+func runFFmpegLog(ctx context.Context, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	base := []string{"-hide_banner", "-nostdin", "-nostats", "-loglevel", "info"}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", append(base, args...)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		tail := &tailBuffer{max: maxStderrBytes}
+		_, _ = tail.Write(stderr.Bytes())
+		return "", &FFmpegError{Tool: "ffmpeg", Err: err, Stderr: tail.String()}
+	}
+	return stderr.String(), nil
+}
+
+// runTool chạy 1 command line tool, trả về stdout; lỗi trả về dạng *FFmpegError có stderr.
+func runTool(ctx context.Context, tool string, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	stderr := &tailBuffer{max: maxStderrBytes}
+
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		// Bị huỷ / timeout thì trả về lỗi của context thay vì "signal: killed".
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, &FFmpegError{Tool: tool, Err: err, Stderr: stderr.String()}
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"video-transcript/internal/model"
+)
+
+// hlsSegmentSeconds là độ dài 1 segment; keyframe được ép đúng mốc này để các rendition chuyển qua lại được.
+const hlsSegmentSeconds = 6
+
+// hlsRendition là 1 bậc trong HLS ladder (bitrate tính bằng kbps).
+type hlsRendition struct {
+	Name         string
+	Height       int
+	VideoBitrate int
+	AudioBitrate int
+	Level        string // H.264 level
+	Codecs       string // giá trị CODECS trong master playlist (H.264 Main + AAC-LC)
+}
+
+// hlsLadder xếp từ cao xuống thấp; chỉ dùng các bậc không cao hơn video gốc.
+var hlsLadder = []hlsRendition{
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 128, Level: "4.0", Codecs: "avc1.4d4028,mp4a.40.2"},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128, Level: "3.1", Codecs: "avc1.4d401f,mp4a.40.2"},
+	{Name: "480p", Height: 480, VideoBitrate: 1400, AudioBitrate: 96, Level: "3.0", Codecs: "avc1.4d401e,mp4a.40.2"},
+	{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96, Level: "3.0", Codecs: "avc1.4d401e,mp4a.40.2"},
+}
+
+// hlsAudioOnly là rendition chỉ có audio (mạng yếu / nghe podcast).
+var hlsAudioOnly = hlsRendition{Name: "audio", AudioBitrate: 128, Codecs: "mp4a.40.2"}
+
+// HLSSubtitle là transcript được gắn vào master playlist dưới dạng track phụ đề WebVTT.
+type HLSSubtitle struct {
+	Name     string // tên hiển thị trong player
+	Language string // mã BCP-47, có thể rỗng
+	Cues     []Cue
+}
+
+// planHLSLadder chọn các rendition phù hợp kích thước video gốc; video nhỏ hơn bậc thấp nhất
+// thì giữ 1 rendition ở đúng độ phân giải gốc.
+func planHLSLadder(height int) []hlsRendition {
+	var out []hlsRendition
+	for _, r := range hlsLadder {
+		if height == 0 || r.Height <= height {
+			out = append(out, r)
+		}
+	}
+	if len(out) == 0 {
+		r := hlsLadder[len(hlsLadder)-1]
+		r.Name = fmt.Sprintf("%dp", height)
+		r.Height = height - height%2
+		out = append(out, r)
+	}
+	return out
+}
+
+// evenWidth tính chiều rộng (số chẵn) giữ đúng tỉ lệ khung hình ở chiều cao height; không rõ kích thước gốc thì coi là 16:9.
+func evenWidth(height, srcWidth, srcHeight int) int {
+	ratio := 16.0 / 9.0
+	if srcWidth > 0 && srcHeight > 0 {
+		ratio = float64(srcWidth) / float64(srcHeight)
+	}
+	return int(math.Round(float64(height)*ratio/2)) * 2
+}
+
+// TranscodeHLS encode sourcePath thành HLS (fMP4) trong outDir: mỗi rendition 1 thư mục con,
+// rendition audio-only, track phụ đề (nếu có) và master.m3u8. Video không có hình chỉ có rendition audio.
+func TranscodeHLS(ctx context.Context, sourcePath, outDir string, info *model.MediaInfo, subtitle *HLSSubtitle) error {
+	var renditions []hlsRendition
+	if info.VideoCodec != "" {
+		renditions = planHLSLadder(info.Height)
+	}
+	hasAudio := info.AudioCodec != ""
+	if len(renditions) == 0 && !hasAudio {
+		return fmt.Errorf("%w: nothing to transcode", model.ErrCorruptMedia)
+	}
+
+	for _, r := range renditions {
+		width := evenWidth(r.Height, info.Width, info.Height)
+		args := []string{
+			"-i", sourcePath,
+			"-map", "0:v:0",
+			"-map", "0:a:0?",
+			"-vf", fmt.Sprintf("scale=%d:%d", width, r.Height),
+			"-c:v", "libx264",
+			"-preset", "veryfast",
+			"-profile:v", "main",
+			"-level", r.Level,
+			"-pix_fmt", "yuv420p",
+			"-b:v", fmt.Sprintf("%dk", r.VideoBitrate),
+			"-maxrate", fmt.Sprintf("%dk", r.VideoBitrate*107/100),
+			"-bufsize", fmt.Sprintf("%dk", r.VideoBitrate*3/2),
+			"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", hlsSegmentSeconds),
+			"-sc_threshold", "0",
+			"-c:a", "aac",
+			"-b:a", fmt.Sprintf("%dk", r.AudioBitrate),
+			"-ac", "2",
+		}
+		if err := runHLS(ctx, outDir, r.Name, args); err != nil {
+			return fmt.Errorf("transcode %s: %w", r.Name, err)
+		}
+	}
+
+	if hasAudio {
+		args := []string{
+			"-i", sourcePath,
+			"-map", "0:a:0",
+			"-vn",
+			"-c:a", "aac",
+			"-b:a", fmt.Sprintf("%dk", hlsAudioOnly.AudioBitrate),
+			"-ac", "2",
+		}
+		if err := runHLS(ctx, outDir, hlsAudioOnly.Name, args); err != nil {
+			return fmt.Errorf("transcode audio: %w", err)
+		}
+	}
+
+	if subtitle != nil {
+		if err := writeHLSSubtitle(outDir, subtitle, info.DurationSec); err != nil {
+			return err
+		}
+	}
+
+	master := buildMasterPlaylist(renditions, hasAudio, info, subtitle)
+	return os.WriteFile(filepath.Join(outDir, "master.m3u8"), []byte(master), 0o600)
+}
+
+// runHLS chạy ffmpeg với args encode và ghi 1 media playlist vào outDir/name.
+func runHLS(ctx context.Context, outDir, name string, args []string) error {
+	dir := filepath.Join(outDir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	args = append(args,
+		"-f", "hls",
+		"-hls_time", fmt.Sprint(hlsSegmentSeconds),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_type", "fmp4",
+		"-hls_fmp4_init_filename", "init.mp4",
+		"-hls_segment_filename", filepath.Join(dir, "seg_%05d.m4s"),
+		"-y",
+		filepath.Join(dir, "index.m3u8"),
+	)
+	return runFFmpeg(ctx, args...)
+}
+
+// writeHLSSubtitle ghi transcript.vtt và playlist 1 segment trỏ tới nó vào outDir/subtitles.
+// Segment fMP4 có timestamp bắt đầu từ 0 nên WebVTT không cần X-TIMESTAMP-MAP.
+func writeHLSSubtitle(outDir string, subtitle *HLSSubtitle, duration float64) error {
+	dir := filepath.Join(outDir, "subtitles")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "transcript.vtt"), []byte(FormatWebVTT(subtitle.Cues)), 0o600); err != nil {
+		return err
+	}
+
+	playlist := fmt.Sprintf("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:%.3f,\ntranscript.vtt\n#EXT-X-ENDLIST\n",
+		int(math.Ceil(duration)), duration)
+	return os.WriteFile(filepath.Join(dir, "index.m3u8"), []byte(playlist), 0o600)
+}
+
+func buildMasterPlaylist(renditions []hlsRendition, hasAudio bool, info *model.MediaInfo, subtitle *HLSSubtitle) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+
+	subs := ""
+	if subtitle != nil {
+		attrs := fmt.Sprintf(`TYPE=SUBTITLES,GROUP-ID="subs",NAME=%q,DEFAULT=NO,AUTOSELECT=YES`, subtitle.Name)
+		if subtitle.Language != "" {
+			attrs += fmt.Sprintf(",LANGUAGE=%q", subtitle.Language)
+		}
+		fmt.Fprintf(&b, "#EXT-X-MEDIA:%s,URI=\"subtitles/index.m3u8\"\n", attrs)
+		subs = `,SUBTITLES="subs"`
+	}
+
+	for _, r := range renditions {
+		bandwidth := (r.VideoBitrate*107/100 + r.AudioBitrate) * 1000
+		codecs := r.Codecs
+		if !hasAudio {
+			codecs = strings.Split(codecs, ",")[0]
+		}
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,CODECS=%q%s\n%s/index.m3u8\n",
+			bandwidth, evenWidth(r.Height, info.Width, info.Height), r.Height, codecs, subs, r.Name)
+	}
+	if hasAudio {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=%q%s\n%s/index.m3u8\n",
+			hlsAudioOnly.AudioBitrate*1000, hlsAudioOnly.Codecs, subs, hlsAudioOnly.Name)
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// runJob chạy job background với timeout riêng (không phụ thuộc request HTTP đã trả về). fn lỗi (hoặc panic)
+// thì gọi fail để ghi trạng thái failed.
+func runJob(timeout time.Duration, fn func(ctx context.Context) error, fail func(ctx context.Context, err error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// Job chạy trong goroutine riêng, panic không recover sẽ làm sập cả server.
+	defer func() {
+		if r := recover(); r != nil {
+			zap.S().Errorw("job panicked", "panic", r, "stack", string(debug.Stack()))
+			fail(context.WithoutCancel(ctx), fmt.Errorf("job panicked: %v", r))
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		// Job có thể fail vì timeout, vẫn phải ghi được trạng thái.
+		fail(context.WithoutCancel(ctx), err)
+	}
+}
+
+// runTask chạy job của task: đánh dấu processing, chạy fn (fn tự ghi completed kèm output của từng loại
+// task), fn lỗi thì task chuyển failed kèm error_message.
+func runTask(taskSvc TaskService, task *model.Task, timeout time.Duration, fn func(ctx context.Context) error) {
+	runJob(timeout, func(ctx context.Context) error {
+		if err := taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusProcessing, nil, nil, nil); err != nil {
+			zap.S().Errorw("update task status failed", "id", task.ID, "error", err)
+		}
+		return fn(ctx)
+	}, func(ctx context.Context, err error) {
+		zap.S().Errorw("task failed", "task_id", task.ID, "task_type", task.TaskType, "error", err)
+		errorMessage := err.Error()
+		if updateErr := taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusFailed, nil, nil, &errorMessage); updateErr != nil {
+			zap.S().Errorw("update task status failed", "id", task.ID, "error", updateErr)
+		}
+	})
+}
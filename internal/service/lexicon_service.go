@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"video-transcript/internal/helper"
+	"video-transcript/internal/model"
+	"video-transcript/internal/repository"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrLexiconForbidden trả về khi user truy cập lexicon entry không phải của mình.
+	ErrLexiconForbidden = errors.New("lexicon entry does not belong to current user")
+	// ErrLexiconFull trả về khi lexicon của user đã đủ MaxLexiconEntries entry.
+	ErrLexiconFull = fmt.Errorf("lexicon is full (max %d entries)", model.MaxLexiconEntries)
+)
+
+// LexiconService defines business logic for the per-user TTS pronunciation lexicon.
+type LexiconService interface {
+	Create(ctx context.Context, e *model.LexiconEntry) error
+	GetForUser(ctx context.Context, id int64, user *model.User) (*model.LexiconEntry, error)
+	ListForUser(ctx context.Context, userID int64) ([]*model.LexiconEntry, error)
+	Update(ctx context.Context, e *model.LexiconEntry, user *model.User) error
+	Delete(ctx context.Context, id int64, user *model.User) error
+	// Preview tổng hợp riêng replacement của 1 entry để user nghe thử (đồng bộ, không lưu file).
+	Preview(ctx context.Context, id int64, user *model.User, voice string) (*helper.SpeechAudio, error)
+}
+
+type lexiconService struct {
+	repo repository.LexiconRepository
+}
+
+// NewLexiconService creates a new LexiconService.
+func NewLexiconService(repo repository.LexiconRepository) LexiconService {
+	return &lexiconService{repo: repo}
+}
+
+func (s *lexiconService) Create(ctx context.Context, e *model.LexiconEntry) error {
+	e.Normalize()
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	count, err := s.repo.CountByUser(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	if count >= model.MaxLexiconEntries {
+		return ErrLexiconFull
+	}
+	return s.repo.Create(ctx, e)
+}
+
+// GetForUser trả về entry nếu là của user (hoặc user là admin).
+func (s *lexiconService) GetForUser(ctx context.Context, id int64, user *model.User) (*model.LexiconEntry, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e.UserID != user.ID && user.Role != "admin" {
+		return nil, ErrLexiconForbidden
+	}
+	return e, nil
+}
+
+func (s *lexiconService) ListForUser(ctx context.Context, userID int64) ([]*model.LexiconEntry, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *lexiconService) Update(ctx context.Context, e *model.LexiconEntry, user *model.User) error {
+	existing, err := s.GetForUser(ctx, e.ID, user)
+	if err != nil {
+		return err
+	}
+	e.Normalize()
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	e.UserID = existing.UserID
+	e.CreatedAt = existing.CreatedAt
+	return s.repo.Update(ctx, e)
+}
+
+func (s *lexiconService) Delete(ctx context.Context, id int64, user *model.User) error {
+	if _, err := s.GetForUser(ctx, id, user); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Preview đọc replacement bằng voice chỉ định; không chỉ định thì dùng voice đầu tiên trong catalog
+// cùng ngôn ngữ với entry (entry không giới hạn ngôn ngữ thì dùng voice mặc định).
+func (s *lexiconService) Preview(ctx context.Context, id int64, user *model.User, voice string) (*helper.SpeechAudio, error) {
+	e, err := s.GetForUser(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	if voice == "" && e.Language != nil {
+		if voices := model.ListTTSVoices(*e.Language, ""); len(voices) > 0 {
+			voice = voices[0].ID
+		}
+	}
+	opts := (&model.TTSOptions{Voice: voice}).WithDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	audio, _, err := helper.Speak(ctx, e.Replacement, opts)
+	return audio, err
+}
+
+// lexiconRule là 1 entry đã chuẩn bị để so khớp.
+type lexiconRule struct {
+	word        []rune
+	replacement string
+	language    string
+	matchCase   bool
+}
+
+// lexiconMatcher thay các từ / cụm từ trong text theo lexicon: khớp nguyên từ (không khớp giữa 1 từ
+// dài hơn), cụm dài được ưu tiên, entry match_case khớp đúng hoa / thường được ưu tiên hơn entry
+// không phân biệt hoa thường.
+type lexiconMatcher struct {
+	rules map[rune][]lexiconRule // theo ký tự đầu (chữ thường)
+}
+
+func newLexiconMatcher(entries []*model.LexiconEntry) *lexiconMatcher {
+	if len(entries) == 0 {
+		return nil
+	}
+	m := &lexiconMatcher{rules: make(map[rune][]lexiconRule)}
+	for _, e := range entries {
+		if e.Word == "" {
+			continue
+		}
+		// Entry lưu trước khi Normalize chuẩn hoá NFC vẫn phải khớp.
+		rule := lexiconRule{word: []rune(norm.NFC.String(e.Word)), replacement: e.Replacement, matchCase: e.MatchCase}
+		if e.Language != nil {
+			rule.language = *e.Language
+		}
+		first := unicode.ToLower(rule.word[0])
+		m.rules[first] = append(m.rules[first], rule)
+	}
+	for _, rules := range m.rules {
+		sort.SliceStable(rules, func(i, j int) bool {
+			if len(rules[i].word) != len(rules[j].word) {
+				return len(rules[i].word) > len(rules[j].word)
+			}
+			return rules[i].matchCase && !rules[j].matchCase
+		})
+	}
+	return m
+}
+
+// Apply thay thế trong text các entry áp dụng cho language (mã 2 ký tự của voice, rỗng = chỉ entry
+// không giới hạn ngôn ngữ). Text được chuẩn hoá NFC trước khi so khớp, giống word của entry.
+func (m *lexiconMatcher) Apply(text, language string) string {
+	if m == nil || text == "" {
+		return text
+	}
+	runes := []rune(norm.NFC.String(text))
+	var out strings.Builder
+	for i := 0; i < len(runes); {
+		if rule, end, ok := m.match(runes, i, language); ok {
+			out.WriteString(rule.replacement)
+			i = end
+			continue
+		}
+		out.WriteRune(runes[i])
+		i++
+	}
+	return out.String()
+}
+
+// match tìm rule khớp tại vị trí i, trả về rule và vị trí kết thúc đoạn khớp trong text.
+func (m *lexiconMatcher) match(runes []rune, i int, language string) (lexiconRule, int, bool) {
+	if i > 0 && isWordRune(runes[i-1]) && isWordRune(runes[i]) {
+		return lexiconRule{}, 0, false
+	}
+	for _, rule := range m.rules[unicode.ToLower(runes[i])] {
+		if rule.language != "" && rule.language != language {
+			continue
+		}
+		end, ok := lexiconMatchAt(runes, i, rule.word, rule.matchCase)
+		if !ok {
+			continue
+		}
+		// Ranh giới cuối: entry kết thúc bằng chữ / số thì ký tự tiếp theo không được là chữ / số.
+		if isWordRune(rule.word[len(rule.word)-1]) && end < len(runes) && isWordRune(runes[end]) {
+			continue
+		}
+		return rule, end, true
+	}
+	return lexiconRule{}, 0, false
+}
+
+// lexiconMatchAt so khớp word tại vị trí i; khoảng trắng trong word khớp với 1 hoặc nhiều khoảng trắng
+// bất kỳ trong text (cụm từ bị xuống dòng vẫn khớp).
+func lexiconMatchAt(runes []rune, i int, word []rune, matchCase bool) (int, bool) {
+	pos := i
+	for _, w := range word {
+		if pos >= len(runes) {
+			return 0, false
+		}
+		if unicode.IsSpace(w) {
+			if !unicode.IsSpace(runes[pos]) {
+				return 0, false
+			}
+			for pos < len(runes) && unicode.IsSpace(runes[pos]) {
+				pos++
+			}
+			continue
+		}
+		if runes[pos] != w && (matchCase || unicode.ToLower(runes[pos]) != unicode.ToLower(w)) {
+			return 0, false
+		}
+		pos++
+	}
+	return pos, true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
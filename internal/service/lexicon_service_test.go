@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"video-transcript/internal/model"
+)
+
+func TestLexiconMatcherApply(t *testing.T) {
+	vi := "vi"
+	entries := []*model.LexiconEntry{
+		{Word: "Nguyễn", Replacement: "Nwin"},
+		{Word: "cafe\u0301", Replacement: "ka-fay"}, // "é" gõ dạng tổ hợp
+		{Word: "WHO", Replacement: "World Health Organization", MatchCase: true},
+		{Word: "phở", Replacement: "fuh", Language: &vi},
+	}
+	for _, e := range entries {
+		e.Normalize()
+	}
+	m := newLexiconMatcher(entries)
+
+	tests := []struct {
+		name     string
+		text     string
+		language string
+		want     string
+	}{
+		{"precomposed text", "Anh Nguyễn", "en", "Anh Nwin"},
+		{"decomposed text", "Anh Nguye\u0302\u0303n", "en", "Anh Nwin"},
+		{"decomposed accent in entry", "a caf\u00e9 please", "en", "a ka-fay please"},
+		{"whole words only", "cafés", "en", "cafés"},
+		{"match case", "who is the WHO", "en", "who is the World Health Organization"},
+		{"language filter", "phở", "en", "phở"},
+		{"language match", "phở", "vi", "fuh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Apply(tt.text, tt.language); got != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexiconMatcherLegacyEntry(t *testing.T) {
+	// Entry lưu trước khi Normalize chuẩn hoá NFC (word ở dạng tổ hợp).
+	m := newLexiconMatcher([]*model.LexiconEntry{{Word: "cafe\u0301", Replacement: "ka-fay"}})
+	if got := m.Apply("caf\u00e9", ""); got != "ka-fay" {
+		t.Errorf("Apply = %q, want %q", got, "ka-fay")
+	}
+}
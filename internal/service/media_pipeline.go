@@ -0,0 +1,752 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/model"
+	"video-transcript/internal/repository"
+	"video-transcript/internal/uploads"
+
+	"go.uber.org/zap"
+)
+
+// Tên file của các artifact, lưu trên R2 dưới videos/<id>/.
+const (
+	audioArtifactName    = "audio-16k-mono.mp3"
+	waveformArtifactName = "waveform.json"
+	posterArtifactName   = "poster.jpg"
+	spriteArtifactName   = "sprite.jpg"
+)
+
+// Ngưỡng silencedetect khi tìm điểm cắt chunk.
+const (
+	chunkSilenceNoiseDB    = -35
+	chunkSilenceMinSeconds = 0.5
+)
+
+// ErrMediaPipelineDisabled trả về khi tính năng cần ffmpeg nhưng MEDIA_PIPELINE_ENABLED = false.
+var ErrMediaPipelineDisabled = errors.New("media pipeline is disabled")
+
+// MediaPipeline xử lý file media (ffmpeg) trước khi gửi cho speech provider.
+type MediaPipeline interface {
+	// ProbeVideo trả về thông tin ffprobe của video (probe và lưu lại nếu chưa có).
+	// Video hỏng hoặc không có audio trả về model.ErrCorruptMedia / model.ErrNoAudioStream.
+	ProbeVideo(ctx context.Context, video *model.Video) (*model.MediaInfo, error)
+	// PrepareAudio tải video, tách audio mono 16 kHz và lưu thành artifact cạnh video.
+	// Video đã có artifact audio thì dùng lại, không chạy ffmpeg.
+	PrepareAudio(ctx context.Context, video *model.Video) (*model.VideoArtifact, error)
+	// PrepareProcessedAudio áp profile tiền xử lý (loudnorm, khử nhiễu, ...) lên audio đã tách
+	// và lưu thành artifact riêng của profile, để user nghe lại đúng audio đã gửi provider.
+	PrepareProcessedAudio(ctx context.Context, video *model.Video, profile string) (*model.VideoArtifact, error)
+	// DetectSpeech chạy VAD (silencedetect) trên audio đã tách và lưu speech map của video.
+	// Video đã có speech map thì dùng lại.
+	DetectSpeech(ctx context.Context, video *model.Video) (*model.SpeechMap, error)
+	// ScheduleSpeech trả về speech map đã lưu của video; chưa có thì chạy DetectSpeech ở background và
+	// trả về (nil, nil). Lần chạy background trước bị lỗi thì trả lỗi đó (lần gọi sau chạy lại).
+	// Media pipeline bị tắt trả về ErrMediaPipelineDisabled.
+	ScheduleSpeech(ctx context.Context, video *model.Video) (*model.SpeechMap, error)
+	// PrepareSpeechAudio ghép các đoạn tiếng nói của source thành 1 file (bỏ khoảng lặng) và lưu thành artifact.
+	PrepareSpeechAudio(ctx context.Context, video *model.Video, source *model.VideoArtifact, speech *model.SpeechMap) (*model.VideoArtifact, error)
+	// SplitAudio cắt audio artifact (dài duration giây) thành các chunk, ưu tiên cắt ở khoảng lặng,
+	// upload từng chunk lên R2 dưới thư mục riêng của task. Caller xoá chunk bằng RemoveChunks.
+	SplitAudio(ctx context.Context, video *model.Video, audio *model.VideoArtifact, taskID int64, duration float64) ([]AudioChunk, error)
+	// RemoveChunks xoá các chunk đã upload (lỗi chỉ ghi log).
+	RemoveChunks(ctx context.Context, chunks []AudioChunk)
+	// StoreCaptions lưu file phụ đề WebVTT (nội dung vtt) thành artifact captions cạnh video.
+	StoreCaptions(ctx context.Context, video *model.Video, vtt string) (*model.VideoArtifact, error)
+	// GenerateAssets sinh waveform (file có audio) và poster + sprite sheet (file có hình) cho web player.
+	GenerateAssets(ctx context.Context, video *model.Video) error
+	// ScheduleAssets chạy GenerateAssets ở background (sau khi upload), lỗi chỉ ghi log. Mỗi video chỉ có
+	// 1 job chờ / chạy, tối đa MEDIA_ASSET_WORKERS job chạy đồng thời. Trả về false nếu media pipeline bị tắt.
+	ScheduleAssets(video *model.Video) bool
+	// TranscodeHLS encode video thành HLS ladder (kèm track phụ đề nếu subtitle != nil),
+	// upload lên R2 dưới thư mục của video và trả về URL master playlist.
+	TranscodeHLS(ctx context.Context, video *model.Video, subtitle *HLSSubtitle) (string, error)
+}
+
+type mediaPipeline struct {
+	videoRepo     repository.VideoRepository
+	artifactRepo  repository.VideoArtifactRepository
+	speechMapRepo repository.SpeechMapRepository
+
+	// speechJobs: video đang chạy DetectSpeech ở background (giá trị nil) hoặc lần chạy trước bị lỗi.
+	speechMu   sync.Mutex
+	speechJobs map[int64]error
+
+	// assetJobs: video đang chờ / chạy GenerateAssets ở background; assetSem giới hạn số job chạy đồng thời.
+	assetMu   sync.Mutex
+	assetJobs map[int64]bool
+	assetSem  chan struct{}
+}
+
+// NewMediaPipeline creates a new MediaPipeline.
+func NewMediaPipeline(videoRepo repository.VideoRepository, artifactRepo repository.VideoArtifactRepository, speechMapRepo repository.SpeechMapRepository) MediaPipeline {
+	return &mediaPipeline{
+		videoRepo:     videoRepo,
+		artifactRepo:  artifactRepo,
+		speechMapRepo: speechMapRepo,
+		speechJobs:    map[int64]error{},
+		assetJobs:     map[int64]bool{},
+		assetSem:      make(chan struct{}, max(1, config.SvcCfg.MediaAssetWorkers)),
+	}
+}
+
+func (p *mediaPipeline) ProbeVideo(ctx context.Context, video *model.Video) (*model.MediaInfo, error) {
+	if info := video.MediaInfo(); info != nil {
+		return info, info.Validate()
+	}
+
+	// Không đưa URL cho ffprobe (ffprobe tự resolve host, bỏ qua kiểm tra IP lúc dial của sourceHTTPClient):
+	// tải file về qua downloadFile rồi probe file local.
+	workDir, err := newWorkDir(video.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	input, err := downloadSource(ctx, video, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := ProbeMedia(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lưu cả kết quả của file không hợp lệ để lần sau không phải probe lại.
+	video.SetMediaInfo(info)
+	if err := p.videoRepo.UpdateMediaInfo(ctx, video); err != nil {
+		zap.S().Errorw("save video media info failed", "video_id", video.ID, "error", err)
+	}
+	return info, info.Validate()
+}
+
+func (p *mediaPipeline) PrepareAudio(ctx context.Context, video *model.Video) (*model.VideoArtifact, error) {
+	existing, err := p.artifactRepo.GetByVideoAndKind(ctx, video.ID, model.ArtifactKindAudio)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, repository.ErrArtifactNotFound) {
+		return nil, err
+	}
+
+	workDir, err := newWorkDir(video.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	sourcePath, err := downloadSource(ctx, video, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	audioPath := filepath.Join(workDir, audioArtifactName)
+	if err := ExtractAudio(ctx, sourcePath, audioPath); err != nil {
+		return nil, fmt.Errorf("extract audio: %w", err)
+	}
+
+	return p.storeArtifact(ctx, video, model.ArtifactKindAudio, audioPath, audioArtifactName, "audio/mpeg", nil)
+}
+
+func (p *mediaPipeline) PrepareProcessedAudio(ctx context.Context, video *model.Video, profile string) (*model.VideoArtifact, error) {
+	kind := model.ProcessedAudioKind(profile)
+	existing, err := p.artifactRepo.GetByVideoAndKind(ctx, video.ID, kind)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, repository.ErrArtifactNotFound) {
+		return nil, err
+	}
+
+	audio, err := p.PrepareAudio(ctx, video)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := newWorkDir(video.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	audioPath := filepath.Join(workDir, audioArtifactName)
+	if err := downloadFile(ctx, audio.URL, audioPath); err != nil {
+		return nil, err
+	}
+
+	end := 0.0
+	if profile == trimSilenceProfile && audio.DurationSec != nil {
+		if end, err = trailingSilenceStart(ctx, audioPath, *audio.DurationSec); err != nil {
+			return nil, err
+		}
+	}
+
+	name := fmt.Sprintf("audio-16k-mono-%s.mp3", profile)
+	outPath := filepath.Join(workDir, name)
+	if err := PreprocessAudio(ctx, audioPath, outPath, profile, end); err != nil {
+		return nil, fmt.Errorf("preprocess audio (%s): %w", profile, err)
+	}
+
+	return p.storeArtifact(ctx, video, kind, outPath, name, "audio/mpeg", nil)
+}
+
+func (p *mediaPipeline) DetectSpeech(ctx context.Context, video *model.Video) (*model.SpeechMap, error) {
+	existing, err := p.speechMapRepo.GetByVideoID(ctx, video.ID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, repository.ErrSpeechMapNotFound) {
+		return nil, err
+	}
+
+	info, err := p.ProbeVideo(ctx, video)
+	if err != nil {
+		return nil, err
+	}
+	audio, err := p.PrepareAudio(ctx, video)
+	if err != nil {
+		return nil, err
+	}
+	duration := info.DurationSec
+	if audio.DurationSec != nil {
+		duration = *audio.DurationSec
+	}
+
+	workDir, err := newWorkDir(video.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	audioPath := filepath.Join(workDir, audioArtifactName)
+	if err := downloadFile(ctx, audio.URL, audioPath); err != nil {
+		return nil, err
+	}
+
+	silences, err := DetectSilences(ctx, audioPath, vadNoiseDB, vadMinSilenceSec, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	speech := &model.SpeechMap{
+		VideoID:       video.ID,
+		DurationSec:   duration,
+		NoiseDB:       vadNoiseDB,
+		MinSilenceSec: vadMinSilenceSec,
+		Segments:      speechSegmentsFromSilences(silences, duration),
+	}
+	speech.Fill()
+	if err := p.speechMapRepo.Upsert(ctx, speech); err != nil {
+		return nil, err
+	}
+	zap.S().Infow("speech map stored", "video_id", video.ID, "segments", len(speech.Segments), "speech_sec", speech.SpeechSec, "duration", duration)
+	return speech, nil
+}
+
+func (p *mediaPipeline) ScheduleSpeech(ctx context.Context, video *model.Video) (*model.SpeechMap, error) {
+	if !config.SvcCfg.MediaPipelineEnabled {
+		return nil, ErrMediaPipelineDisabled
+	}
+	speech, err := p.speechMapRepo.GetByVideoID(ctx, video.ID)
+	if err == nil {
+		return speech, nil
+	}
+	if !errors.Is(err, repository.ErrSpeechMapNotFound) {
+		return nil, err
+	}
+
+	p.speechMu.Lock()
+	defer p.speechMu.Unlock()
+	if jobErr, ok := p.speechJobs[video.ID]; ok {
+		if jobErr == nil {
+			return nil, nil // đang chạy
+		}
+		delete(p.speechJobs, video.ID)
+		return nil, jobErr
+	}
+	p.speechJobs[video.ID] = nil
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.SvcCfg.MediaJobTimeoutMinute)*time.Minute)
+		defer cancel()
+		_, err := p.DetectSpeech(ctx, video)
+		if err != nil {
+			zap.S().Errorw("detect speech failed", "video_id", video.ID, "error", err)
+		}
+
+		p.speechMu.Lock()
+		defer p.speechMu.Unlock()
+		if err != nil {
+			p.speechJobs[video.ID] = err
+		} else {
+			delete(p.speechJobs, video.ID)
+		}
+	}()
+	return nil, nil
+}
+
+func (p *mediaPipeline) PrepareSpeechAudio(ctx context.Context, video *model.Video, source *model.VideoArtifact, speech *model.SpeechMap) (*model.VideoArtifact, error) {
+	kind := source.Kind + "_speech"
+	existing, err := p.artifactRepo.GetByVideoAndKind(ctx, video.ID, kind)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, repository.ErrArtifactNotFound) {
+		return nil, err
+	}
+
+	workDir, err := newWorkDir(video.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	sourceName := path.Base(source.StorageKey)
+	sourcePath := filepath.Join(workDir, sourceName)
+	if err := downloadFile(ctx, source.URL, sourcePath); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(sourceName, path.Ext(sourceName)) + "-speech.mp3"
+	outPath := filepath.Join(workDir, name)
+	if err := ConcatSegments(ctx, sourcePath, outPath, speech.Segments); err != nil {
+		return nil, fmt.Errorf("concat speech segments: %w", err)
+	}
+
+	return p.storeArtifact(ctx, video, kind, outPath, name, "audio/mpeg", nil)
+}
+
+func (p *mediaPipeline) ScheduleAssets(video *model.Video) bool {
+	if !config.SvcCfg.MediaPipelineEnabled {
+		return false
+	}
+
+	p.assetMu.Lock()
+	defer p.assetMu.Unlock()
+	if p.assetJobs[video.ID] {
+		return true // đang chờ / đang chạy
+	}
+	p.assetJobs[video.ID] = true
+
+	go func() {
+		defer func() {
+			p.assetMu.Lock()
+			delete(p.assetJobs, video.ID)
+			p.assetMu.Unlock()
+		}()
+
+		p.assetSem <- struct{}{}
+		defer func() { <-p.assetSem }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.SvcCfg.MediaJobTimeoutMinute)*time.Minute)
+		defer cancel()
+		if err := p.GenerateAssets(ctx, video); err != nil {
+			zap.S().Errorw("generate video assets failed", "video_id", video.ID, "error", err)
+		}
+	}()
+	return true
+}
+
+func (p *mediaPipeline) GenerateAssets(ctx context.Context, video *model.Video) error {
+	info, err := p.ProbeVideo(ctx, video)
+	if err != nil && !errors.Is(err, model.ErrNoAudioStream) {
+		return err
+	}
+
+	workDir, err := newWorkDir(video.ID)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	sourcePath, err := downloadSource(ctx, video, workDir)
+	if err != nil {
+		return err
+	}
+
+	// File không có audio thì không có waveform.
+	if info.AudioCodec != "" {
+		if err := p.storeWaveform(ctx, video, workDir, sourcePath); err != nil {
+			return err
+		}
+	}
+
+	// File chỉ có audio (hoặc chỉ có ảnh bìa) thì không có poster / sprite.
+	if info.VideoCodec == "" {
+		return nil
+	}
+
+	posterPath := filepath.Join(workDir, posterArtifactName)
+	if err := GeneratePoster(ctx, sourcePath, posterPath, info.DurationSec); err != nil {
+		return fmt.Errorf("generate poster: %w", err)
+	}
+	if _, err := p.storeArtifact(ctx, video, model.ArtifactKindPoster, posterPath, posterArtifactName, "image/jpeg", nil); err != nil {
+		return err
+	}
+
+	sheet := planSprite(info.DurationSec, info.Width, info.Height)
+	spritePath := filepath.Join(workDir, spriteArtifactName)
+	if err := GenerateSprite(ctx, sourcePath, spritePath, sheet); err != nil {
+		return fmt.Errorf("generate sprite: %w", err)
+	}
+	if _, err := p.storeArtifact(ctx, video, model.ArtifactKindSprite, spritePath, spriteArtifactName, "image/jpeg", sheet); err != nil {
+		return err
+	}
+	return nil
+}
+
+// storeWaveform tính waveform của sourcePath và lưu thành artifact JSON.
+func (p *mediaPipeline) storeWaveform(ctx context.Context, video *model.Video, workDir, sourcePath string) error {
+	waveform, err := GenerateWaveform(ctx, sourcePath, filepath.Join(workDir, "waveform.pcm"))
+	if err != nil {
+		return fmt.Errorf("generate waveform: %w", err)
+	}
+	waveformPath := filepath.Join(workDir, waveformArtifactName)
+	data, err := json.Marshal(waveform)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(waveformPath, data, 0o600); err != nil {
+		return err
+	}
+	_, err = p.storeArtifact(ctx, video, model.ArtifactKindWaveform, waveformPath, waveformArtifactName, "application/json", nil)
+	return err
+}
+
+func (p *mediaPipeline) TranscodeHLS(ctx context.Context, video *model.Video, subtitle *HLSSubtitle) (string, error) {
+	info, err := p.ProbeVideo(ctx, video)
+	if err != nil && !errors.Is(err, model.ErrNoAudioStream) {
+		return "", err
+	}
+
+	workDir, err := newWorkDir(video.ID)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	sourcePath, err := downloadSource(ctx, video, workDir)
+	if err != nil {
+		return "", err
+	}
+
+	outDir := filepath.Join(workDir, "hls")
+	if err := TranscodeHLS(ctx, sourcePath, outDir, info, subtitle); err != nil {
+		return "", err
+	}
+
+	prefix := artifactKey(video, "hls")
+	urls, err := uploadDir(ctx, outDir, prefix)
+	if err != nil {
+		return "", fmt.Errorf("upload hls: %w", err)
+	}
+	zap.S().Infow("hls uploaded", "video_id", video.ID, "prefix", prefix, "files", len(urls))
+	return urls["master.m3u8"], nil
+}
+
+func (p *mediaPipeline) SplitAudio(ctx context.Context, video *model.Video, audio *model.VideoArtifact, taskID int64, duration float64) ([]AudioChunk, error) {
+	workDir, err := newWorkDir(video.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	audioPath := filepath.Join(workDir, audioArtifactName)
+	if err := downloadFile(ctx, audio.URL, audioPath); err != nil {
+		return nil, err
+	}
+
+	// Không tìm được khoảng lặng thì vẫn cắt theo độ dài mục tiêu.
+	silences, err := DetectSilences(ctx, audioPath, chunkSilenceNoiseDB, chunkSilenceMinSeconds, duration)
+	if err != nil {
+		zap.S().Warnw("detect silences failed, cutting at fixed length", "video_id", video.ID, "error", err)
+		silences = nil
+	}
+	chunks := planChunks(duration, silences,
+		float64(config.SvcCfg.STTChunkSeconds), float64(config.SvcCfg.STTChunkOverlapSeconds))
+
+	for i := range chunks {
+		name := fmt.Sprintf("chunk-%03d.mp3", chunks[i].Index)
+		chunkPath := filepath.Join(workDir, name)
+		if err := CutAudio(ctx, audioPath, chunkPath, chunks[i].Start, chunks[i].End); err != nil {
+			p.RemoveChunks(context.WithoutCancel(ctx), chunks[:i])
+			return nil, fmt.Errorf("cut chunk %d: %w", chunks[i].Index, err)
+		}
+
+		key := artifactKey(video, fmt.Sprintf("chunks/task-%d/%s", taskID, name))
+		url, err := uploadFile(ctx, chunkPath, key, "audio/mpeg")
+		os.Remove(chunkPath)
+		if err != nil {
+			p.RemoveChunks(context.WithoutCancel(ctx), chunks[:i])
+			return nil, fmt.Errorf("upload chunk %d: %w", chunks[i].Index, err)
+		}
+		chunks[i].URL = url
+		chunks[i].StorageKey = key
+	}
+
+	zap.S().Infow("audio split into chunks", "video_id", video.ID, "task_id", taskID, "chunks", len(chunks), "silences", len(silences))
+	return chunks, nil
+}
+
+func (p *mediaPipeline) RemoveChunks(ctx context.Context, chunks []AudioChunk) {
+	for _, c := range chunks {
+		if c.StorageKey == "" {
+			continue
+		}
+		if err := uploads.DeleteFromR2(ctx, c.StorageKey); err != nil {
+			zap.S().Warnw("remove audio chunk failed", "key", c.StorageKey, "error", err)
+		}
+	}
+}
+
+func (p *mediaPipeline) StoreCaptions(ctx context.Context, video *model.Video, vtt string) (*model.VideoArtifact, error) {
+	f, err := os.CreateTemp(config.SvcCfg.MediaWorkDir, fmt.Sprintf("captions-%d-*.vtt", video.ID))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(vtt); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return p.storeArtifact(ctx, video, model.ArtifactKindCaptions, f.Name(), "captions.vtt", "text/vtt", nil)
+}
+
+// storeArtifact upload file lên R2 dưới thư mục của video và ghi lại vào video_artifacts;
+// meta (có thể nil) được lưu dạng JSON vào video_artifacts.meta.
+func (p *mediaPipeline) storeArtifact(ctx context.Context, video *model.Video, kind model.ArtifactKind, filePath, name, contentType string, meta any) (*model.VideoArtifact, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	key := artifactKey(video, name)
+	url, err := uploadFile(ctx, filePath, key, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("upload artifact: %w", err)
+	}
+
+	artifact := &model.VideoArtifact{
+		VideoID:     video.ID,
+		Kind:        kind,
+		URL:         url,
+		StorageKey:  key,
+		ContentType: contentType,
+		SizeBytes:   info.Size(),
+	}
+	if meta != nil {
+		if artifact.Meta, err = json.Marshal(meta); err != nil {
+			return nil, err
+		}
+	}
+	// Audio đã xử lý có thể ngắn hơn video gốc (cắt khoảng lặng), lưu lại thời lượng thực.
+	if strings.HasPrefix(contentType, "audio/") {
+		if media, err := ProbeMedia(ctx, filePath); err == nil {
+			artifact.DurationSec = &media.DurationSec
+		} else {
+			zap.S().Warnw("probe artifact failed", "video_id", video.ID, "kind", kind, "error", err)
+		}
+	}
+	if err := p.artifactRepo.Upsert(ctx, artifact); err != nil {
+		return nil, err
+	}
+	zap.S().Infow("video artifact stored", "video_id", video.ID, "kind", kind, "key", key, "size", info.Size())
+	return artifact, nil
+}
+
+// uploadFile upload file local lên R2 với key cho trước, trả về URL.
+func uploadFile(ctx context.Context, filePath, key, contentType string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	return uploads.UploadToR2(ctx, key, f, info.Size(), contentType)
+}
+
+// uploadWorkers là số file upload đồng thời khi upload cả thư mục (HLS có hàng nghìn segment).
+const uploadWorkers = 8
+
+// uploadDir upload mọi file trong dir lên R2 dưới prefix (giữ cấu trúc thư mục con),
+// trả về map đường dẫn tương đối -> URL.
+func uploadDir(ctx context.Context, dir, prefix string) (map[string]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	urls := make(map[string]string, len(files))
+	sem := make(chan struct{}, uploadWorkers)
+	for _, file := range files {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+
+		wg.Add(1)
+		go func(file, rel string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			url, err := uploadFile(ctx, file, prefix+"/"+rel, contentTypeByExt(rel))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			urls[rel] = url
+		}(file, rel)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return urls, nil
+}
+
+// contentTypeByExt trả về content type cho các file media do pipeline sinh ra.
+func contentTypeByExt(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".m4s":
+		return "video/iso.segment"
+	case ".mp4":
+		return "video/mp4"
+	case ".ts":
+		return "video/mp2t"
+	case ".vtt":
+		return "text/vtt"
+	case ".json":
+		return "application/json"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".mp3":
+		return "audio/mpeg"
+	case ".m4a", ".m4b":
+		return "audio/mp4"
+	case ".wav":
+		return "audio/wav"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// newWorkDir tạo thư mục tạm cho 1 lần xử lý video; caller phải xoá khi xong.
+func newWorkDir(videoID int64) (string, error) {
+	dir, err := os.MkdirTemp(config.SvcCfg.MediaWorkDir, fmt.Sprintf("video-%d-*", videoID))
+	if err != nil {
+		return "", fmt.Errorf("create work dir: %w", err)
+	}
+	return dir, nil
+}
+
+// downloadSource tải video gốc (từ R2 hoặc URL ngoài) vào workDir, trả về đường dẫn file.
+func downloadSource(ctx context.Context, video *model.Video, workDir string) (string, error) {
+	return downloadVideoAs(ctx, video, workDir, "source")
+}
+
+// downloadVideoAs tải file của video vào workDir với tên name (giữ đuôi file gốc), dùng khi 1 job cần
+// nhiều file nguồn.
+func downloadVideoAs(ctx context.Context, video *model.Video, workDir, name string) (string, error) {
+	ext := filepath.Ext(video.NameFile)
+	if ext == "" {
+		ext = path.Ext(strings.SplitN(video.LinkVideo, "?", 2)[0])
+	}
+	sourcePath := filepath.Join(workDir, name+ext)
+
+	if err := downloadFile(ctx, video.LinkVideo, sourcePath); err != nil {
+		return "", err
+	}
+	return sourcePath, nil
+}
+
+// downloadFile tải url (object trên R2 hoặc URL ngoài) về destPath.
+func downloadFile(ctx context.Context, url, destPath string) error {
+	f, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if key, ok := uploads.ObjectKeyFromURL(url); ok {
+		_, err := uploads.DownloadFromR2(ctx, key, f)
+		return err
+	}
+
+	if err := CheckSourceURL(ctx, url); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("download source: %w", err)
+	}
+	resp, err := sourceHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download source: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download source: unexpected status %d", resp.StatusCode)
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return fmt.Errorf("download source: %w", err)
+	}
+	return nil
+}
+
+// artifactKey đặt artifact theo id video: videos/<id>/<name>. Không suy ra từ key của file gốc vì nhiều
+// Video (của các user khác nhau) có thể cùng link_video.
+func artifactKey(video *model.Video, name string) string {
+	return fmt.Sprintf("videos/%d/%s", video.ID, name)
+}
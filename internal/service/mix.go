@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"video-transcript/internal/model"
+)
+
+// Bản mix luôn là stereo 48 kHz (loudnorm xử lý ở 192 kHz nên phải resample lại ở cuối).
+const mixSampleRate = 48000
+
+// Tham số sidechain ducking: ngưỡng thấp để giọng đọc nhỏ cũng kích hoạt, nhả chậm để nhạc không
+// "bơm" lên xuống giữa các từ.
+const (
+	duckThreshold = 0.02
+	duckAttackMs  = 20
+	duckReleaseMs = 500
+)
+
+// mixCodecArgs là tham số encoder ffmpeg theo đuôi file bản mix.
+var mixCodecArgs = map[string][]string{
+	".m4a": {"-c:a", "aac", "-b:a", "192k", "-movflags", "+faststart"},
+	".mp3": {"-c:a", "libmp3lame", "-b:a", "192k"},
+	".wav": {"-c:a", "pcm_s16le"},
+}
+
+// mixPlan là thời gian của bản mix (giây).
+type mixPlan struct {
+	LeadIn   float64 // giọng đọc bắt đầu sau LeadIn giây nhạc
+	Total    float64 // độ dài bản mix = lead-in + giọng đọc + tail
+	MusicEnd float64 // nhạc dừng (không lặp và ngắn hơn bản mix thì sớm hơn Total)
+	FadeIn   float64
+	FadeOut  float64
+}
+
+// planMix tính thời gian bản mix từ độ dài giọng đọc và nhạc; fade được rút ngắn khi nhạc quá ngắn.
+func planMix(req *model.MixMusicRequest, voiceDuration, musicDuration float64) mixPlan {
+	p := mixPlan{LeadIn: float64(req.LeadInMs) / 1000}
+	p.Total = p.LeadIn + voiceDuration + float64(req.TailMs)/1000
+	p.MusicEnd = p.Total
+	if !*req.Loop && musicDuration > 0 && musicDuration < p.MusicEnd {
+		p.MusicEnd = musicDuration
+	}
+	p.FadeIn = min(float64(*req.FadeInMs)/1000, p.MusicEnd/2)
+	p.FadeOut = min(float64(*req.FadeOutMs)/1000, p.MusicEnd/2)
+	return p
+}
+
+// mixFilter dựng filter_complex: [0:a] giọng đọc (trễ lead-in, pad tới hết bản mix), [1:a] nhạc (chỉnh mức,
+// cắt, fade), nhạc bị nén theo sidechain là giọng đọc rồi trộn với giọng đọc và chuẩn hoá loudness.
+func mixFilter(req *model.MixMusicRequest, p mixPlan) string {
+	format := fmt.Sprintf("aresample=%d,aformat=sample_fmts=fltp:channel_layouts=stereo", mixSampleRate)
+
+	voice := []string{format}
+	if p.LeadIn > 0 {
+		voice = append(voice, fmt.Sprintf("adelay=delays=%d:all=1", int(p.LeadIn*1000)))
+	}
+	voice = append(voice, "apad=whole_dur="+formatSeconds(p.Total))
+
+	music := []string{
+		format,
+		fmt.Sprintf("volume=%sdB", strconv.FormatFloat(*req.MusicVolumeDb, 'f', 1, 64)),
+		"atrim=duration=" + formatSeconds(p.MusicEnd),
+		"asetpts=PTS-STARTPTS",
+	}
+	if p.FadeIn > 0 {
+		music = append(music, "afade=t=in:st=0:d="+formatSeconds(p.FadeIn))
+	}
+	if p.FadeOut > 0 {
+		music = append(music, fmt.Sprintf("afade=t=out:st=%s:d=%s", formatSeconds(p.MusicEnd-p.FadeOut), formatSeconds(p.FadeOut)))
+	}
+
+	var graph []string
+	if *req.Duck {
+		graph = append(graph,
+			"[0:a]"+strings.Join(voice, ",")+",asplit=2[voice][sc]",
+			"[1:a]"+strings.Join(music, ",")+"[music]",
+			fmt.Sprintf("[music][sc]sidechaincompress=threshold=%g:ratio=%g:attack=%d:release=%d[bed]", duckThreshold, *req.DuckRatio, duckAttackMs, duckReleaseMs),
+		)
+	} else {
+		graph = append(graph,
+			"[0:a]"+strings.Join(voice, ",")+"[voice]",
+			"[1:a]"+strings.Join(music, ",")+"[bed]",
+		)
+	}
+
+	out := []string{"amix=inputs=2:duration=first:dropout_transition=0:normalize=0"}
+	if t, ok := req.LoudnessTarget(); ok {
+		out = append(out,
+			fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g", t.Integrated, t.TruePeak, t.Range),
+			fmt.Sprintf("aresample=%d", mixSampleRate),
+		)
+	}
+	graph = append(graph, "[voice][bed]"+strings.Join(out, ",")+"[out]")
+	return strings.Join(graph, ";")
+}
+
+// MixMusic trộn nhạc nền musicPath dưới giọng đọc voicePath thành outPath (định dạng theo đuôi file).
+func MixMusic(ctx context.Context, voicePath, musicPath, outPath string, req *model.MixMusicRequest, p mixPlan) error {
+	args := []string{"-i", voicePath}
+	if *req.Loop {
+		args = append(args, "-stream_loop", "-1")
+	}
+	args = append(args,
+		"-i", musicPath,
+		"-filter_complex", mixFilter(req, p),
+		"-map", "[out]",
+		"-vn",
+		"-ac", "2",
+		"-ar", strconv.Itoa(mixSampleRate),
+	)
+	args = append(args, mixCodecArgs[req.Extension()]...)
+	args = append(args, "-y", outPath)
+	return runFFmpeg(ctx, args...)
+}
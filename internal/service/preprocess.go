@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// loudnormFilter chuẩn hoá âm lượng theo EBU R128 (mức chuẩn của podcast/giọng nói).
+const loudnormFilter = "loudnorm=I=-16:TP=-1.5:LRA=11"
+
+// preprocessFilters là bộ filter ffmpeg (-af) của từng profile tiền xử lý audio.
+// Tên profile phải khớp allowlist trong model.STTOptions.
+var preprocessFilters = map[string]string{
+	"speech": "highpass=f=80," + loudnormFilter,
+	"phone":  "highpass=f=200,lowpass=f=3400,afftdn=nf=-25," + loudnormFilter,
+	"noisy":  "highpass=f=100,afftdn=nf=-20:nt=w,afftdn=nf=-30," + loudnormFilter,
+	// Khoảng lặng cuối file được cắt bằng atrim (xem trailingSilenceStart), ở đây chỉ chuẩn hoá.
+	trimSilenceProfile: loudnormFilter,
+}
+
+// trimSilenceProfile chỉ cắt khoảng lặng cuối file: khoảng lặng ở đầu / giữa bài giữ nguyên để
+// timestamp của transcript vẫn khớp file gốc.
+const trimSilenceProfile = "trim_silence"
+
+// Khoảng lặng cuối file: dưới trimSilenceNoiseDB ít nhất trimSilenceMinSec giây, giữ lại trimSilencePadSec.
+const (
+	trimSilenceNoiseDB = -50
+	trimSilenceMinSec  = 1.0
+	trimSilencePadSec  = 0.5
+)
+
+// trailingSilenceStart trả về điểm cắt khoảng lặng cuối audioPath (dài duration giây), 0 nếu file
+// không kết thúc bằng khoảng lặng.
+func trailingSilenceStart(ctx context.Context, audioPath string, duration float64) (float64, error) {
+	silences, err := DetectSilences(ctx, audioPath, trimSilenceNoiseDB, trimSilenceMinSec, duration)
+	if err != nil {
+		return 0, err
+	}
+	if n := len(silences); n > 0 && silences[n-1].End >= duration-0.01 && silences[n-1].Start > 0 {
+		return silences[n-1].Start + trimSilencePadSec, nil
+	}
+	return 0, nil
+}
+
+// PreprocessAudio áp profile tiền xử lý lên audioPath, ghi ra outPath (MP3 mono 16 kHz như ExtractAudio).
+// end > 0 thì cắt audio tại end giây.
+func PreprocessAudio(ctx context.Context, audioPath, outPath, profile string, end float64) error {
+	filters, ok := preprocessFilters[profile]
+	if !ok {
+		return fmt.Errorf("unknown preprocess profile %q", profile)
+	}
+	if end > 0 {
+		filters = fmt.Sprintf("atrim=end=%s,", formatSeconds(end)) + filters
+	}
+	return runFFmpeg(ctx,
+		"-i", audioPath,
+		"-vn",
+		"-af", filters,
+		"-acodec", "libmp3lame",
+		"-b:a", "64k",
+		"-ar", "16000", // loudnorm upsample lên 192 kHz, phải ép lại 16 kHz
+		"-ac", "1",
+		"-y",
+		outPath,
+	)
+}
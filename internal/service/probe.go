@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// ffprobeOutput là phần JSON của `ffprobe -show_format -show_streams` mà ta dùng.
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		CodecName   string `json:"codec_name"`
+		Channels    int    `json:"channels"`
+		SampleRate  string `json:"sample_rate"`
+		Width       int    `json:"width"`
+		Height      int    `json:"height"`
+		Duration    string `json:"duration"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		Size       string `json:"size"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+// ProbeEnabled cho biết có probe media được không: media pipeline bật và có ffprobe trong PATH.
+// Tắt thì upload / STT bỏ qua bước probe (gửi thẳng URL video cho provider như cũ).
+func ProbeEnabled() bool {
+	return config.SvcCfg.MediaPipelineEnabled && ffprobeAvailable()
+}
+
+var ffprobeAvailable = sync.OnceValue(func() bool {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		zap.S().Warnw("ffprobe not found, skipping media probe", "error", err)
+		return false
+	}
+	return true
+})
+
+// ProbeMedia chạy ffprobe trên input (đường dẫn file local, ffprobe không được mở URL) và trả về thông tin kỹ thuật.
+// File ffprobe không đọc được trả về lỗi bọc model.ErrCorruptMedia (kèm stderr).
+func ProbeMedia(ctx context.Context, input string) (*model.MediaInfo, error) {
+	out, err := runTool(ctx, "ffprobe",
+		"-v", "error",
+		"-protocol_whitelist", "file,pipe",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		input,
+	)
+	if err != nil {
+		// ffprobe chạy được nhưng exit != 0: file hỏng / không phải media.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %v", model.ErrCorruptMedia, err)
+		}
+		return nil, err
+	}
+	return parseFFprobe(out)
+}
+
+func parseFFprobe(data []byte) (*model.MediaInfo, error) {
+	var probe ffprobeOutput
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("%w: invalid ffprobe output: %v", model.ErrCorruptMedia, err)
+	}
+
+	info := &model.MediaInfo{
+		Container:   probe.Format.FormatName,
+		DurationSec: parseFloat(probe.Format.Duration),
+		SizeBytes:   parseInt(probe.Format.Size),
+		BitRate:     parseInt(probe.Format.BitRate),
+	}
+
+	var streamDuration float64
+	for _, s := range probe.Streams {
+		if d := parseFloat(s.Duration); d > streamDuration {
+			streamDuration = d
+		}
+		switch s.CodecType {
+		case "audio":
+			if info.AudioCodec != "" {
+				continue // chỉ lấy audio stream đầu tiên
+			}
+			info.AudioCodec = s.CodecName
+			info.AudioChannels = s.Channels
+			info.SampleRate = int(parseInt(s.SampleRate))
+		case "video":
+			// Ảnh bìa (mp3/m4a) cũng là video stream, bỏ qua.
+			if info.VideoCodec != "" || s.Disposition.AttachedPic == 1 {
+				continue
+			}
+			info.VideoCodec = s.CodecName
+			info.Width = s.Width
+			info.Height = s.Height
+		}
+	}
+	// Một số container không ghi duration ở format, lấy duration dài nhất của stream.
+	if info.DurationSec <= 0 {
+		info.DurationSec = streamDuration
+	}
+
+	return info, nil
+}
+
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+func parseInt(s string) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/helper"
+	"video-transcript/internal/model"
+	"video-transcript/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultKeyProvider = "deepgram"
+	maxKeyWeight       = 100
+)
+
+// ErrInvalidProviderKey trả về khi tham số key (key, weight, quota) không hợp lệ.
+var ErrInvalidProviderKey = errors.New("invalid provider key")
+
+// ProviderKeyService quản lý pool API key của speech provider và cấp key cho helper
+// (implement helper.KeySource).
+type ProviderKeyService interface {
+	helper.KeySource
+
+	Create(ctx context.Context, in *model.CreateProviderKeyRequest) (*model.ProviderKey, error)
+	List(ctx context.Context) ([]*model.ProviderKey, error)
+	Disable(ctx context.Context, id int64, reason string) error
+	Enable(ctx context.Context, id int64) error
+	Rotate(ctx context.Context, id int64, in *model.RotateProviderKeyRequest) (*model.ProviderKey, error)
+}
+
+// pooledKey là key đã giải mã trong bộ nhớ cùng trạng thái chọn key.
+type pooledKey struct {
+	id            int64
+	plaintext     string
+	weight        int
+	current       int // trọng số hiện tại cho smooth weighted round-robin
+	quotaLimit    *int64
+	usage         int64 // số lần đã tính phí (usage_count trong DB)
+	reserved      int64 // số request đang chạy với key, giữ chỗ quota
+	cooldownUntil time.Time
+}
+
+func (k *pooledKey) available(now time.Time) bool {
+	if now.Before(k.cooldownUntil) {
+		return false
+	}
+	return k.quotaLimit == nil || k.usage+k.reserved < *k.quotaLimit
+}
+
+type providerKeyService struct {
+	repo repository.ProviderKeyRepository
+
+	mu        sync.Mutex
+	pool      map[string][]*pooledKey // provider -> keys đang active
+	loadedAt  time.Time
+	reloading bool   // đang nạp pool (ngoài s.mu)
+	gen       uint64 // tăng mỗi lần invalidate, để lần nạp đang chạy biết dữ liệu của nó đã cũ
+}
+
+// NewProviderKeyService creates a new ProviderKeyService.
+func NewProviderKeyService(repo repository.ProviderKeyRepository) ProviderKeyService {
+	return &providerKeyService{repo: repo}
+}
+
+func (s *providerKeyService) Create(ctx context.Context, in *model.CreateProviderKeyRequest) (*model.ProviderKey, error) {
+	provider := strings.TrimSpace(in.Provider)
+	if provider == "" {
+		provider = defaultKeyProvider
+	}
+	weight := in.Weight
+	if weight == 0 {
+		weight = 1
+	}
+	key := strings.TrimSpace(in.Key)
+	if err := validateKeyParams(key, weight, in.QuotaLimit); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := helper.EncryptSecret(key)
+	if err != nil {
+		return nil, err
+	}
+	k := &model.ProviderKey{
+		Provider:     provider,
+		Name:         strings.TrimSpace(in.Name),
+		KeyEncrypted: encrypted,
+		KeyHint:      keyHint(key),
+		Weight:       weight,
+		QuotaLimit:   in.QuotaLimit,
+		Status:       model.ProviderKeyStatusActive,
+	}
+	if err := s.repo.Create(ctx, k); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return k, nil
+}
+
+func (s *providerKeyService) List(ctx context.Context) ([]*model.ProviderKey, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *providerKeyService) Disable(ctx context.Context, id int64, reason string) error {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	var reasonPtr *string
+	if reason = strings.TrimSpace(reason); reason != "" {
+		reasonPtr = &reason
+	}
+	if err := s.repo.UpdateStatus(ctx, id, model.ProviderKeyStatusDisabled, reasonPtr); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *providerKeyService) Enable(ctx context.Context, id int64) error {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateStatus(ctx, id, model.ProviderKeyStatusActive, nil); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Rotate thay key mới cho slot. Request đang chạy vẫn dùng key cũ tới khi xong,
+// request mới lấy key mới ngay sau khi pool được nạp lại.
+func (s *providerKeyService) Rotate(ctx context.Context, id int64, in *model.RotateProviderKeyRequest) (*model.ProviderKey, error) {
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	weight := existing.Weight
+	if in.Weight != nil {
+		weight = *in.Weight
+	}
+	quotaLimit := existing.QuotaLimit
+	if in.QuotaLimit != nil {
+		quotaLimit = in.QuotaLimit
+	}
+	key := strings.TrimSpace(in.Key)
+	if err := validateKeyParams(key, weight, quotaLimit); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := helper.EncryptSecret(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Rotate(ctx, id, encrypted, keyHint(key), weight, quotaLimit, in.ResetUsage); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return s.repo.GetByID(ctx, id)
+}
+
+// Acquire chọn key theo smooth weighted round-robin trong các key còn dùng được
+// (không cooldown, còn quota, chưa thử trong request này).
+func (s *providerKeyService) Acquire(ctx context.Context, provider string, exclude map[int64]bool) (*helper.KeyLease, error) {
+	if err := s.reloadIfStale(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := s.pool[provider]
+	if len(keys) == 0 {
+		return nil, helper.ErrNoProviderKeys
+	}
+
+	now := time.Now()
+	total := 0
+	var best *pooledKey
+	for _, k := range keys {
+		if exclude[k.id] || !k.available(now) {
+			continue
+		}
+		k.current += k.weight
+		total += k.weight
+		if best == nil || k.current > best.current {
+			best = k
+		}
+	}
+	if best == nil {
+		return nil, helper.ErrNoAvailableKey
+	}
+	best.current -= total
+	// Giữ chỗ quota ngay khi cấp key để các request song song không vượt quota.
+	best.reserved++
+
+	return &helper.KeyLease{ID: best.id, Key: best.plaintext}, nil
+}
+
+// Release trả chỗ quota đã giữ và ghi nhận kết quả: 401/402 tự disable key, 429 cho key vào cooldown.
+// Chỉ request thành công mới tính là 1 lần sử dụng (5xx, lỗi mạng, request bị huỷ thì không).
+func (s *providerKeyService) Release(ctx context.Context, lease *helper.KeyLease, status int, err error) {
+	// Vẫn ghi nhận kết quả khi caller đã huỷ request.
+	ctx = context.WithoutCancel(ctx)
+
+	billable := err == nil
+	s.mu.Lock()
+	if k := s.findKey(lease.ID); k != nil {
+		k.reserved = max(k.reserved-1, 0)
+		if billable {
+			k.usage++
+		}
+	}
+	s.mu.Unlock()
+
+	switch status {
+	case http.StatusUnauthorized, http.StatusPaymentRequired:
+		reason := fmt.Sprintf("auto-disabled: provider returned %d", status)
+		if err != nil {
+			reason = fmt.Sprintf("%s: %v", reason, err)
+		}
+		s.mu.Lock()
+		s.removeKey(lease.ID)
+		s.mu.Unlock()
+		if err := s.repo.UpdateStatus(ctx, lease.ID, model.ProviderKeyStatusDisabled, &reason); err != nil {
+			zap.S().Errorw("disable provider key failed", "key_id", lease.ID, "error", err)
+		}
+		zap.S().Warnw("provider key disabled", "key_id", lease.ID, "status", status)
+
+	case http.StatusTooManyRequests:
+		until := time.Now().Add(time.Duration(config.SvcCfg.ProviderKeyCooldownSecond) * time.Second)
+		s.mu.Lock()
+		if k := s.findKey(lease.ID); k != nil {
+			k.cooldownUntil = until
+		}
+		s.mu.Unlock()
+		lastError := fmt.Sprintf("provider returned %d", status)
+		if err := s.repo.SetCooldown(ctx, lease.ID, until, lastError); err != nil {
+			zap.S().Errorw("set provider key cooldown failed", "key_id", lease.ID, "error", err)
+		}
+
+	default:
+		if !billable {
+			return
+		}
+		if err := s.repo.IncrementUsage(ctx, lease.ID); err != nil {
+			zap.S().Errorw("increment provider key usage failed", "key_id", lease.ID, "error", err)
+		}
+	}
+}
+
+// reloadIfStale nạp lại pool từ DB theo chu kỳ hoặc sau khi admin thay đổi key. Đọc DB và giải mã key
+// chạy ngoài s.mu (request khác vẫn dùng pool cũ), xong mới thay pool. Trạng thái round-robin, cooldown
+// và chỗ quota đang giữ của key còn trong pool được giữ nguyên.
+func (s *providerKeyService) reloadIfStale(ctx context.Context) error {
+	reloadEvery := time.Duration(config.SvcCfg.ProviderKeyReloadSecond) * time.Second
+	s.mu.Lock()
+	if s.pool != nil && (s.reloading || time.Since(s.loadedAt) < reloadEvery) {
+		s.mu.Unlock()
+		return nil
+	}
+	s.reloading = true
+	gen := s.gen
+	s.mu.Unlock()
+
+	loaded, err := s.loadPool(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reloading = false
+	if err != nil {
+		// Giữ pool cũ nếu DB lỗi tạm thời.
+		if s.pool != nil {
+			zap.S().Errorw("reload provider keys failed, keep current pool", "error", err)
+			return nil
+		}
+		return err
+	}
+
+	for _, keys := range loaded {
+		for _, k := range keys {
+			if old := s.findKey(k.id); old != nil {
+				k.current = old.current
+				k.reserved = old.reserved
+				if old.cooldownUntil.After(k.cooldownUntil) {
+					k.cooldownUntil = old.cooldownUntil
+				}
+			}
+		}
+	}
+	s.pool = loaded
+	// Admin thay đổi key trong lúc đang nạp thì lần Acquire sau nạp lại.
+	if s.gen == gen {
+		s.loadedAt = time.Now()
+	}
+	return nil
+}
+
+// loadPool đọc các key active từ DB và giải mã (không giữ s.mu).
+func (s *providerKeyService) loadPool(ctx context.Context) (map[string][]*pooledKey, error) {
+	rows, err := s.repo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := make(map[string][]*pooledKey)
+	for _, row := range rows {
+		plaintext, err := helper.DecryptSecret(row.KeyEncrypted)
+		if err != nil {
+			zap.S().Errorw("decrypt provider key failed", "key_id", row.ID, "error", err)
+			continue
+		}
+		k := &pooledKey{
+			id:         row.ID,
+			plaintext:  plaintext,
+			weight:     row.Weight,
+			quotaLimit: row.QuotaLimit,
+			usage:      row.UsageCount,
+		}
+		if row.CooldownUntil != nil {
+			k.cooldownUntil = *row.CooldownUntil
+		}
+		pool[row.Provider] = append(pool[row.Provider], k)
+	}
+	return pool, nil
+}
+
+func (s *providerKeyService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.gen++
+	s.mu.Unlock()
+}
+
+func (s *providerKeyService) findKey(id int64) *pooledKey {
+	for _, keys := range s.pool {
+		for _, k := range keys {
+			if k.id == id {
+				return k
+			}
+		}
+	}
+	return nil
+}
+
+func (s *providerKeyService) removeKey(id int64) {
+	for provider, keys := range s.pool {
+		for i, k := range keys {
+			if k.id == id {
+				s.pool[provider] = append(keys[:i:i], keys[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func validateKeyParams(key string, weight int, quotaLimit *int64) error {
+	if key == "" {
+		return fmt.Errorf("%w: key is required", ErrInvalidProviderKey)
+	}
+	if weight < 1 || weight > maxKeyWeight {
+		return fmt.Errorf("%w: weight must be between 1 and %d", ErrInvalidProviderKey, maxKeyWeight)
+	}
+	if quotaLimit != nil && *quotaLimit < 0 {
+		return fmt.Errorf("%w: quota_limit must not be negative", ErrInvalidProviderKey)
+	}
+	return nil
+}
+
+// keyHint giữ 4 ký tự cuối để admin nhận diện key mà không lộ key.
+func keyHint(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/model"
+)
+
+// renderSize trả về kích thước khung hình đầu ra của preset (preset "source" giữ kích thước gốc, làm chẵn cho libx264).
+func renderSize(preset model.RenderPreset, info *model.MediaInfo) (int, int) {
+	if preset.Width > 0 && preset.Height > 0 {
+		return preset.Width, preset.Height
+	}
+	return info.Width - info.Width%2, info.Height - info.Height%2
+}
+
+// scaleFilter đưa video về đúng width x height: Crop thì phóng to rồi cắt cho đầy khung,
+// ngược lại thu nhỏ vừa khung và thêm viền đen.
+func scaleFilter(preset model.RenderPreset, width, height int) string {
+	if preset.Crop {
+		return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,setsar=1", width, height, width, height)
+	}
+	return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1", width, height, width, height)
+}
+
+// escapeFilterPath escape đường dẫn file để đặt trong giá trị option của filter ffmpeg.
+func escapeFilterPath(p string) string {
+	return strings.NewReplacer(`\`, `\\\\`, `:`, `\\:`, `'`, `\\\'`, `,`, `\,`, `[`, `\[`, `]`, `\]`, `;`, `\;`).Replace(p)
+}
+
+// BurnCaptions render sourcePath với phụ đề assPath vẽ thẳng lên hình, ghi ra outPath (MP4 H.264/AAC).
+func BurnCaptions(ctx context.Context, sourcePath, assPath, outPath string, preset model.RenderPreset, width, height int) error {
+	filters := []string{}
+	if preset.Width > 0 && preset.Height > 0 {
+		filters = append(filters, scaleFilter(preset, width, height))
+	}
+	ass := "ass=filename=" + escapeFilterPath(assPath)
+	if dir := config.SvcCfg.CaptionFontsDir; dir != "" {
+		ass += ":fontsdir=" + escapeFilterPath(dir)
+	}
+	filters = append(filters, ass)
+
+	return runFFmpeg(ctx,
+		"-i", sourcePath,
+		"-map", "0:v:0",
+		"-map", "0:a:0?",
+		"-vf", strings.Join(filters, ","),
+		"-c:v", "libx264",
+		"-preset", "medium",
+		"-crf", "20",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-b:a", "160k",
+		"-movflags", "+faststart",
+		"-y",
+		outPath,
+	)
+}
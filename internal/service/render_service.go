@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/model"
+	"video-transcript/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrEmptyTranscript trả về khi task không có transcript có timestamp để render.
+	ErrEmptyTranscript = errors.New("task has no timed transcript")
+	// ErrNoVideoStream trả về khi render video từ file chỉ có audio.
+	ErrNoVideoStream = errors.New("source has no video stream")
+)
+
+// RenderService render video dẫn xuất (burn-in phụ đề, ...) ở background. Mỗi lần render là 1 task
+// loại render; kết quả được upload và lưu thành Video mới trỏ về video nguồn.
+type RenderService interface {
+	// RenderCaptions burn-in transcript của transcriptTask vào video theo style / preset của req.
+	RenderCaptions(ctx context.Context, userID int64, video *model.Video, transcriptTask *model.Task, req *model.RenderCaptionsRequest) (*model.Task, error)
+	// EditVideo cắt video theo edit decision trên transcript của transcriptTask. Video kết quả có kèm
+	// 1 task STT completed chứa transcript đã đổi timestamp.
+	EditVideo(ctx context.Context, userID int64, video *model.Video, transcriptTask *model.Task, req *model.EditVideoRequest) (*model.Task, error)
+	// ExtractClips cắt các clip theo thời gian / utterance, mỗi clip thành 1 video kèm transcript riêng;
+	// req.Reel thì ghép thêm 1 highlight reel (video cuối cùng của task) có title card.
+	ExtractClips(ctx context.Context, userID int64, video *model.Video, transcriptTask *model.Task, req *model.ClipsRequest) (*model.Task, error)
+	// MixMusic trộn nhạc nền music dưới audio narration của task TTS narrationTask (ducking, fade, chuẩn hoá
+	// loudness). Bản mix là Video dẫn xuất trỏ về narration và narrationTask, kèm transcript timing nếu có.
+	MixMusic(ctx context.Context, userID int64, narration *model.Video, narrationTask *model.Task, music *model.Video, req *model.MixMusicRequest) (*model.Task, error)
+}
+
+type renderService struct {
+	videoRepo repository.VideoRepository
+	taskSvc   TaskService
+	pipeline  MediaPipeline
+}
+
+// NewRenderService creates a new RenderService.
+func NewRenderService(videoRepo repository.VideoRepository, taskSvc TaskService, pipeline MediaPipeline) RenderService {
+	return &renderService{videoRepo: videoRepo, taskSvc: taskSvc, pipeline: pipeline}
+}
+
+// renderOutput là 1 file kết quả của job render, mỗi file thành 1 Video dẫn xuất.
+type renderOutput struct {
+	path       string
+	suffix     string                  // thêm vào tên file: clip.mov -> clip-<suffix>.mp4
+	transcript *model.SimpleTranscript // transcript của file kết quả (nếu có), lưu thành task STT của video mới
+}
+
+// renderJob là phần việc riêng của từng loại render: ghi các file kết quả vào workDir.
+type renderJob func(ctx context.Context, workDir, sourcePath string, info *model.MediaInfo) ([]renderOutput, error)
+
+// renderSpec mô tả 1 loại render dùng chung luồng task / upload / tạo video dẫn xuất.
+type renderSpec struct {
+	sourceTaskID *int64 // task transcript dùng để render (nếu có)
+	job          renderJob
+}
+
+func (s *renderService) RenderCaptions(ctx context.Context, userID int64, video *model.Video, transcriptTask *model.Task, req *model.RenderCaptionsRequest) (*model.Task, error) {
+	if !config.SvcCfg.MediaPipelineEnabled {
+		return nil, ErrMediaPipelineDisabled
+	}
+
+	transcript, err := decodeTranscript(transcriptTask)
+	if err != nil {
+		return nil, err
+	}
+	cues := BuildCues(transcript)
+	if len(cues) == 0 {
+		return nil, ErrEmptyTranscript
+	}
+	preset, _ := model.LookupRenderPreset(req.Preset)
+
+	job := func(ctx context.Context, workDir, sourcePath string, info *model.MediaInfo) ([]renderOutput, error) {
+		if info.VideoCodec == "" {
+			return nil, ErrNoVideoStream
+		}
+		width, height := renderSize(preset, info)
+		assPath := filepath.Join(workDir, "captions.ass")
+		if err := os.WriteFile(assPath, []byte(BuildASS(cues, req.Style, width, height)), 0o600); err != nil {
+			return nil, err
+		}
+		outPath := filepath.Join(workDir, "captions.mp4")
+		if err := BurnCaptions(ctx, sourcePath, assPath, outPath, preset, width, height); err != nil {
+			return nil, fmt.Errorf("burn captions: %w", err)
+		}
+		return []renderOutput{{path: outPath, suffix: "captions"}}, nil
+	}
+
+	return s.start(ctx, userID, video, req, renderSpec{sourceTaskID: &transcriptTask.ID, job: job})
+}
+
+func (s *renderService) EditVideo(ctx context.Context, userID int64, video *model.Video, transcriptTask *model.Task, req *model.EditVideoRequest) (*model.Task, error) {
+	if !config.SvcCfg.MediaPipelineEnabled {
+		return nil, ErrMediaPipelineDisabled
+	}
+
+	transcript, err := decodeTranscript(transcriptTask)
+	if err != nil {
+		return nil, err
+	}
+	// Cần độ dài video để cắt tới cuối file; thường đã có sẵn từ lúc upload.
+	info, err := s.pipeline.ProbeVideo(ctx, video)
+	if err != nil && !errors.Is(err, model.ErrNoAudioStream) {
+		return nil, err
+	}
+	plan, err := planEdit(transcript, info.DurationSec, req)
+	if err != nil {
+		return nil, err
+	}
+
+	job := func(ctx context.Context, workDir, sourcePath string, info *model.MediaInfo) ([]renderOutput, error) {
+		hasVideo, hasAudio := info.VideoCodec != "", info.AudioCodec != ""
+		outPath := filepath.Join(workDir, "edited"+renderExt(hasVideo))
+		if err := RenderSegments(ctx, sourcePath, outPath, plan.Segments, plan.Crossfade, hasVideo, hasAudio); err != nil {
+			return nil, fmt.Errorf("render edit: %w", err)
+		}
+		return []renderOutput{{path: outPath, suffix: "edited", transcript: plan.Transcript}}, nil
+	}
+
+	return s.start(ctx, userID, video, req, renderSpec{sourceTaskID: &transcriptTask.ID, job: job})
+}
+
+func (s *renderService) ExtractClips(ctx context.Context, userID int64, video *model.Video, transcriptTask *model.Task, req *model.ClipsRequest) (*model.Task, error) {
+	if !config.SvcCfg.MediaPipelineEnabled {
+		return nil, ErrMediaPipelineDisabled
+	}
+
+	transcript, err := decodeTranscript(transcriptTask)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.pipeline.ProbeVideo(ctx, video)
+	if err != nil && !errors.Is(err, model.ErrNoAudioStream) {
+		return nil, err
+	}
+	clips, err := planClips(transcript, info.DurationSec, req)
+	if err != nil {
+		return nil, err
+	}
+
+	job := func(ctx context.Context, workDir, sourcePath string, info *model.MediaInfo) ([]renderOutput, error) {
+		hasVideo, hasAudio := info.VideoCodec != "", info.AudioCodec != ""
+		outputs := make([]renderOutput, 0, len(clips)+1)
+		for i, c := range clips {
+			outPath := clipFileName(workDir, i, hasVideo)
+			if err := RenderSegments(ctx, sourcePath, outPath, []EditSegment{c.Segment}, 0, hasVideo, hasAudio); err != nil {
+				return nil, fmt.Errorf("render clip %d: %w", i+1, err)
+			}
+			outputs = append(outputs, renderOutput{path: outPath, suffix: fmt.Sprintf("clip-%d", i+1), transcript: c.Transcript})
+		}
+		if req.Reel {
+			outPath := filepath.Join(workDir, "reel"+renderExt(hasVideo))
+			if err := RenderReel(ctx, sourcePath, outPath, clips, req.TitleCardDuration(), info); err != nil {
+				return nil, fmt.Errorf("render highlight reel: %w", err)
+			}
+			outputs = append(outputs, renderOutput{path: outPath, suffix: "reel", transcript: reelTranscript(clips, req.TitleCardDuration(), hasVideo)})
+		}
+		return outputs, nil
+	}
+
+	return s.start(ctx, userID, video, req, renderSpec{sourceTaskID: &transcriptTask.ID, job: job})
+}
+
+func (s *renderService) MixMusic(ctx context.Context, userID int64, narration *model.Video, narrationTask *model.Task, music *model.Video, req *model.MixMusicRequest) (*model.Task, error) {
+	if !config.SvcCfg.MediaPipelineEnabled {
+		return nil, ErrMediaPipelineDisabled
+	}
+
+	req = req.WithDefaults()
+	musicInfo, err := s.pipeline.ProbeVideo(ctx, music)
+	if err != nil {
+		return nil, err
+	}
+	// Task TTS tạo kèm timing thì bản mix có transcript tương ứng (lệch theo lead-in).
+	var transcript *model.SimpleTranscript
+	if t, err := decodeTranscript(narrationTask); err == nil {
+		shiftTranscript(t, float64(req.LeadInMs)/1000)
+		transcript = t
+	}
+
+	job := func(ctx context.Context, workDir, sourcePath string, info *model.MediaInfo) ([]renderOutput, error) {
+		if info.AudioCodec == "" {
+			return nil, model.ErrNoAudioStream
+		}
+		musicPath, err := downloadVideoAs(ctx, music, workDir, "music")
+		if err != nil {
+			return nil, fmt.Errorf("download music: %w", err)
+		}
+		outPath := filepath.Join(workDir, "mix"+req.Extension())
+		if err := MixMusic(ctx, sourcePath, musicPath, outPath, req, planMix(req, info.DurationSec, musicInfo.DurationSec)); err != nil {
+			return nil, fmt.Errorf("mix music: %w", err)
+		}
+		return []renderOutput{{path: outPath, suffix: "mix", transcript: transcript}}, nil
+	}
+
+	return s.start(ctx, userID, narration, req, renderSpec{sourceTaskID: &narrationTask.ID, job: job})
+}
+
+// saveVideoTranscript lưu transcript của video được sinh ra (render, TTS) thành 1 task STT completed,
+// để dùng tiếp như transcript bình thường (HLS subtitle, render phụ đề, ...).
+func saveVideoTranscript(ctx context.Context, taskSvc TaskService, video *model.Video, transcript *model.SimpleTranscript) error {
+	transcriptJSON, err := json.Marshal(transcript)
+	if err != nil {
+		return err
+	}
+	task := &model.Task{
+		TaskType:       model.TaskTypeSTT,
+		Status:         model.TaskStatusCompleted,
+		InputURL:       &video.LinkVideo,
+		TranscriptText: &transcript.TranscriptText,
+		TranscriptJSON: transcriptJSON,
+		DurationSec:    video.DurationSec,
+		UserID:         &video.UserID,
+		VideoID:        &video.ID,
+	}
+	if err := taskSvc.Create(ctx, task); err != nil {
+		return fmt.Errorf("save transcript: %w", err)
+	}
+	if transcript.DetectedLanguage != "" {
+		if err := taskSvc.UpdateDetectedLanguage(ctx, task.ID, &transcript.DetectedLanguage, &transcript.LanguageConfidence); err != nil {
+			zap.S().Errorw("update detected language failed", "task_id", task.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// start tạo task render và chạy job ở background.
+func (s *renderService) start(ctx context.Context, userID int64, video *model.Video, req any, spec renderSpec) (*model.Task, error) {
+	optionsJSON, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &model.Task{
+		TaskType: model.TaskTypeRender,
+		Status:   model.TaskStatusPending,
+		InputURL: &video.LinkVideo,
+		UserID:   &userID,
+		VideoID:  &video.ID,
+		Options:  optionsJSON,
+	}
+	if err := s.taskSvc.Create(ctx, task); err != nil {
+		zap.S().Errorw("create render task failed", "video_id", video.ID, "error", err)
+		return nil, err
+	}
+
+	go s.run(task, video, spec)
+	return task, nil
+}
+
+func (s *renderService) run(task *model.Task, video *model.Video, spec renderSpec) {
+	timeout := time.Duration(config.SvcCfg.RenderJobTimeoutMinute) * time.Minute
+	runTask(s.taskSvc, task, timeout, func(ctx context.Context) error {
+		derived, err := s.render(ctx, task, video, spec)
+		if err != nil {
+			return err
+		}
+		if len(derived) == 0 {
+			return errors.New("render produced no output")
+		}
+
+		// output_url của task là video cuối cùng (video duy nhất, hoặc highlight reel); danh sách đầy đủ
+		// lấy qua các video có render_task_id = task.
+		last := derived[len(derived)-1]
+		if err := s.taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusCompleted, &last.LinkVideo, last.DurationSec, nil); err != nil {
+			zap.S().Errorw("update task status failed", "id", task.ID, "error", err)
+		}
+		zap.S().Infow("derived videos rendered", "task_id", task.ID, "source_video_id", video.ID, "videos", len(derived))
+		return nil
+	})
+}
+
+// render tải video nguồn, chạy job, upload các file kết quả và tạo Video dẫn xuất cho từng file.
+func (s *renderService) render(ctx context.Context, task *model.Task, video *model.Video, spec renderSpec) ([]*model.Video, error) {
+	info, err := s.pipeline.ProbeVideo(ctx, video)
+	if err != nil && !errors.Is(err, model.ErrNoAudioStream) {
+		return nil, err
+	}
+
+	workDir, err := newWorkDir(video.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	sourcePath, err := downloadSource(ctx, video, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := spec.job(ctx, workDir, sourcePath, info)
+	if err != nil {
+		return nil, err
+	}
+
+	derived := make([]*model.Video, 0, len(outputs))
+	for _, out := range outputs {
+		v, err := s.storeOutput(ctx, task, video, spec, out)
+		if err != nil {
+			return nil, err
+		}
+		derived = append(derived, v)
+	}
+	return derived, nil
+}
+
+// storeOutput upload 1 file kết quả và tạo Video dẫn xuất (kèm transcript nếu job có trả về).
+func (s *renderService) storeOutput(ctx context.Context, task *model.Task, video *model.Video, spec renderSpec, out renderOutput) (*model.Video, error) {
+	outInfo, err := ProbeMedia(ctx, out.path)
+	if err != nil {
+		return nil, fmt.Errorf("probe rendered video: %w", err)
+	}
+
+	name := derivedFileName(video, out.suffix, path.Ext(out.path))
+	key := filepath.ToSlash(filepath.Join("uploads", fmt.Sprintf("%d-%s", time.Now().UnixNano(), name)))
+	url, err := uploadFile(ctx, out.path, key, contentTypeByExt(out.path))
+	if err != nil {
+		return nil, fmt.Errorf("upload rendered video: %w", err)
+	}
+
+	derived := &model.Video{
+		UserID:        *task.UserID,
+		LinkVideo:     url,
+		NameFile:      name,
+		SourceVideoID: &video.ID,
+		SourceTaskID:  spec.sourceTaskID,
+		RenderTaskID:  &task.ID,
+	}
+	derived.SetMediaInfo(outInfo)
+	if err := s.videoRepo.Create(ctx, derived); err != nil {
+		return nil, err
+	}
+	if out.transcript != nil {
+		if err := saveVideoTranscript(ctx, s.taskSvc, derived, out.transcript); err != nil {
+			return nil, err
+		}
+	}
+
+	s.pipeline.ScheduleAssets(derived)
+	return derived, nil
+}
+
+// renderExt là đuôi file kết quả: MP4 cho video, M4A khi nguồn chỉ có audio.
+func renderExt(hasVideo bool) string {
+	if hasVideo {
+		return ".mp4"
+	}
+	return ".m4a"
+}
+
+// derivedFileName đặt tên file dẫn xuất theo tên video nguồn: clip.mov -> clip-captions.mp4.
+func derivedFileName(video *model.Video, suffix, ext string) string {
+	base := strings.TrimSuffix(filepath.Base(video.NameFile), filepath.Ext(video.NameFile))
+	if base == "" || base == "." {
+		base = fmt.Sprintf("video-%d", video.ID)
+	}
+	return base + "-" + suffix + ext
+}
+
+// decodeTranscript đọc transcript JSON của task STT.
+func decodeTranscript(task *model.Task) (*model.SimpleTranscript, error) {
+	if len(task.TranscriptJSON) == 0 {
+		return nil, ErrEmptyTranscript
+	}
+	var transcript model.SimpleTranscript
+	if err := json.Unmarshal(task.TranscriptJSON, &transcript); err != nil {
+		return nil, fmt.Errorf("decode transcript: %w", err)
+	}
+	return &transcript, nil
+}
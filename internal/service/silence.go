@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Silence là 1 khoảng lặng (giây) do ffmpeg silencedetect tìm được.
+type Silence struct {
+	Start float64
+	End   float64
+}
+
+// Mid trả về điểm giữa khoảng lặng, dùng làm điểm cắt.
+func (s Silence) Mid() float64 {
+	return (s.Start + s.End) / 2
+}
+
+// DetectSilences tìm các khoảng lặng có mức âm dưới noiseDB (vd: -35) dài ít nhất minDuration giây.
+// duration là độ dài file, dùng để đóng khoảng lặng kéo dài tới cuối file.
+func DetectSilences(ctx context.Context, path string, noiseDB, minDuration, duration float64) ([]Silence, error) {
+	filter := fmt.Sprintf("silencedetect=noise=%gdB:d=%g", noiseDB, minDuration)
+	log, err := runFFmpegLog(ctx, "-i", path, "-af", filter, "-f", "null", "-")
+	if err != nil {
+		return nil, fmt.Errorf("detect silences: %w", err)
+	}
+	return parseSilences(log, duration), nil
+}
+
+// parseSilences đọc các dòng "silence_start: X" / "silence_end: Y | silence_duration: Z" trong log ffmpeg.
+func parseSilences(log string, duration float64) []Silence {
+	var silences []Silence
+	start := -1.0
+	scanner := bufio.NewScanner(strings.NewReader(log))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "silence_start: "); i >= 0 {
+			start = parseFloat(firstField(line[i+len("silence_start: "):]))
+			if start < 0 {
+				start = 0
+			}
+			continue
+		}
+		if i := strings.Index(line, "silence_end: "); i >= 0 && start >= 0 {
+			end := parseFloat(firstField(line[i+len("silence_end: "):]))
+			if end > start {
+				silences = append(silences, Silence{Start: start, End: end})
+			}
+			start = -1
+		}
+	}
+	// Khoảng lặng kéo dài tới cuối file thì ffmpeg không in silence_end.
+	if start >= 0 && duration > start {
+		silences = append(silences, Silence{Start: start, End: duration})
+	}
+	return silences
+}
+
+func firstField(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
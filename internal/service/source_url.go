@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrUnsafeSourceURL: link ngoài không phải http(s) hoặc trỏ vào địa chỉ nội bộ (loopback, private,
+// link-local, metadata cloud, ...). Server không được GET / ffprobe những URL này.
+var ErrUnsafeSourceURL = errors.New("source url must be a public http(s) url")
+
+// sourceHTTPClient tải link ngoài: kiểm tra lại IP lúc connect (cả khi redirect hoặc DNS đổi sau bước
+// CheckSourceURL) để không gọi được vào mạng nội bộ.
+var sourceHTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 30 * time.Second,
+			Control: func(_, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+					return fmt.Errorf("%w: %s", ErrUnsafeSourceURL, host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// CheckSourceURL kiểm tra link ngoài trước khi GET / ffprobe: chỉ nhận http(s), mọi IP mà host resolve
+// ra phải là địa chỉ public.
+func CheckSourceURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrUnsafeSourceURL
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPublicIP(ip) {
+			return fmt.Errorf("%w: %s", ErrUnsafeSourceURL, host)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: resolve %s: %v", ErrUnsafeSourceURL, host, err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrUnsafeSourceURL, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// isPublicIP: false với loopback, private, link-local (gồm 169.254.169.254), multicast, unspecified và
+// dải CGNAT 100.64.0.0/10.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+		return false
+	}
+	return true
+}
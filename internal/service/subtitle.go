@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"video-transcript/internal/model"
+)
+
+// Giới hạn 1 cue phụ đề: tối đa 2 dòng x subtitleLineChars ký tự, dài tối đa subtitleMaxDuration giây;
+// 2 từ cách nhau hơn subtitleMaxGap giây thì sang cue mới.
+const (
+	subtitleLineChars   = 42
+	subtitleMaxDuration = 6.0
+	subtitleMaxGap      = 1.0
+)
+
+// Cue là 1 đoạn phụ đề.
+type Cue struct {
+	Start   float64
+	End     float64
+	Text    string
+	Speaker *int
+	Name    string             // tên người nói (nếu biết), ưu tiên hơn "Speaker N"
+	Words   []model.SimpleWord // các từ trong cue (rỗng nếu transcript không có word timing)
+}
+
+// BuildCues chia transcript thành các cue phụ đề theo thời gian từng từ; transcript không có
+// word timing thì dùng nguyên utterance. Cue không gộp từ của 2 speaker khác nhau.
+func BuildCues(t *model.SimpleTranscript) []Cue {
+	if t == nil {
+		return nil
+	}
+	if len(t.Words) == 0 {
+		cues := make([]Cue, 0, len(t.Utterances))
+		for _, u := range t.Utterances {
+			if text := strings.TrimSpace(u.Transcript); text != "" {
+				cues = append(cues, Cue{Start: u.Start, End: u.End, Text: text, Speaker: u.Speaker})
+			}
+		}
+		return cues
+	}
+
+	var (
+		cues  []Cue
+		cur   *Cue
+		words []string
+	)
+	flush := func() {
+		if cur != nil {
+			cur.Text = strings.Join(words, " ")
+			cues = append(cues, *cur)
+		}
+		cur, words = nil, nil
+	}
+	for _, w := range t.Words {
+		if cur != nil {
+			chars := utf8.RuneCountInString(strings.Join(words, " ")) + 1 + utf8.RuneCountInString(w.Word)
+			if chars > 2*subtitleLineChars ||
+				w.End-cur.Start > subtitleMaxDuration ||
+				w.Start-cur.End > subtitleMaxGap ||
+				!sameSpeaker(cur.Speaker, w.Speaker) {
+				flush()
+			}
+		}
+		if cur == nil {
+			cur = &Cue{Start: w.Start, Speaker: w.Speaker}
+		}
+		cur.End = w.End
+		cur.Words = append(cur.Words, w)
+		words = append(words, w.Word)
+	}
+	flush()
+	return cues
+}
+
+func sameSpeaker(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// wrapCueText ngắt text dài hơn 1 dòng thành 2 dòng tại khoảng trắng gần giữa nhất.
+func wrapCueText(text string) string {
+	if utf8.RuneCountInString(text) <= subtitleLineChars {
+		return text
+	}
+	mid := len(text) / 2
+	best := -1
+	for i, r := range text {
+		if r == ' ' && (best < 0 || math.Abs(float64(i-mid)) < math.Abs(float64(best-mid))) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return text
+	}
+	return text[:best] + "\n" + text[best+1:]
+}
+
+// vttEscaper escape các ký tự đặc biệt của WebVTT trong text cue và tên voice tag.
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// FormatWebVTT xuất các cue thành file WebVTT (speaker dùng voice tag <v Tên> hoặc <v Speaker N>).
+func FormatWebVTT(cues []Cue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i, c := range cues {
+		text := vttEscaper.Replace(wrapCueText(c.Text))
+		if c.Name != "" {
+			text = fmt.Sprintf("<v %s>%s", vttEscaper.Replace(c.Name), text)
+		} else if c.Speaker != nil {
+			text = fmt.Sprintf("<v Speaker %d>%s", *c.Speaker, text)
+		}
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n%s\n", i+1, formatCueTime(c.Start, "."), formatCueTime(c.End, "."), text)
+	}
+	return b.String()
+}
+
+// formatCueTime định dạng giây thành HH:MM:SS<sep>mmm (WebVTT dùng ".", SRT dùng ",").
+func formatCueTime(sec float64, sep string) string {
+	if sec < 0 {
+		sec = 0
+	}
+	ms := int64(math.Round(sec * 1000))
+	h := ms / 3600000
+	m := ms / 60000 % 60
+	s := ms / 1000 % 60
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, s, sep, ms%1000)
+}
@@ -15,6 +15,13 @@ type TaskService interface {
 	ListTaskByUserID(ctx context.Context, userID int64, limit, offset int, search string, status string) (*model.ListTaskByUserIDResponse, error)
 	UpdateStatus(ctx context.Context, id int64, status model.TaskStatus, outputURL *string, durationSec *float64, errorMessage *string) error
 	UpdateTranscript(ctx context.Context, id int64, status model.TaskStatus, transcriptText *string, transcriptJSON []byte) error
+	UpdateDetectedLanguage(ctx context.Context, id int64, language *string, confidence *float64) error
+	UpdateProvider(ctx context.Context, id int64, provider string) error
+	UpdateAudioURL(ctx context.Context, id int64, audioURL string) error
+	UpdateDuration(ctx context.Context, id int64, durationSec float64) error
+	UpdateProgress(ctx context.Context, id int64, current, total int) error
+	UpdateVideoID(ctx context.Context, id int64, videoID int64) error
+	GetLatestCompletedByVideo(ctx context.Context, videoID int64, taskType model.TaskType) (*model.Task, error)
 }
 
 type taskService struct {
@@ -49,3 +56,31 @@ func (s *taskService) UpdateTranscript(ctx context.Context, id int64, status mod
 func (s *taskService) ListTaskByUserID(ctx context.Context, userID int64, limit, offset int, search string, status string) (*model.ListTaskByUserIDResponse, error) {
 	return s.repo.ListTaskByUserID(ctx, userID, limit, offset, search, status)
 }
+
+func (s *taskService) UpdateDetectedLanguage(ctx context.Context, id int64, language *string, confidence *float64) error {
+	return s.repo.UpdateDetectedLanguage(ctx, id, language, confidence)
+}
+
+func (s *taskService) UpdateProvider(ctx context.Context, id int64, provider string) error {
+	return s.repo.UpdateProvider(ctx, id, provider)
+}
+
+func (s *taskService) UpdateAudioURL(ctx context.Context, id int64, audioURL string) error {
+	return s.repo.UpdateAudioURL(ctx, id, audioURL)
+}
+
+func (s *taskService) UpdateDuration(ctx context.Context, id int64, durationSec float64) error {
+	return s.repo.UpdateDuration(ctx, id, durationSec)
+}
+
+func (s *taskService) UpdateVideoID(ctx context.Context, id int64, videoID int64) error {
+	return s.repo.UpdateVideoID(ctx, id, videoID)
+}
+
+func (s *taskService) UpdateProgress(ctx context.Context, id int64, current, total int) error {
+	return s.repo.UpdateProgress(ctx, id, current, total)
+}
+
+func (s *taskService) GetLatestCompletedByVideo(ctx context.Context, videoID int64, taskType model.TaskType) (*model.Task, error) {
+	return s.repo.GetLatestCompletedByVideo(ctx, videoID, taskType)
+}
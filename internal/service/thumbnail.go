@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"video-transcript/internal/model"
+)
+
+// Sprite sheet: tối đa spriteMaxThumbs thumbnail rộng spriteThumbWidth px, xếp spriteColumns cột,
+// cách nhau ít nhất spriteMinInterval giây.
+const (
+	posterWidth       = 1280
+	spriteThumbWidth  = 160
+	spriteColumns     = 10
+	spriteMaxThumbs   = 100
+	spriteMinInterval = 2.0
+)
+
+// GeneratePoster lấy 1 frame của video (10% thời lượng, tối đa giây thứ 10) làm ảnh poster JPEG.
+func GeneratePoster(ctx context.Context, videoPath, outPath string, duration float64) error {
+	at := math.Min(duration*0.1, 10)
+	return runFFmpeg(ctx,
+		"-ss", formatSeconds(at),
+		"-i", videoPath,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", posterWidth),
+		"-q:v", "3",
+		"-y",
+		outPath,
+	)
+}
+
+// planSprite tính layout sprite sheet cho video dài duration giây, kích thước width x height.
+func planSprite(duration float64, width, height int) model.SpriteSheet {
+	interval := math.Max(spriteMinInterval, math.Ceil(duration/spriteMaxThumbs))
+	count := int(math.Ceil(duration / interval))
+	if count < 1 {
+		count = 1
+	}
+	columns := min(spriteColumns, count)
+
+	thumbHeight := spriteThumbWidth * 9 / 16
+	if width > 0 && height > 0 {
+		thumbHeight = int(math.Round(float64(spriteThumbWidth)*float64(height)/float64(width)/2)) * 2
+	}
+	return model.SpriteSheet{
+		IntervalSec: interval,
+		Columns:     columns,
+		Rows:        (count + columns - 1) / columns,
+		Count:       count,
+		ThumbWidth:  spriteThumbWidth,
+		ThumbHeight: thumbHeight,
+	}
+}
+
+// GenerateSprite ghép thumbnail mỗi sheet.IntervalSec giây thành 1 ảnh JPEG theo layout sheet.
+func GenerateSprite(ctx context.Context, videoPath, outPath string, sheet model.SpriteSheet) error {
+	filter := fmt.Sprintf("fps=1/%s,scale=%d:%d,tile=%dx%d",
+		formatSeconds(sheet.IntervalSec), sheet.ThumbWidth, sheet.ThumbHeight, sheet.Columns, sheet.Rows)
+	return runFFmpeg(ctx,
+		"-i", videoPath,
+		"-an",
+		"-vf", filter,
+		"-frames:v", "1",
+		"-q:v", "4",
+		"-y",
+		outPath,
+	)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/model"
+	"video-transcript/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// ErrTranscodeInProgress trả về khi video đang có job transcode chưa xong.
+var ErrTranscodeInProgress = errors.New("video is already being transcoded")
+
+// TranscodeService chạy job transcode HLS cho video và theo dõi trạng thái trên bảng videos.
+type TranscodeService interface {
+	// Start đánh dấu video transcode_status = pending và chạy job ở background.
+	// Transcript STT completed mới nhất của video (nếu có) được gắn vào master playlist làm phụ đề WebVTT.
+	Start(ctx context.Context, video *model.Video) error
+}
+
+type transcodeService struct {
+	videoRepo repository.VideoRepository
+	taskSvc   TaskService
+	pipeline  MediaPipeline
+}
+
+// NewTranscodeService creates a new TranscodeService.
+func NewTranscodeService(videoRepo repository.VideoRepository, taskSvc TaskService, pipeline MediaPipeline) TranscodeService {
+	return &transcodeService{videoRepo: videoRepo, taskSvc: taskSvc, pipeline: pipeline}
+}
+
+func (s *transcodeService) Start(ctx context.Context, video *model.Video) error {
+	if !config.SvcCfg.MediaPipelineEnabled {
+		return ErrMediaPipelineDisabled
+	}
+
+	claimed, err := s.videoRepo.ClaimTranscode(ctx, video.ID, hlsJobTimeout())
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrTranscodeInProgress
+	}
+
+	pending := model.TaskStatusPending
+	video.TranscodeStatus = &pending
+	video.TranscodeError = nil
+
+	go s.run(video)
+	return nil
+}
+
+func hlsJobTimeout() time.Duration {
+	return time.Duration(config.SvcCfg.HLSJobTimeoutMinute) * time.Minute
+}
+
+func (s *transcodeService) run(video *model.Video) {
+	runJob(hlsJobTimeout(), func(ctx context.Context) error {
+		if err := s.videoRepo.UpdateTranscodeStatus(ctx, video.ID, model.TaskStatusProcessing, nil, nil); err != nil {
+			zap.S().Errorw("update transcode status failed", "video_id", video.ID, "error", err)
+		}
+
+		hlsURL, err := s.pipeline.TranscodeHLS(ctx, video, s.subtitle(ctx, video))
+		if err != nil {
+			return err
+		}
+
+		if err := s.videoRepo.UpdateTranscodeStatus(ctx, video.ID, model.TaskStatusCompleted, &hlsURL, nil); err != nil {
+			zap.S().Errorw("update transcode status failed", "video_id", video.ID, "error", err)
+		}
+		return nil
+	}, func(ctx context.Context, err error) {
+		zap.S().Errorw("transcode hls failed", "video_id", video.ID, "error", err)
+		errorMessage := err.Error()
+		if updateErr := s.videoRepo.UpdateTranscodeStatus(ctx, video.ID, model.TaskStatusFailed, nil, &errorMessage); updateErr != nil {
+			zap.S().Errorw("update transcode status failed", "video_id", video.ID, "error", updateErr)
+		}
+	})
+}
+
+// subtitle dựng track phụ đề từ transcript STT mới nhất của video (audio TTS thì từ timing của task TTS);
+// nil nếu video chưa có transcript.
+func (s *transcodeService) subtitle(ctx context.Context, video *model.Video) *HLSSubtitle {
+	task, err := s.taskSvc.GetLatestCompletedByVideo(ctx, video.ID, model.TaskTypeSTT)
+	if err != nil && err.Error() == "task not found" {
+		task, err = s.taskSvc.GetLatestCompletedByVideo(ctx, video.ID, model.TaskTypeTTS)
+	}
+	if err != nil {
+		if err.Error() != "task not found" {
+			zap.S().Errorw("get transcript for subtitles failed", "video_id", video.ID, "error", err)
+		}
+		return nil
+	}
+	transcript, err := decodeTranscript(task)
+	if err != nil {
+		if !errors.Is(err, ErrEmptyTranscript) {
+			zap.S().Errorw("decode transcript for subtitles failed", "video_id", video.ID, "task_id", task.ID, "error", err)
+		}
+		return nil
+	}
+	cues := BuildCues(transcript)
+	if len(cues) == 0 {
+		return nil
+	}
+
+	language := transcript.DetectedLanguage
+	if language == "" && task.DetectedLanguage != nil {
+		language = *task.DetectedLanguage
+	}
+	return &HLSSubtitle{Name: "Transcript", Language: language, Cues: cues}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/helper"
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// STTJob là 1 yêu cầu chuyển video thành transcript với options đã resolve.
+type STTJob struct {
+	UserID     int64
+	Video      *model.Video
+	Options    *model.STTOptions
+	Vocabulary *model.Vocabulary // nil = không sửa transcript bằng vocabulary
+}
+
+// TranscriptionService điều phối job STT: media pipeline -> speech provider -> lưu transcript.
+type TranscriptionService interface {
+	// Start tạo task STT và chạy job ở background, trả về task vừa tạo.
+	// Video đã probe mà không có audio trả về model.ErrNoAudioStream, không tạo task.
+	Start(ctx context.Context, job *STTJob) (*model.Task, error)
+}
+
+type transcriptionService struct {
+	taskSvc  TaskService
+	pipeline MediaPipeline
+}
+
+// NewTranscriptionService creates a new TranscriptionService.
+func NewTranscriptionService(taskSvc TaskService, pipeline MediaPipeline) TranscriptionService {
+	return &transcriptionService{taskSvc: taskSvc, pipeline: pipeline}
+}
+
+func (s *transcriptionService) Start(ctx context.Context, job *STTJob) (*model.Task, error) {
+	// Video đã probe lúc upload: báo lỗi ngay (vd: ErrNoAudioStream) thay vì tạo task rồi fail.
+	if info := job.Video.MediaInfo(); info != nil {
+		if err := info.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	optionsJSON, err := json.Marshal(job.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &model.Task{
+		TaskType: model.TaskTypeSTT,
+		Status:   model.TaskStatusPending,
+		InputURL: &job.Video.LinkVideo,
+		UserID:   &job.UserID,
+		VideoID:  &job.Video.ID,
+		Options:  optionsJSON,
+	}
+	if err := s.taskSvc.Create(ctx, task); err != nil {
+		zap.S().Errorw("create task failed", "error", err)
+		return nil, err
+	}
+
+	go s.run(task, job)
+
+	return task, nil
+}
+
+// run chạy job STT; transcribe tự lưu transcript và đánh completed.
+func (s *transcriptionService) run(task *model.Task, job *STTJob) {
+	timeout := time.Duration(config.SvcCfg.MediaJobTimeoutMinute) * time.Minute
+	runTask(s.taskSvc, task, timeout, func(ctx context.Context) error {
+		return s.transcribe(ctx, task, job)
+	})
+}
+
+func (s *transcriptionService) transcribe(ctx context.Context, task *model.Task, job *STTJob) error {
+	opts := job.Options
+
+	// Probe trước khi gọi provider: file hỏng / không có audio thì dừng luôn, không tốn credit.
+	var duration float64
+	if ProbeEnabled() {
+		info, err := s.pipeline.ProbeVideo(ctx, job.Video)
+		if err != nil {
+			zap.S().Errorw("probe video failed", "task_id", task.ID, "video_id", job.Video.ID, "error", err)
+			return fmt.Errorf("probe media: %w", err)
+		}
+		duration = info.DurationSec
+		if err := s.taskSvc.UpdateDuration(ctx, task.ID, duration); err != nil {
+			zap.S().Errorw("update task duration failed", "id", task.ID, "error", err)
+		}
+	}
+
+	// Media pipeline: gửi audio mono 16 kHz thay vì cả file video cho provider.
+	audioURL := job.Video.LinkVideo
+	var audio *model.VideoArtifact
+	var speech *model.SpeechMap
+	var err error
+	if !config.SvcCfg.MediaPipelineEnabled && (opts.PreprocessProfile() != "" || model.BoolValue(opts.SpeechOnly)) {
+		zap.S().Warnw("media pipeline disabled, ignoring preprocess / speech_only options", "task_id", task.ID)
+	}
+	if config.SvcCfg.MediaPipelineEnabled {
+		audio, err = s.pipeline.PrepareAudio(ctx, job.Video)
+		if err != nil {
+			zap.S().Errorw("media pipeline failed", "task_id", task.ID, "video_id", job.Video.ID, "error", err)
+			return fmt.Errorf("media pipeline: %w", err)
+		}
+		// Profile tiền xử lý: gửi audio đã xử lý, task.audio_url trỏ tới đúng file đó.
+		if profile := opts.PreprocessProfile(); profile != "" {
+			audio, err = s.pipeline.PrepareProcessedAudio(ctx, job.Video, profile)
+			if err != nil {
+				zap.S().Errorw("preprocess audio failed", "task_id", task.ID, "video_id", job.Video.ID, "profile", profile, "error", err)
+				return fmt.Errorf("preprocess audio: %w", err)
+			}
+		}
+		// Chỉ gửi phần có tiếng nói: ghép các đoạn theo speech map, transcript được remap lại sau.
+		if model.BoolValue(opts.SpeechOnly) {
+			speech, err = s.pipeline.DetectSpeech(ctx, job.Video)
+			if err != nil {
+				zap.S().Errorw("detect speech failed", "task_id", task.ID, "video_id", job.Video.ID, "error", err)
+				return fmt.Errorf("detect speech: %w", err)
+			}
+			if len(speech.Segments) == 0 {
+				zap.S().Infow("no speech detected, skipping provider call", "task_id", task.ID, "video_id", job.Video.ID)
+				return s.saveTranscript(ctx, task.ID, &model.SimpleTranscript{})
+			}
+			audio, err = s.pipeline.PrepareSpeechAudio(ctx, job.Video, audio, speech)
+			if err != nil {
+				zap.S().Errorw("prepare speech audio failed", "task_id", task.ID, "video_id", job.Video.ID, "error", err)
+				return fmt.Errorf("prepare speech audio: %w", err)
+			}
+		}
+		audioURL = audio.URL
+		if err := s.taskSvc.UpdateAudioURL(ctx, task.ID, audioURL); err != nil {
+			zap.S().Errorw("update task audio url failed", "id", task.ID, "error", err)
+		}
+	}
+
+	var simpleTranscript *model.SimpleTranscript
+	if audio != nil && audio.DurationSec != nil {
+		duration = *audio.DurationSec
+	}
+	if audio != nil && shouldChunk(duration) {
+		simpleTranscript, err = s.transcribeChunked(ctx, task.ID, job, audio, duration)
+	} else {
+		simpleTranscript, err = s.transcribeURL(ctx, task.ID, audioURL, opts)
+	}
+	if err != nil {
+		return err
+	}
+	if speech != nil {
+		remapSpeechTranscript(simpleTranscript, speech)
+	}
+
+	if simpleTranscript.DetectedLanguage != "" {
+		if err := s.taskSvc.UpdateDetectedLanguage(ctx, task.ID, &simpleTranscript.DetectedLanguage, &simpleTranscript.LanguageConfidence); err != nil {
+			zap.S().Errorw("update task detected language failed", "id", task.ID, "error", err)
+		}
+	}
+
+	if job.Vocabulary != nil {
+		corrected := ApplyVocabulary(simpleTranscript, job.Vocabulary)
+		zap.S().Infow("vocabulary corrections applied", "task_id", task.ID, "vocabulary_id", job.Vocabulary.ID, "corrected", corrected)
+	}
+
+	return s.saveTranscript(ctx, task.ID, simpleTranscript)
+}
+
+// shouldChunk: file dài từ STT_CHUNK_MIN_MINUTES trở lên (và đủ dài để cắt ít nhất 2 chunk) thì cắt chunk.
+func shouldChunk(duration float64) bool {
+	cfg := config.SvcCfg
+	if cfg.STTChunkMinMinutes <= 0 || cfg.STTChunkSeconds <= 0 {
+		return false
+	}
+	return duration >= float64(cfg.STTChunkMinMinutes*60) && duration > float64(cfg.STTChunkSeconds)*1.5
+}
+
+// transcribeURL gọi provider cho 1 file audio (kèm lần chạy 2 nếu độ tin cậy ngôn ngữ thấp).
+func (s *transcriptionService) transcribeURL(ctx context.Context, taskID int64, audioURL string, opts *model.STTOptions) (*model.SimpleTranscript, error) {
+	res, provider, err := helper.Transcribe(ctx, audioURL, opts)
+	if provider != "" {
+		if err := s.taskSvc.UpdateProvider(ctx, taskID, provider); err != nil {
+			zap.S().Errorw("update task provider failed", "id", taskID, "error", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Độ tin cậy ngôn ngữ thấp (vd: nội dung trộn Việt/Anh): chạy lại lần 2 với ngôn ngữ đã nhận diện.
+	detectedLanguage, languageConfidence := model.DetectedLanguage(res)
+	if second, ok := opts.SecondPassOptions(detectedLanguage, languageConfidence); ok {
+		zap.S().Infow("low language confidence, running second pass",
+			"task_id", taskID,
+			"detected_language", detectedLanguage,
+			"language_confidence", languageConfidence,
+		)
+		secondRes, _, err := helper.Transcribe(ctx, audioURL, second)
+		if err != nil {
+			zap.S().Errorw("second pass failed, keeping first pass result", "task_id", taskID, "error", err)
+		} else {
+			res = secondRes
+		}
+	}
+
+	// Log Deepgram response structure for debugging
+	if res != nil && res.Results != nil {
+		zap.S().Infow("Deepgram response received",
+			"task_id", taskID,
+			"utterances_count", len(res.Results.Utterances),
+			"channels_count", len(res.Results.Channels),
+		)
+	}
+
+	simpleTranscript, err := model.ConvertDeepgramToSimple(res)
+	if err != nil {
+		zap.S().Errorw("convert deepgram to simple transcript failed",
+			"error", err,
+			"task_id", taskID,
+			"file_url", audioURL,
+		)
+		return nil, err
+	}
+
+	// Lần chạy 2 ép language nên provider không trả detected_language, giữ kết quả lần 1.
+	if simpleTranscript.DetectedLanguage == "" && detectedLanguage != "" {
+		simpleTranscript.DetectedLanguage = detectedLanguage
+		simpleTranscript.LanguageConfidence = languageConfidence
+	}
+	return simpleTranscript, nil
+}
+
+// transcribeChunked cắt audio dài thành chunk, transcribe song song (tối đa STT_CHUNK_WORKERS chunk cùng lúc)
+// rồi ghép lại theo thời gian. Chunk nào lỗi thì huỷ các chunk còn lại và fail cả task.
+func (s *transcriptionService) transcribeChunked(ctx context.Context, taskID int64, job *STTJob, audio *model.VideoArtifact, duration float64) (*model.SimpleTranscript, error) {
+	chunks, err := s.pipeline.SplitAudio(ctx, job.Video, audio, taskID, duration)
+	if err != nil {
+		zap.S().Errorw("split audio failed", "task_id", taskID, "video_id", job.Video.ID, "error", err)
+		return nil, fmt.Errorf("split audio: %w", err)
+	}
+	defer s.pipeline.RemoveChunks(context.WithoutCancel(ctx), chunks)
+
+	total := len(chunks)
+	s.updateProgress(ctx, taskID, 0, total)
+
+	workers := config.SvcCfg.STTChunkWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		done     int
+	)
+	results := make([]chunkTranscript, total)
+	sem := make(chan struct{}, workers)
+
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk AudioChunk) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			transcript, err := s.transcribeURL(ctx, taskID, chunk.URL, job.Options)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("chunk %d/%d: %w", chunk.Index+1, total, err)
+					cancel()
+				}
+				return
+			}
+			shiftTranscript(transcript, chunk.Start)
+			results[i] = chunkTranscript{chunk: chunk, transcript: transcript}
+			done++
+			s.updateProgress(ctx, taskID, done, total)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	// Job hết hạn / bị huỷ: các chunk chưa chạy thoát ra không ghi firstErr, không được lưu transcript thiếu.
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = fmt.Errorf("chunked transcription: %w", ctx.Err())
+	}
+	if firstErr != nil {
+		zap.S().Errorw("chunked transcription failed", "task_id", taskID, "error", firstErr)
+		return nil, firstErr
+	}
+
+	merged := mergeChunkTranscripts(results)
+	zap.S().Infow("chunk transcripts merged", "task_id", taskID, "chunks", total, "words", len(merged.Words))
+	return merged, nil
+}
+
+func (s *transcriptionService) updateProgress(ctx context.Context, taskID int64, current, total int) {
+	if err := s.taskSvc.UpdateProgress(ctx, taskID, current, total); err != nil {
+		zap.S().Errorw("update task progress failed", "id", taskID, "error", err)
+	}
+}
+
+// saveTranscript lưu transcript và đánh dấu task completed (kể cả khi transcript rỗng).
+func (s *transcriptionService) saveTranscript(ctx context.Context, taskID int64, transcript *model.SimpleTranscript) error {
+	hasTranscript := transcript.TranscriptText != "" || len(transcript.Words) > 0 || len(transcript.Utterances) > 0
+	if !hasTranscript {
+		zap.S().Warnw("No transcript data available from Deepgram, marking task as completed with null transcript",
+			"task_id", taskID,
+		)
+	}
+
+	var transcriptText *string
+	if transcript.TranscriptText != "" {
+		transcriptText = &transcript.TranscriptText
+	}
+
+	var transcriptJSON json.RawMessage
+	if hasTranscript {
+		jsonBytes, err := json.Marshal(transcript)
+		if err != nil {
+			zap.S().Errorw("marshal simple transcript failed", "error", err)
+			return err
+		}
+		transcriptJSON = jsonBytes
+	}
+
+	if err := s.taskSvc.UpdateTranscript(ctx, taskID, model.TaskStatusCompleted, transcriptText, transcriptJSON); err != nil {
+		zap.S().Errorw("update task transcript failed", "id", taskID, "error", err)
+		return err
+	}
+	return nil
+}
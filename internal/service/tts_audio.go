@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"video-transcript/internal/model"
+)
+
+// Audio các chunk TTS được decode về PCM s16le mono cùng sample rate rồi nối bằng Go (chèn khoảng lặng
+// chính xác tới từng sample), sau đó encode 1 lần ra định dạng đầu ra.
+const (
+	ttsPCMChannels       = 1
+	ttsPCMBytesPerSample = 2
+)
+
+// ttsCodecArgs là tham số encoder ffmpeg theo encoding của TTSOptions.
+var ttsCodecArgs = map[string][]string{
+	"mp3":  {"-c:a", "libmp3lame"},
+	"wav":  {"-c:a", "pcm_s16le", "-f", "wav"},
+	"opus": {"-c:a", "libopus", "-f", "ogg"},
+	"flac": {"-c:a", "flac", "-f", "flac"},
+	"aac":  {"-c:a", "aac", "-f", "adts"},
+}
+
+// decodeToPCM decode 1 file audio thành PCM s16le mono sampleRate Hz (raw, không header).
+func decodeToPCM(ctx context.Context, inPath, outPath string, sampleRate int) error {
+	return runFFmpeg(ctx,
+		"-i", inPath,
+		"-vn",
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"-ac", strconv.Itoa(ttsPCMChannels),
+		"-ar", strconv.Itoa(sampleRate),
+		"-y",
+		outPath,
+	)
+}
+
+// JoinTTSAudio nối audio các chunk (theo thứ tự) thành outPath theo định dạng của opts. silences có
+// len(chunkPaths)+1 phần tử: silences[0] giây im lặng trước chunk đầu, silences[i+1] sau chunk i.
+// Trả về khoảng thời gian của từng chunk trên audio đầu ra và độ dài audio đầu ra (giây).
+func JoinTTSAudio(ctx context.Context, workDir string, chunkPaths []string, silences []float64, opts *model.TTSOptions, outPath string) ([]model.SpeechSegment, float64, error) {
+	sampleRate := opts.OutputSampleRate()
+	joinedPath := filepath.Join(workDir, "joined.pcm")
+	joined, err := os.Create(joinedPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.Remove(joinedPath)
+
+	var totalBytes int64
+	bytesPerSec := float64(sampleRate * ttsPCMChannels * ttsPCMBytesPerSample)
+	spans := make([]model.SpeechSegment, len(chunkPaths))
+	writeSilence := func(i int) error {
+		if i >= len(silences) || silences[i] <= 0 {
+			return nil
+		}
+		silence := make([]byte, int(silences[i]*float64(sampleRate))*ttsPCMChannels*ttsPCMBytesPerSample)
+		n, err := joined.Write(silence)
+		totalBytes += int64(n)
+		return err
+	}
+
+	if err := writeSilence(0); err != nil {
+		joined.Close()
+		return nil, 0, err
+	}
+	for i, chunkPath := range chunkPaths {
+		pcmPath := chunkPath + ".pcm"
+		if err := decodeToPCM(ctx, chunkPath, pcmPath, sampleRate); err != nil {
+			joined.Close()
+			return nil, 0, fmt.Errorf("decode chunk %d: %w", i, err)
+		}
+		start := float64(totalBytes) / bytesPerSec
+		n, err := appendFile(joined, pcmPath)
+		os.Remove(pcmPath)
+		if err != nil {
+			joined.Close()
+			return nil, 0, err
+		}
+		totalBytes += n
+		spans[i] = model.SpeechSegment{Start: start, End: float64(totalBytes) / bytesPerSec}
+
+		if err := writeSilence(i + 1); err != nil {
+			joined.Close()
+			return nil, 0, err
+		}
+	}
+	if err := joined.Close(); err != nil {
+		return nil, 0, err
+	}
+
+	// Cắt khoảng lặng đầu / cuối tính trên PCM nên biết chính xác thời gian bị cắt; tempo co giãn đều
+	// cả file, pitch và loudness không đổi thời gian.
+	totalSamples := totalBytes / (ttsPCMChannels * ttsPCMBytesPerSample)
+	trim := pcmRange{Start: 0, End: totalSamples}
+	if opts.TrimSilence {
+		if trim, err = pcmSpeechRange(joinedPath, sampleRate); err != nil {
+			return nil, 0, fmt.Errorf("detect silence: %w", err)
+		}
+	}
+	offset := float64(trim.Start) / float64(sampleRate)
+	length := float64(trim.End-trim.Start) / float64(sampleRate)
+	tempo := opts.TempoFactor()
+	for i := range spans {
+		spans[i].Start = math.Min(math.Max(spans[i].Start-offset, 0), length) / tempo
+		spans[i].End = math.Min(math.Max(spans[i].End-offset, 0), length) / tempo
+	}
+
+	if err := encodePCM(ctx, joinedPath, outPath, opts, ttsPostFilter(opts, sampleRate, trim, totalSamples)); err != nil {
+		return nil, 0, err
+	}
+	return spans, length / tempo, nil
+}
+
+// encodePCM encode file PCM s16le mono ra định dạng / sample rate / bitrate của opts, qua chuỗi filter
+// hậu xử lý (rỗng = không xử lý).
+func encodePCM(ctx context.Context, pcmPath, outPath string, opts *model.TTSOptions, filter string) error {
+	sampleRate := strconv.Itoa(opts.OutputSampleRate())
+	args := []string{
+		"-f", "s16le",
+		"-ar", sampleRate,
+		"-ac", strconv.Itoa(ttsPCMChannels),
+		"-i", pcmPath,
+	}
+	if filter != "" {
+		args = append(args, "-af", filter)
+	}
+	args = append(args, "-ar", sampleRate)
+	args = append(args, ttsCodecArgs[opts.WithDefaults().Encoding]...)
+	if bitRate := opts.OutputBitRate(); bitRate > 0 {
+		args = append(args, "-b:a", strconv.Itoa(bitRate))
+	}
+	args = append(args, "-y", outPath)
+	return runFFmpeg(ctx, args...)
+}
+
+// appendFile ghi nối nội dung file path vào w.
+func appendFile(w io.Writer, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
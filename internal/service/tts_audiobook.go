@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// audiobookAACBitRate là bitrate AAC của file M4B (giọng đọc mono).
+const audiobookAACBitRate = "64k"
+
+// ErrNotAudiobook trả về khi task không phải task audiobook.
+var ErrNotAudiobook = errors.New("task is not an audiobook")
+
+// AudiobookJob là 1 yêu cầu tạo audiobook từ tài liệu đã tách chương.
+type AudiobookJob struct {
+	UserID   int64
+	Document *model.Document
+	Request  *model.AudiobookRequest
+	Lexicon  []*model.LexiconEntry // lexicon phát âm của user, StartAudiobook tự nạp nếu options không tắt
+}
+
+// Title là tên sách: tên trong request, không có thì lấy từ tài liệu.
+func (j *AudiobookJob) Title() string {
+	if title := strings.TrimSpace(j.Request.Title); title != "" {
+		return title
+	}
+	return j.Document.Title
+}
+
+// audiobookChapter là 1 chương đã chia chunk, sẵn sàng tổng hợp.
+type audiobookChapter struct {
+	Title  string
+	Chunks []ttsChunk
+	Lead   float64
+}
+
+func (s *ttsService) StartAudiobook(ctx context.Context, job *AudiobookJob) (*model.Task, error) {
+	if !config.SvcCfg.MediaPipelineEnabled {
+		return nil, ErrMediaPipelineDisabled
+	}
+	opts := job.Request.Options.WithDefaults()
+	opts.Encoding = "mp3"
+	job.Request.Options = opts
+	if !opts.SkipLexicon && job.Lexicon == nil {
+		lexicon, err := s.loadLexicon(ctx, job.UserID)
+		if err != nil {
+			return nil, err
+		}
+		job.Lexicon = lexicon
+	}
+
+	chapters := make([]string, 0, len(job.Document.Chapters))
+	for _, ch := range job.Document.Chapters {
+		chapters = append(chapters, ch.Title)
+	}
+	optionsJSON, err := json.Marshal(struct {
+		*model.AudiobookRequest
+		Format   string   `json:"format"`
+		Chapters []string `json:"chapters"`
+	}{job.Request, job.Document.Format, chapters})
+	if err != nil {
+		return nil, err
+	}
+
+	title := job.Title()
+	task := &model.Task{
+		TaskType:  model.TaskTypeAudiobook,
+		Status:    model.TaskStatusPending,
+		InputText: &title,
+		UserID:    &job.UserID,
+		Options:   optionsJSON,
+	}
+	if err := s.taskSvc.Create(ctx, task); err != nil {
+		zap.S().Errorw("create task failed", "error", err)
+		return nil, err
+	}
+
+	go s.runAudiobook(task, job)
+
+	return task, nil
+}
+
+// runAudiobook chạy job audiobook, output_url của task là file M4B.
+func (s *ttsService) runAudiobook(task *model.Task, job *AudiobookJob) {
+	timeout := time.Duration(config.SvcCfg.AudiobookJobTimeoutMinute) * time.Minute
+	runTask(s.taskSvc, task, timeout, func(ctx context.Context) error {
+		book, err := s.synthesizeAudiobook(ctx, task, job)
+		if err != nil {
+			return err
+		}
+		if err := s.taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusCompleted, &book.LinkVideo, book.DurationSec, nil); err != nil {
+			zap.S().Errorw("update task status failed", "id", task.ID, "error", err)
+		}
+		zap.S().Infow("audiobook generated", "task_id", task.ID, "chapters", len(job.Document.Chapters))
+		return nil
+	})
+}
+
+// synthesizeAudiobook tổng hợp lần lượt từng chương (các chunk trong chương chạy song song) thành MP3, lưu mỗi
+// chương 1 Video, rồi ghép các chương thành 1 file M4B có chapter marker. Progress của task đếm chunk của cả sách.
+func (s *ttsService) synthesizeAudiobook(ctx context.Context, task *model.Task, job *AudiobookJob) (*model.Video, error) {
+	chapters := planAudiobookChapters(job)
+	if len(chapters) == 0 {
+		return nil, errors.New("document has no text")
+	}
+	total := 0
+	for _, ch := range chapters {
+		total += len(ch.Chunks)
+	}
+
+	workDir, err := os.MkdirTemp(config.SvcCfg.MediaWorkDir, fmt.Sprintf("audiobook-%d-*", task.ID))
+	if err != nil {
+		return nil, fmt.Errorf("create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	progress := s.chunkProgress(ctx, task.ID, total)
+	keyPrefix := fmt.Sprintf("text-to-speech/%d/%d-audiobook", job.UserID, time.Now().UnixNano())
+	providers := make([]string, 0, len(chapters))
+	defer func() {
+		if provider := mostFrequent(providers); provider != "" {
+			if err := s.taskSvc.UpdateProvider(context.WithoutCancel(ctx), task.ID, provider); err != nil {
+				zap.S().Errorw("update task provider failed", "id", task.ID, "error", err)
+			}
+		}
+	}()
+
+	paths := make([]string, len(chapters))
+	markers := make([]audiobookMarker, len(chapters))
+	var start float64
+	for i, ch := range chapters {
+		paths[i] = filepath.Join(workDir, fmt.Sprintf("chapter-%03d.mp3", i+1))
+		video, provider, err := s.synthesizeChapter(ctx, task, job, workDir, i, ch, paths[i], keyPrefix, progress)
+		providers = append(providers, provider)
+		if err != nil {
+			return nil, fmt.Errorf("chapter %d (%s): %w", i+1, ch.Title, err)
+		}
+		markers[i] = audiobookMarker{Title: ch.Title, Start: start, End: start + *video.DurationSec}
+		start = markers[i].End
+	}
+
+	bookPath := filepath.Join(workDir, "audiobook.m4b")
+	if err := buildM4B(ctx, workDir, job.Title(), paths, markers, bookPath); err != nil {
+		return nil, fmt.Errorf("build m4b: %w", err)
+	}
+	return s.storeAudiobookFile(ctx, task, job, bookPath, audiobookFileName(0, job.Title(), ".m4b"), keyPrefix+"/audiobook.m4b", job.Title())
+}
+
+// planAudiobookChapters chia chunk từng chương (tên chương được đọc như 1 đoạn văn riêng ở đầu chương),
+// bỏ qua chương không có gì để đọc.
+func planAudiobookChapters(job *AudiobookJob) []audiobookChapter {
+	opts := job.Request.Options
+	var lexicon *lexiconMatcher
+	if !opts.SkipLexicon {
+		lexicon = newLexiconMatcher(job.Lexicon)
+	}
+	chapters := make([]audiobookChapter, 0, len(job.Document.Chapters))
+	for _, ch := range job.Document.Chapters {
+		text := ch.Text
+		if !job.Request.SkipTitles {
+			text = ch.Title + "\n\n" + text
+		}
+		chunks, lead := planTTSChunks(plainTTSPieces(text, opts.Voice, lexicon), config.SvcCfg.TTSChunkChars, false)
+		if len(chunks) == 0 {
+			continue
+		}
+		chapters = append(chapters, audiobookChapter{Title: ch.Title, Chunks: chunks, Lead: lead})
+	}
+	return chapters
+}
+
+// synthesizeChapter tổng hợp 1 chương thành outPath (MP3, cuối chương có khoảng lặng chapter gap),
+// upload và lưu thành Video của task. Trả về Video và provider phục vụ nhiều chunk nhất.
+func (s *ttsService) synthesizeChapter(ctx context.Context, task *model.Task, job *AudiobookJob, workDir string, index int, ch audiobookChapter, outPath, keyPrefix string, progress func()) (*model.Video, string, error) {
+	opts := job.Request.Options
+	chunkDir := filepath.Join(workDir, fmt.Sprintf("chapter-%03d", index+1))
+	if err := os.Mkdir(chunkDir, 0o700); err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(chunkDir)
+
+	chunkPaths, provider, err := speakChunks(ctx, chunkDir, ch.Chunks, opts, progress)
+	if err != nil {
+		return nil, provider, err
+	}
+	silences := ttsSilences(ch.Chunks, ch.Lead, opts)
+	last := len(silences) - 1
+	silences[last] = max(silences[last], job.Request.ChapterGap())
+	if _, _, err := JoinTTSAudio(ctx, chunkDir, chunkPaths, silences, opts, outPath); err != nil {
+		return nil, provider, fmt.Errorf("join tts audio: %w", err)
+	}
+
+	key := fmt.Sprintf("%s/chapter-%03d.mp3", keyPrefix, index+1)
+	video, err := s.storeAudiobookFile(ctx, task, job, outPath, audiobookFileName(index+1, ch.Title, ".mp3"), key, ch.Title)
+	return video, provider, err
+}
+
+// storeAudiobookFile probe + upload 1 file của audiobook và lưu thành Video có render_task_id = task.
+func (s *ttsService) storeAudiobookFile(ctx context.Context, task *model.Task, job *AudiobookJob, filePath, name, key, description string) (*model.Video, error) {
+	info, err := ProbeMedia(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("probe %s: %w", name, err)
+	}
+	url, err := uploadFile(ctx, filePath, key, contentTypeByExt(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("upload %s: %w", name, err)
+	}
+
+	video := &model.Video{
+		UserID:       job.UserID,
+		LinkVideo:    url,
+		NameFile:     name,
+		Description:  &description,
+		RenderTaskID: &task.ID,
+	}
+	video.SetMediaInfo(info)
+	if err := s.videoSvc.Create(ctx, video); err != nil {
+		zap.S().Errorw("create video failed", "user_id", job.UserID, "file_url", url, "error", err)
+		return nil, err
+	}
+	return video, nil
+}
+
+func (s *ttsService) GetAudiobook(ctx context.Context, taskID int64) (*model.Audiobook, error) {
+	task, err := s.taskSvc.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.TaskType != model.TaskTypeAudiobook {
+		return nil, ErrNotAudiobook
+	}
+	videos, err := s.videoSvc.ListByRenderTask(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	book := &model.Audiobook{Task: task, Chapters: make([]*model.Video, 0, len(videos))}
+	for _, v := range videos {
+		if path.Ext(v.NameFile) == ".m4b" {
+			book.Book = v
+			continue
+		}
+		book.Chapters = append(book.Chapters, v)
+	}
+	return book, nil
+}
+
+// audiobookMarker là vị trí 1 chương trên file M4B (giây).
+type audiobookMarker struct {
+	Title      string
+	Start, End float64
+}
+
+// buildM4B ghép các chương MP3 (concat demuxer) thành 1 file M4B (AAC) với chapter marker lấy từ ffmetadata.
+func buildM4B(ctx context.Context, workDir, title string, chapterPaths []string, markers []audiobookMarker, outPath string) error {
+	var list strings.Builder
+	for _, p := range chapterPaths {
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(p, "'", `'\''`))
+	}
+	listPath := filepath.Join(workDir, "chapters.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0o600); err != nil {
+		return err
+	}
+	metaPath := filepath.Join(workDir, "chapters.ffmeta")
+	if err := os.WriteFile(metaPath, []byte(formatFFMetadata(title, markers)), 0o600); err != nil {
+		return err
+	}
+
+	return runFFmpeg(ctx,
+		"-f", "concat",
+		"-safe", "0",
+		"-i", listPath,
+		"-i", metaPath,
+		"-map", "0:a",
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+		"-c:a", "aac",
+		"-b:a", audiobookAACBitRate,
+		"-movflags", "+faststart",
+		"-f", "mp4",
+		"-y",
+		outPath,
+	)
+}
+
+// formatFFMetadata tạo file FFMETADATA1: tên sách + mỗi chương 1 mục [CHAPTER] tính bằng ms.
+func formatFFMetadata(title string, markers []audiobookMarker) string {
+	var sb strings.Builder
+	sb.WriteString(";FFMETADATA1\n")
+	fmt.Fprintf(&sb, "title=%s\n", escapeFFMetadata(title))
+	sb.WriteString("genre=Audiobook\n")
+	for _, m := range markers {
+		sb.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(&sb, "START=%d\n", int64(math.Round(m.Start*1000)))
+		fmt.Fprintf(&sb, "END=%d\n", int64(math.Round(m.End*1000)))
+		fmt.Fprintf(&sb, "title=%s\n", escapeFFMetadata(m.Title))
+	}
+	return sb.String()
+}
+
+// escapeFFMetadata escape các ký tự đặc biệt của ffmetadata ('=', ';', '#', '\' và xuống dòng).
+func escapeFFMetadata(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '=', ';', '#', '\\', '\n':
+			sb.WriteByte('\\')
+		case '\r':
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// audiobookFileName đặt tên file theo số thứ tự chương (0 = cả sách) và tên, bỏ các ký tự không dùng được trong tên file.
+func audiobookFileName(index int, title, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, joinWords(title))
+	if runes := []rune(name); len(runes) > 100 {
+		name = strings.TrimSpace(string(runes[:100]))
+	}
+	if name == "" {
+		name = "audiobook"
+	}
+	if index > 0 {
+		name = fmt.Sprintf("%02d - %s", index, name)
+	}
+	return name + ext
+}
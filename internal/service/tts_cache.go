@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ttsCacheProvider là giá trị provider ghi lên task khi audio lấy từ cache (không gọi speech provider).
+const ttsCacheProvider = "cache"
+
+// ttsCacheScope trả về phạm vi cache của job: theo options, rỗng thì theo TTS_CACHE_DEFAULT_SCOPE.
+// Script hội thoại và job cần timing không cache vì còn phải dựng transcript / phụ đề theo thời gian trên audio.
+func ttsCacheScope(job *TTSJob) string {
+	if !config.SvcCfg.TTSCacheEnabled || job.Dialogue != nil || job.Options.Timings != "" {
+		return model.TTSCacheScopeNone
+	}
+	scope := job.Options.Cache
+	if scope == "" {
+		scope = config.SvcCfg.TTSCacheDefaultScope
+	}
+	if scope != model.TTSCacheScopeUser && scope != model.TTSCacheScopeShared {
+		return model.TTSCacheScopeNone
+	}
+	return scope
+}
+
+// ttsCacheKey băm đúng nội dung sẽ gửi provider: text từng chunk (đã áp markup / lexicon, gộp khoảng trắng,
+// chuẩn hoá Unicode NFC để chữ có dấu dựng sẵn / tổ hợp ra cùng key), voice từng chunk, các khoảng lặng và
+// định dạng đầu ra, cùng tham số hậu xử lý nếu có. Scope user thì key gồm cả user ID.
+func ttsCacheKey(scope string, userID int64, chunks []ttsChunk, silences []float64, opts *model.TTSOptions) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v1\x00%s\x00", scope)
+	if scope == model.TTSCacheScopeUser {
+		fmt.Fprintf(h, "%d\x00", userID)
+	}
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00%d\x00", opts.Voice, opts.Encoding, opts.OutputSampleRate(), opts.OutputBitRate())
+	fmt.Fprintf(h, "%.3f\x00", silences[0])
+	for i, chunk := range chunks {
+		io.WriteString(h, chunk.Voice+"\x00")
+		io.WriteString(h, norm.NFC.String(joinWords(chunk.Text))+"\x00")
+		fmt.Fprintf(h, "%.3f\x00", silences[i+1])
+	}
+	// Chỉ thêm vào hash khi có hậu xử lý để key của audio không xử lý giữ nguyên như trước.
+	if opts.HasPostProcess() {
+		fmt.Fprintf(h, "post\x00%.3f\x00%.3f\x00%s\x00%t\x00", opts.TempoFactor(), opts.Pitch(), opts.Loudness, opts.TrimSilence)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ttsCacheObjectKey là key R2 của audio được cache: theo hash nội dung nên request trùng ghi đè cùng 1 object.
+func ttsCacheObjectKey(job *TTSJob) string {
+	if job.cacheScope == model.TTSCacheScopeShared {
+		return fmt.Sprintf("text-to-speech/cache/%s%s", job.cacheKey, job.Options.Extension())
+	}
+	return fmt.Sprintf("text-to-speech/%d/cache/%s%s", job.UserID, job.cacheKey, job.Options.Extension())
+}
+
+// lookupCache trả về audio đã cache của job, nil nếu chưa có (lỗi DB coi như miss).
+func (s *ttsService) lookupCache(ctx context.Context, job *TTSJob) *model.TTSCacheEntry {
+	entry, err := s.cacheRepo.GetByKey(ctx, job.cacheKey)
+	if err != nil {
+		if err.Error() != "tts cache entry not found" {
+			zap.S().Errorw("lookup tts cache failed", "cache_key", job.cacheKey, "error", err)
+		}
+		return nil
+	}
+	return entry
+}
+
+// completeFromCache hoàn tất task ngay bằng audio đã cache: tạo Video trỏ tới object có sẵn, không gọi provider.
+func (s *ttsService) completeFromCache(ctx context.Context, task *model.Task, job *TTSJob, entry *model.TTSCacheEntry) error {
+	video := &model.Video{
+		UserID:      job.UserID,
+		LinkVideo:   entry.URL,
+		NameFile:    job.Options.FileName(),
+		Description: &job.Text,
+		DurationSec: entry.DurationSec,
+	}
+	if err := s.videoSvc.Create(ctx, video); err != nil {
+		return err
+	}
+	if err := s.taskSvc.UpdateVideoID(ctx, task.ID, video.ID); err != nil {
+		return err
+	}
+	if err := s.taskSvc.UpdateProvider(ctx, task.ID, ttsCacheProvider); err != nil {
+		return err
+	}
+	if err := s.taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusCompleted, &entry.URL, entry.DurationSec, nil); err != nil {
+		return err
+	}
+	if err := s.cacheRepo.MarkHit(ctx, entry.ID); err != nil {
+		zap.S().Errorw("mark tts cache hit failed", "id", entry.ID, "error", err)
+	}
+
+	provider := ttsCacheProvider
+	task.Status = model.TaskStatusCompleted
+	task.OutputURL = &entry.URL
+	task.DurationSec = entry.DurationSec
+	task.Provider = &provider
+	task.VideoID = &video.ID
+	return nil
+}
+
+// storeCache ghi audio vừa tổng hợp vào cache (lỗi chỉ log, audio đã tạo vẫn dùng được).
+func (s *ttsService) storeCache(ctx context.Context, job *TTSJob, result *ttsResult) {
+	entry := &model.TTSCacheEntry{
+		CacheKey:    job.cacheKey,
+		Scope:       job.cacheScope,
+		UserID:      job.UserID,
+		URL:         result.URL,
+		ContentType: job.Options.ContentType(),
+		DurationSec: result.Duration,
+	}
+	if result.Provider != "" {
+		entry.Provider = &result.Provider
+	}
+	if err := s.cacheRepo.Upsert(ctx, entry); err != nil {
+		zap.S().Errorw("store tts cache failed", "cache_key", job.cacheKey, "error", err)
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ttsChunk là 1 đoạn text gửi provider trong 1 request speak.
+type ttsChunk struct {
+	Index        int
+	Text         string
+	Voice        string
+	ParagraphEnd bool     // chunk kết thúc 1 đoạn văn: chèn khoảng lặng dài hơn phía sau
+	PauseAfter   *float64 // khoảng lặng tường minh (<break>) ngay sau chunk, thay cho khoảng lặng mặc định
+	Line         int      // câu thoại chứa chunk (script hội thoại)
+}
+
+var paragraphSeparator = regexp.MustCompile(`\n\s*\n`)
+
+// planTTSChunks gom các piece thành các chunk không quá maxChars ký tự: text trong 1 đoạn văn được gom
+// theo câu cho tới khi đầy, câu dài quá giới hạn thì cắt tiếp ở dấu phẩy / khoảng trắng. Chunk không bao
+// giờ vượt qua ranh giới đoạn văn, <break>, chỗ đổi voice hay câu thoại. perSentence: mỗi câu 1 chunk (để có
+// thời gian từng câu trên audio đã nối). Trả về thêm khoảng lặng trước chunk đầu (khi text mở đầu bằng <break>).
+func planTTSChunks(pieces []ttsPiece, maxChars int, perSentence bool) ([]ttsChunk, float64) {
+	var (
+		chunks  []ttsChunk
+		lead    float64
+		current strings.Builder
+		voice   string
+		line    int
+	)
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, ttsChunk{Index: len(chunks), Text: current.String(), Voice: voice, Line: line})
+			current.Reset()
+		}
+	}
+
+	for _, piece := range pieces {
+		switch {
+		case piece.Pause != nil:
+			flush()
+			if len(chunks) == 0 {
+				lead += *piece.Pause
+				continue
+			}
+			last := &chunks[len(chunks)-1]
+			pause := *piece.Pause
+			if last.PauseAfter != nil {
+				pause += *last.PauseAfter
+			}
+			last.PauseAfter = &pause
+
+		case piece.Paragraph:
+			flush()
+			if n := len(chunks); n > 0 {
+				chunks[n-1].ParagraphEnd = true
+			}
+
+		default:
+			if piece.Voice != voice || piece.Line != line {
+				flush()
+				voice, line = piece.Voice, piece.Line
+			}
+			text := strings.Join(strings.Fields(piece.Text), " ")
+			for _, sentence := range splitSentences(text) {
+				for _, part := range splitLongText(sentence, maxChars) {
+					if current.Len() > 0 && (perSentence || utf8.RuneCountInString(current.String())+1+utf8.RuneCountInString(part) > maxChars) {
+						flush()
+					}
+					if current.Len() > 0 {
+						current.WriteByte(' ')
+					}
+					current.WriteString(part)
+				}
+			}
+		}
+	}
+	flush()
+	return chunks, lead
+}
+
+// splitSentences tách 1 đoạn văn (đã gộp khoảng trắng) thành các câu: kết thúc bằng . ! ? … (kèm dấu
+// đóng ngoặc / nháy phía sau) và theo sau là khoảng trắng.
+func splitSentences(paragraph string) []string {
+	var sentences []string
+	runes := []rune(paragraph)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if !isSentenceEnd(runes[i]) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && (isSentenceEnd(runes[j]) || strings.ContainsRune(`"'”’)]»`, runes[j])) {
+			j++
+		}
+		if j < len(runes) && !unicode.IsSpace(runes[j]) {
+			i = j - 1
+			continue
+		}
+		if s := strings.TrimSpace(string(runes[start:j])); s != "" {
+			sentences = append(sentences, s)
+		}
+		start, i = j, j
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
+
+func isSentenceEnd(r rune) bool {
+	return strings.ContainsRune(".!?…。！？", r)
+}
+
+// splitLongText cắt câu dài hơn maxChars: ưu tiên sau dấu , ; : gần giới hạn nhất, rồi tới khoảng trắng,
+// cuối cùng cắt cứng theo số ký tự.
+func splitLongText(sentence string, maxChars int) []string {
+	var pieces []string
+	runes := []rune(sentence)
+	for len(runes) > maxChars {
+		cut := -1
+		for i := maxChars; i > maxChars/2; i-- {
+			if runes[i] == ' ' && strings.ContainsRune(",;:", runes[i-1]) {
+				cut = i
+				break
+			}
+		}
+		if cut < 0 {
+			for i := maxChars; i > 0; i-- {
+				if runes[i] == ' ' {
+					cut = i
+					break
+				}
+			}
+		}
+		if cut <= 0 {
+			cut = maxChars
+		}
+		pieces = append(pieces, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+	if len(runes) > 0 {
+		pieces = append(pieces, string(runes))
+	}
+	return pieces
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitLongText(t *testing.T) {
+	tests := []struct {
+		name     string
+		sentence string
+		maxChars int
+		want     []string
+	}{
+		{"shorter than limit", "abc", 5, []string{"abc"}},
+		{"exactly at limit", "abcde", 5, []string{"abcde"}},
+		{"space right at limit", "abcde f", 5, []string{"abcde", "f"}},
+		{"cut at last space", "aaa bbb ccc", 5, []string{"aaa", "bbb", "ccc"}},
+		{"prefer clause boundary", "one two, three four", 15, []string{"one two,", "three four"}},
+		{"clause boundary too early", "a, bbbbbbbb cccc", 12, []string{"a, bbbbbbbb", "cccc"}},
+		{"hard cut without spaces", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"counts runes not bytes", "ááááá ááá", 5, []string{"ááááá", "ááá"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitLongText(tt.sentence, tt.maxChars)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitLongText(%q, %d) = %q, want %q", tt.sentence, tt.maxChars, got, tt.want)
+			}
+			for _, part := range got {
+				if utf8.RuneCountInString(part) > tt.maxChars {
+					t.Errorf("part %q is longer than %d", part, tt.maxChars)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"video-transcript/internal/model"
+)
+
+// Markup TTS (bật bằng options.markup) là 1 tập con nhỏ của SSML:
+//
+//	<speak>...</speak>                          (tuỳ chọn, chỉ ở ngoài cùng)
+//	<break time="500ms"/>                        chèn khoảng lặng (ms hoặc s, tối đa 10s)
+//	<say-as interpret-as="characters">API</say-as>
+//	<say-as interpret-as="date" format="dmy">05/03/2024</say-as>
+//	<sub alias="World Wide Web">WWW</sub>        đọc alias thay cho nội dung
+//	<voice name="aura-2-apollo-en">...</voice>  đổi voice cho đoạn bên trong (lồng nhau được)
+//
+// Ký tự đặc biệt viết dạng &lt; &gt; &amp; &quot; &apos;. Markup được biên dịch thành các ttsPiece:
+// text (đã thay thế) theo voice, khoảng lặng và ranh giới đoạn văn.
+
+// maxTTSBreakSec là độ dài tối đa của 1 <break>.
+const maxTTSBreakSec = 10.0
+
+// TTSMarkupError là lỗi markup TTS kèm vị trí (dòng / cột, tính từ 1) trong text gốc.
+type TTSMarkupError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *TTSMarkupError) Error() string {
+	return fmt.Sprintf("markup error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ttsPiece là 1 phần của text TTS sau khi biên dịch: text cùng voice, khoảng lặng hoặc ranh giới đoạn văn.
+type ttsPiece struct {
+	Text      string
+	Voice     string
+	Pause     *float64 // != nil: piece là khoảng lặng <break> (giây)
+	Paragraph bool     // piece đánh dấu hết 1 đoạn văn
+	Line      int      // câu thoại chứa piece (script hội thoại), text thường luôn là 0
+}
+
+// ttsMarkupTag mô tả 1 tag được hỗ trợ.
+type ttsMarkupTag struct {
+	Attrs    []string // attribute được phép
+	Required []string // attribute bắt buộc
+	Empty    bool     // không có nội dung (vd <break/>)
+	TextOnly bool     // nội dung chỉ là text, không lồng tag
+	RootOnly bool     // chỉ dùng ở ngoài cùng
+}
+
+var ttsMarkupTags = map[string]ttsMarkupTag{
+	"speak":  {RootOnly: true},
+	"break":  {Attrs: []string{"time"}, Required: []string{"time"}, Empty: true},
+	"say-as": {Attrs: []string{"interpret-as", "format"}, Required: []string{"interpret-as"}, TextOnly: true},
+	"sub":    {Attrs: []string{"alias"}, Required: []string{"alias"}, TextOnly: true},
+	"voice":  {Attrs: []string{"name"}, Required: []string{"name"}},
+}
+
+var ttsMarkupEntities = map[string]string{"lt": "<", "gt": ">", "amp": "&", "quot": `"`, "apos": "'"}
+
+var breakTimePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(ms|s)$`)
+
+// markupNode là 1 node trong cây markup: text (Tag rỗng) hoặc element.
+type markupNode struct {
+	Tag      string
+	Text     string
+	Attrs    map[string]string
+	Children []*markupNode
+	Line     int
+	Column   int
+}
+
+// compileTTSMarkup parse và biên dịch markup thành các piece, voice mặc định là defaultVoice.
+// Lexicon chỉ áp dụng cho text thường, không áp dụng cho alias của <sub> và kết quả <say-as>.
+func compileTTSMarkup(text, defaultVoice string, lexicon *lexiconMatcher) ([]ttsPiece, error) {
+	root, err := parseTTSMarkup(text)
+	if err != nil {
+		return nil, err
+	}
+	b := &ttsPieceBuilder{lexicon: lexicon}
+	if err := b.compile(root, defaultVoice); err != nil {
+		return nil, err
+	}
+	return b.pieces, nil
+}
+
+// plainTTSPieces áp lexicon rồi chia text thường (không markup) thành các piece theo đoạn văn.
+func plainTTSPieces(text, voice string, lexicon *lexiconMatcher) []ttsPiece {
+	b := &ttsPieceBuilder{}
+	b.text(lexicon.Apply(text, voiceLanguage(voice)), voice)
+	return b.pieces
+}
+
+// ---- parser ----
+
+type markupParser struct {
+	src    []rune
+	pos    int
+	line   int
+	column int
+}
+
+func parseTTSMarkup(text string) (*markupNode, error) {
+	p := &markupParser{src: []rune(text), line: 1, column: 1}
+	root := &markupNode{Line: 1, Column: 1}
+	if _, err := p.parseChildren(root, true); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func (p *markupParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *markupParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *markupParser) next() rune {
+	r := p.src[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+		p.column = 1
+	} else {
+		p.column++
+	}
+	return r
+}
+
+func (p *markupParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(p.src[p.pos:min(len(p.src), p.pos+len(s))]), s)
+}
+
+func (p *markupParser) skipSpaces() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.next()
+	}
+}
+
+func markupErrorf(line, column int, format string, args ...any) error {
+	return &TTSMarkupError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+// parseChildren đọc nội dung của parent tới thẻ đóng tương ứng (hoặc hết text nếu là root).
+func (p *markupParser) parseChildren(parent *markupNode, isRoot bool) (bool, error) {
+	var text strings.Builder
+	textLine, textColumn := p.line, p.column
+	flush := func() {
+		if text.Len() > 0 {
+			parent.Children = append(parent.Children, &markupNode{Text: text.String(), Line: textLine, Column: textColumn})
+			text.Reset()
+		}
+	}
+
+	for !p.eof() {
+		if text.Len() == 0 {
+			textLine, textColumn = p.line, p.column
+		}
+		switch {
+		case p.hasPrefix("<!--"):
+			line, column := p.line, p.column
+			for !p.eof() && !p.hasPrefix("-->") {
+				p.next()
+			}
+			if p.eof() {
+				return false, markupErrorf(line, column, "comment is never closed")
+			}
+			for range len("-->") {
+				p.next()
+			}
+
+		case p.hasPrefix("</"):
+			flush()
+			line, column := p.line, p.column
+			p.next()
+			p.next()
+			name := p.readName()
+			p.skipSpaces()
+			if p.peek() != '>' {
+				return false, markupErrorf(p.line, p.column, "expected '>' to close </%s>", name)
+			}
+			p.next()
+			if isRoot {
+				return false, markupErrorf(line, column, "unexpected closing tag </%s>", name)
+			}
+			if name != parent.Tag {
+				return false, markupErrorf(line, column, "closing tag </%s> does not match <%s> opened at line %d, column %d", name, parent.Tag, parent.Line, parent.Column)
+			}
+			return true, nil
+
+		case p.peek() == '<':
+			flush()
+			node, selfClosing, err := p.parseStartTag()
+			if err != nil {
+				return false, err
+			}
+			spec := ttsMarkupTags[node.Tag]
+			if spec.RootOnly && (!isRoot || len(parent.Children) > 0 && !isBlankNodes(parent.Children)) {
+				return false, markupErrorf(node.Line, node.Column, "<%s> must wrap the whole text", node.Tag)
+			}
+			if !selfClosing {
+				closed, err := p.parseChildren(node, false)
+				if err != nil {
+					return false, err
+				}
+				if !closed {
+					return false, markupErrorf(node.Line, node.Column, "<%s> is never closed", node.Tag)
+				}
+			}
+			if err := validateMarkupContent(node, spec); err != nil {
+				return false, err
+			}
+			parent.Children = append(parent.Children, node)
+
+		case p.peek() == '&':
+			text.WriteString(p.readEntity())
+
+		default:
+			text.WriteRune(p.next())
+		}
+	}
+	flush()
+	return false, nil
+}
+
+// parseStartTag đọc `<name attr="value" ...>` hoặc `<name .../>`, kiểm tra tag và attribute.
+func (p *markupParser) parseStartTag() (*markupNode, bool, error) {
+	node := &markupNode{Attrs: map[string]string{}, Line: p.line, Column: p.column}
+	p.next() // '<'
+	node.Tag = p.readName()
+	if node.Tag == "" {
+		return nil, false, markupErrorf(node.Line, node.Column, "'<' must start a tag, write &lt; for a literal '<'")
+	}
+	spec, ok := ttsMarkupTags[node.Tag]
+	if !ok {
+		return nil, false, markupErrorf(node.Line, node.Column, "unsupported tag <%s> (supported: break, say-as, sub, voice, speak)", node.Tag)
+	}
+
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return nil, false, markupErrorf(node.Line, node.Column, "<%s> tag is never closed with '>'", node.Tag)
+		}
+		switch {
+		case p.peek() == '>':
+			p.next()
+			return node, false, validateMarkupAttrs(node, spec)
+		case p.hasPrefix("/>"):
+			p.next()
+			p.next()
+			return node, true, validateMarkupAttrs(node, spec)
+		}
+
+		line, column := p.line, p.column
+		name := p.readName()
+		if name == "" {
+			return nil, false, markupErrorf(line, column, "unexpected %q in <%s> tag", p.peek(), node.Tag)
+		}
+		if !slices.Contains(spec.Attrs, name) {
+			return nil, false, markupErrorf(line, column, "unsupported attribute %q on <%s>", name, node.Tag)
+		}
+		if _, dup := node.Attrs[name]; dup {
+			return nil, false, markupErrorf(line, column, "duplicate attribute %q on <%s>", name, node.Tag)
+		}
+		p.skipSpaces()
+		if p.peek() != '=' {
+			return nil, false, markupErrorf(p.line, p.column, "expected '=' after attribute %q", name)
+		}
+		p.next()
+		p.skipSpaces()
+		quote := p.peek()
+		if quote != '"' && quote != '\'' {
+			return nil, false, markupErrorf(p.line, p.column, "value of attribute %q must be quoted", name)
+		}
+		p.next()
+		var value strings.Builder
+		for !p.eof() && p.peek() != quote {
+			if p.peek() == '&' {
+				value.WriteString(p.readEntity())
+				continue
+			}
+			value.WriteRune(p.next())
+		}
+		if p.eof() {
+			return nil, false, markupErrorf(line, column, "value of attribute %q is never closed", name)
+		}
+		p.next()
+		node.Attrs[name] = value.String()
+	}
+}
+
+func (p *markupParser) readName() string {
+	start := p.pos
+	for !p.eof() {
+		r := p.peek()
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			break
+		}
+		p.next()
+	}
+	return strings.ToLower(string(p.src[start:p.pos]))
+}
+
+// readEntity đọc &name; đã biết, '&' không thuộc entity nào được giữ nguyên (vd "AT&T").
+func (p *markupParser) readEntity() string {
+	for name, value := range ttsMarkupEntities {
+		if p.hasPrefix("&" + name + ";") {
+			for range len(name) + 2 {
+				p.next()
+			}
+			return value
+		}
+	}
+	p.next()
+	return "&"
+}
+
+func validateMarkupAttrs(node *markupNode, spec ttsMarkupTag) error {
+	for _, name := range spec.Required {
+		if strings.TrimSpace(node.Attrs[name]) == "" {
+			return markupErrorf(node.Line, node.Column, "<%s> requires attribute %q", node.Tag, name)
+		}
+	}
+	switch node.Tag {
+	case "break":
+		if _, err := parseBreakTime(node.Attrs["time"]); err != nil {
+			return markupErrorf(node.Line, node.Column, "%v", err)
+		}
+	case "say-as":
+		if _, ok := sayAsInterpreters[node.Attrs["interpret-as"]]; !ok {
+			return markupErrorf(node.Line, node.Column, "unsupported interpret-as %q (supported: %s)", node.Attrs["interpret-as"], strings.Join(sayAsNames(), ", "))
+		}
+		if format, ok := node.Attrs["format"]; ok {
+			if node.Attrs["interpret-as"] != "date" {
+				return markupErrorf(node.Line, node.Column, "format is only supported with interpret-as=\"date\"")
+			}
+			if !slices.Contains(dateFormats, format) {
+				return markupErrorf(node.Line, node.Column, "unsupported date format %q (supported: %s)", format, strings.Join(dateFormats, ", "))
+			}
+		}
+	case "voice":
+		if _, ok := model.LookupTTSVoice(node.Attrs["name"]); !ok {
+			return markupErrorf(node.Line, node.Column, "unsupported voice %q", node.Attrs["name"])
+		}
+	}
+	return nil
+}
+
+func validateMarkupContent(node *markupNode, spec ttsMarkupTag) error {
+	for _, child := range node.Children {
+		if spec.Empty && (child.Tag != "" || strings.TrimSpace(child.Text) != "") {
+			return markupErrorf(child.Line, child.Column, "<%s> must be empty", node.Tag)
+		}
+		if spec.TextOnly && child.Tag != "" {
+			return markupErrorf(child.Line, child.Column, "<%s> cannot be nested inside <%s>", child.Tag, node.Tag)
+		}
+	}
+	return nil
+}
+
+func isBlankNodes(nodes []*markupNode) bool {
+	for _, n := range nodes {
+		if n.Tag != "" || strings.TrimSpace(n.Text) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseBreakTime đổi "500ms" / "1.5s" thành giây.
+func parseBreakTime(value string) (float64, error) {
+	m := breakTimePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, fmt.Errorf("invalid break time %q (use e.g. \"500ms\" or \"1.5s\")", value)
+	}
+	sec, _ := strconv.ParseFloat(m[1], 64)
+	if m[2] == "ms" {
+		sec /= 1000
+	}
+	if sec > maxTTSBreakSec {
+		return 0, fmt.Errorf("break time %q is longer than %gs", value, maxTTSBreakSec)
+	}
+	return sec, nil
+}
+
+// ---- compiler ----
+
+// ttsPieceBuilder gom text liên tiếp cùng voice vào 1 piece, tách đoạn văn ở dòng trống.
+type ttsPieceBuilder struct {
+	pieces  []ttsPiece
+	lexicon *lexiconMatcher
+}
+
+func (b *ttsPieceBuilder) compile(node *markupNode, voice string) error {
+	for _, child := range node.Children {
+		switch child.Tag {
+		case "":
+			b.text(b.lexicon.Apply(child.Text, voiceLanguage(voice)), voice)
+		case "speak":
+			if err := b.compile(child, voice); err != nil {
+				return err
+			}
+		case "voice":
+			if err := b.compile(child, child.Attrs["name"]); err != nil {
+				return err
+			}
+		case "break":
+			sec, _ := parseBreakTime(child.Attrs["time"])
+			b.pieces = append(b.pieces, ttsPiece{Pause: &sec})
+		case "sub":
+			b.text(child.Attrs["alias"], voice)
+		case "say-as":
+			content := nodeText(child)
+			spoken, err := sayAs(child.Attrs["interpret-as"], child.Attrs["format"], content, voiceLanguage(voice))
+			if err != nil {
+				return markupErrorf(child.Line, child.Column, "%v", err)
+			}
+			b.text(spoken, voice)
+		}
+	}
+	return nil
+}
+
+func (b *ttsPieceBuilder) text(s, voice string) {
+	for i, part := range paragraphSeparator.Split(s, -1) {
+		if i > 0 {
+			b.paragraph()
+		}
+		if n := len(b.pieces); n > 0 && b.pieces[n-1].Pause == nil && !b.pieces[n-1].Paragraph && b.pieces[n-1].Voice == voice {
+			b.pieces[n-1].Text += part
+			continue
+		}
+		if strings.TrimSpace(part) != "" {
+			b.pieces = append(b.pieces, ttsPiece{Text: part, Voice: voice})
+		}
+	}
+}
+
+func (b *ttsPieceBuilder) paragraph() {
+	if n := len(b.pieces); n > 0 && !b.pieces[n-1].Paragraph {
+		b.pieces = append(b.pieces, ttsPiece{Paragraph: true})
+	}
+}
+
+func nodeText(node *markupNode) string {
+	var sb strings.Builder
+	for _, child := range node.Children {
+		sb.WriteString(child.Text)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// voiceLanguage trả về mã ngôn ngữ 2 ký tự của voice (vd "en"), rỗng nếu không rõ.
+func voiceLanguage(voice string) string {
+	v, ok := model.LookupTTSVoice(voice)
+	if !ok {
+		return ""
+	}
+	lang, _, _ := strings.Cut(strings.ToLower(v.Language), "-")
+	return lang
+}
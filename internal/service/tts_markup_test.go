@@ -0,0 +1,57 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompileTTSMarkupErrorPosition(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		line   int
+		column int
+	}{
+		{"unsupported tag", "Hello <foo>x</foo>", 1, 7},
+		{"literal less-than", "x < y", 1, 3},
+		{"unclosed element", "Hi\n  <sub alias=\"x\">y", 2, 3},
+		{"mismatched closing tag", "a\n<sub alias=\"x\">b</voice>", 2, 17},
+		{"unexpected closing tag", "Hello\n</sub>", 2, 1},
+		{"duplicate attribute", `<voice name="aura-2-thalia-en" name="x">`, 1, 32},
+		{"break too long", `Wait <break time="20s"/>`, 1, 6},
+		{"unclosed comment", "a\nb <!-- note", 2, 3},
+		{"invalid say-as content", "Take the\n <say-as interpret-as=\"ordinal\">1st</say-as> exit", 2, 2},
+		{"speak not at root", `Hi <speak>x</speak>`, 1, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileTTSMarkup(tt.text, "aura-2-thalia-en", nil)
+			var markupErr *TTSMarkupError
+			if !errors.As(err, &markupErr) {
+				t.Fatalf("err = %v, want *TTSMarkupError", err)
+			}
+			if markupErr.Line != tt.line || markupErr.Column != tt.column {
+				t.Errorf("position = %d:%d, want %d:%d (%s)", markupErr.Line, markupErr.Column, tt.line, tt.column, markupErr.Message)
+			}
+		})
+	}
+}
+
+func TestCompileTTSMarkup(t *testing.T) {
+	pieces, err := compileTTSMarkup(`<speak>Call <say-as interpret-as="characters">API</say-as> &amp; <sub alias="World Wide Web">WWW</sub><break time="500ms"/><voice name="aura-2-apollo-en">Bye</voice></speak>`, "aura-2-thalia-en", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces) != 3 {
+		t.Fatalf("got %d pieces, want 3: %+v", len(pieces), pieces)
+	}
+	if pieces[0].Text != "Call A P I & World Wide Web" || pieces[0].Voice != "aura-2-thalia-en" {
+		t.Errorf("pieces[0] = %+v", pieces[0])
+	}
+	if pieces[1].Pause == nil || *pieces[1].Pause != 0.5 {
+		t.Errorf("pieces[1] = %+v, want 0.5s pause", pieces[1])
+	}
+	if pieces[2].Text != "Bye" || pieces[2].Voice != "aura-2-apollo-en" {
+		t.Errorf("pieces[2] = %+v", pieces[2])
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"video-transcript/internal/model"
+)
+
+// Cắt khoảng lặng: sample có biên độ dưới ngưỡng (~ -50 dBFS) coi là im lặng; giữ lại ttsTrimPad giây ở
+// mỗi đầu để âm đầu / cuối không bị cụt.
+const (
+	ttsSilenceThreshold = 104
+	ttsTrimPad          = 0.05
+)
+
+// atempo chỉ nhận hệ số trong [0.5, 2], ngoài khoảng này phải nối nhiều filter.
+const (
+	minAtempo = 0.5
+	maxAtempo = 2.0
+)
+
+// pcmRange là khoảng sample [Start, End) trên PCM mono.
+type pcmRange struct {
+	Start int64
+	End   int64
+}
+
+// pcmSpeechRange quét PCM s16le mono, trả về khoảng từ sample có tiếng đầu tiên tới sample có tiếng cuối
+// cùng (nới thêm ttsTrimPad mỗi đầu). File toàn im lặng thì giữ nguyên cả file.
+func pcmSpeechRange(path string, sampleRate int) (pcmRange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return pcmRange{}, err
+	}
+	defer f.Close()
+
+	first, last := int64(-1), int64(-1)
+	buf := make([]byte, 1<<16) // bội số của ttsPCMBytesPerSample
+	var n int64
+	for {
+		read, err := io.ReadFull(f, buf)
+		for i := 0; i+ttsPCMBytesPerSample <= read; i += ttsPCMBytesPerSample {
+			v := int16(binary.LittleEndian.Uint16(buf[i:]))
+			if v > ttsSilenceThreshold || v < -ttsSilenceThreshold {
+				if first < 0 {
+					first = n
+				}
+				last = n
+			}
+			n++
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return pcmRange{}, err
+		}
+	}
+	if first < 0 {
+		return pcmRange{Start: 0, End: n}, nil
+	}
+	pad := int64(ttsTrimPad * float64(sampleRate))
+	return pcmRange{Start: max(first-pad, 0), End: min(last+1+pad, n)}, nil
+}
+
+// ttsPostFilter dựng chuỗi filter ffmpeg hậu xử lý audio TTS (PCM mono sampleRate Hz): cắt về khoảng trim,
+// đổi pitch (asetrate, bù lại tốc độ bằng atempo), đổi tempo (atempo, giữ cao độ), chuẩn hoá loudness.
+// Rỗng khi không cần xử lý gì.
+func ttsPostFilter(opts *model.TTSOptions, sampleRate int, trim pcmRange, totalSamples int64) string {
+	var filters []string
+	if trim.Start > 0 || trim.End < totalSamples {
+		filters = append(filters, fmt.Sprintf("atrim=start_sample=%d:end_sample=%d", trim.Start, trim.End), "asetpts=PTS-STARTPTS")
+	}
+
+	tempo := opts.TempoFactor()
+	if pitch := opts.Pitch(); pitch != 0 {
+		ratio := math.Pow(2, pitch/12)
+		filters = append(filters, fmt.Sprintf("asetrate=%d", int(math.Round(float64(sampleRate)*ratio))), fmt.Sprintf("aresample=%d", sampleRate))
+		tempo /= ratio
+	}
+	filters = append(filters, atempoChain(tempo)...)
+
+	if t, ok := model.LookupLoudnessTarget(opts.Loudness); ok {
+		filters = append(filters,
+			fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g", t.Integrated, t.TruePeak, t.Range),
+			fmt.Sprintf("aresample=%d", sampleRate),
+		)
+	}
+	return strings.Join(filters, ",")
+}
+
+// atempoChain tách hệ số tempo thành các filter atempo, mỗi filter trong [0.5, 2]. Hệ số 1 thì không cần filter.
+func atempoChain(factor float64) []string {
+	var filters []string
+	for factor > maxAtempo {
+		filters = append(filters, fmt.Sprintf("atempo=%g", maxAtempo))
+		factor /= maxAtempo
+	}
+	for factor < minAtempo {
+		filters = append(filters, fmt.Sprintf("atempo=%g", minAtempo))
+		factor /= minAtempo
+	}
+	if math.Abs(factor-1) > 1e-6 {
+		filters = append(filters, fmt.Sprintf("atempo=%.6f", factor))
+	}
+	return filters
+}
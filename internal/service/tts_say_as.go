@@ -0,0 +1,241 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// sayAsInterpreters đổi nội dung <say-as> thành text đọc được theo interpret-as. lang là mã ngôn ngữ
+// 2 ký tự của voice: số chỉ được viết thành chữ với tiếng Anh, ngôn ngữ khác provider tự đọc chữ số.
+var sayAsInterpreters = map[string]func(content, format, lang string) (string, error){
+	"characters": sayCharacters,
+	"spell-out":  sayCharacters,
+	"cardinal":   sayCardinal,
+	"number":     sayCardinal,
+	"ordinal":    sayOrdinal,
+	"digits":     sayDigits,
+	"telephone":  sayTelephone,
+	"date":       sayDate,
+}
+
+// dateFormats là thứ tự ngày / tháng / năm của <say-as interpret-as="date" format="...">, mặc định ymd.
+var dateFormats = []string{"ymd", "dmy", "mdy"}
+
+var (
+	numberPattern    = regexp.MustCompile(`^[+-]?(\d{1,3}(,\d{3})+|\d+)(\.\d+)?$`)
+	dateSeparator    = regexp.MustCompile(`[-/.]`)
+	englishMonths    = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	spanishMonths    = []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"}
+	englishSmall     = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	englishTens      = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	englishScales    = []string{"", "thousand", "million", "billion", "trillion"}
+	englishOrdinals  = map[string]string{"one": "first", "two": "second", "three": "third", "five": "fifth", "eight": "eighth", "nine": "ninth", "twelve": "twelfth"}
+	maxSpelledNumber = uint64(1e15)
+)
+
+func sayAsNames() []string {
+	names := make([]string, 0, len(sayAsInterpreters))
+	for name := range sayAsInterpreters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func sayAs(interpretAs, format, content, lang string) (string, error) {
+	if content == "" {
+		return "", errors.New("<say-as> is empty")
+	}
+	return sayAsInterpreters[interpretAs](content, format, lang)
+}
+
+// sayCharacters đọc từng ký tự: "API" -> "A P I".
+func sayCharacters(content, _, _ string) (string, error) {
+	var chars []string
+	for _, r := range content {
+		if !unicode.IsSpace(r) {
+			chars = append(chars, string(r))
+		}
+	}
+	return strings.Join(chars, " "), nil
+}
+
+// sayDigits đọc từng chữ số: "2024" -> "2 0 2 4".
+func sayDigits(content, _, _ string) (string, error) {
+	for _, r := range content {
+		if !unicode.IsDigit(r) {
+			return "", fmt.Errorf("%q is not a sequence of digits", content)
+		}
+	}
+	return sayCharacters(content, "", "")
+}
+
+// sayTelephone đọc số điện thoại từng chữ số, ngắt nghỉ giữa các nhóm: "555-0123" -> "5 5 5, 0 1 2 3".
+func sayTelephone(content, _, _ string) (string, error) {
+	groups := strings.FieldsFunc(content, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("-.()/", r)
+	})
+	if len(groups) == 0 {
+		return "", fmt.Errorf("%q is not a telephone number", content)
+	}
+	spoken := make([]string, 0, len(groups))
+	for i, g := range groups {
+		if i == 0 && strings.HasPrefix(g, "+") {
+			g = g[1:]
+			spoken = append(spoken, "plus")
+		}
+		s, err := sayDigits(g, "", "")
+		if err != nil {
+			return "", fmt.Errorf("%q is not a telephone number", content)
+		}
+		spoken = append(spoken, s+",")
+	}
+	return strings.TrimSuffix(strings.Join(spoken, " "), ","), nil
+}
+
+// sayCardinal đọc số: tiếng Anh viết thành chữ ("1,234.5" -> "one thousand two hundred thirty-four point five"),
+// ngôn ngữ khác bỏ dấu phân cách hàng nghìn để provider không đọc nhầm.
+func sayCardinal(content, _, lang string) (string, error) {
+	sign, integer, fraction, err := parseSpokenNumber(content)
+	if err != nil {
+		return "", err
+	}
+	if lang != "en" || integer >= maxSpelledNumber {
+		out := sign + strconv.FormatUint(integer, 10)
+		if fraction != "" {
+			out += "." + fraction
+		}
+		return out, nil
+	}
+
+	words := englishCardinal(integer)
+	if sign == "-" {
+		words = "minus " + words
+	}
+	if fraction != "" {
+		words += " point"
+		for _, d := range fraction {
+			words += " " + englishSmall[d-'0']
+		}
+	}
+	return words, nil
+}
+
+// sayOrdinal đọc số thứ tự: "21" -> "twenty-first" (tiếng Anh), ngôn ngữ khác giữ nguyên.
+func sayOrdinal(content, _, lang string) (string, error) {
+	sign, integer, fraction, err := parseSpokenNumber(content)
+	if err != nil || sign != "" || fraction != "" {
+		return "", fmt.Errorf("%q is not a positive whole number", content)
+	}
+	if lang != "en" || integer >= maxSpelledNumber {
+		return content, nil
+	}
+	return englishOrdinal(englishCardinal(integer)), nil
+}
+
+// sayDate đọc ngày theo format (ymd / dmy / mdy): "2024-03-05" -> "March 5, 2024" / "5 de marzo de 2024".
+func sayDate(content, format, lang string) (string, error) {
+	if format == "" {
+		format = "ymd"
+	}
+	parts := dateSeparator.Split(content, -1)
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%q is not a date in %s format", content, format)
+	}
+	values := map[byte]int{}
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return "", fmt.Errorf("%q is not a date in %s format", content, format)
+		}
+		values[format[i]] = n
+	}
+	year, month, day := values['y'], values['m'], values['d']
+	if year < 100 {
+		year += 2000
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if month < 1 || month > 12 || t.Day() != day {
+		return "", fmt.Errorf("%q is not a valid date", content)
+	}
+
+	if lang == "es" {
+		return fmt.Sprintf("%d de %s de %d", day, spanishMonths[month-1], year), nil
+	}
+	return fmt.Sprintf("%s %d, %d", englishMonths[month-1], day, year), nil
+}
+
+// parseSpokenNumber tách dấu, phần nguyên (bỏ dấu phẩy hàng nghìn) và phần thập phân.
+func parseSpokenNumber(content string) (string, uint64, string, error) {
+	if !numberPattern.MatchString(content) {
+		return "", 0, "", fmt.Errorf("%q is not a number", content)
+	}
+	sign := ""
+	if content[0] == '+' || content[0] == '-' {
+		sign, content = strings.TrimPrefix(content[:1], "+"), content[1:]
+	}
+	integerPart, fraction, _ := strings.Cut(content, ".")
+	integer, err := strconv.ParseUint(strings.ReplaceAll(integerPart, ",", ""), 10, 64)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("%q is too large", content)
+	}
+	return sign, integer, fraction, nil
+}
+
+// englishCardinal viết số nguyên < 10^15 thành chữ tiếng Anh.
+func englishCardinal(n uint64) string {
+	if n < 20 {
+		return englishSmall[n]
+	}
+	var groups []string
+	for scale := 0; n > 0; scale++ {
+		if group := n % 1000; group > 0 {
+			words := englishHundreds(group)
+			if englishScales[scale] != "" {
+				words += " " + englishScales[scale]
+			}
+			groups = append([]string{words}, groups...)
+		}
+		n /= 1000
+	}
+	return strings.Join(groups, " ")
+}
+
+func englishHundreds(n uint64) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, englishSmall[n/100], "hundred")
+		n %= 100
+	}
+	switch {
+	case n == 0:
+	case n < 20:
+		words = append(words, englishSmall[n])
+	case n%10 == 0:
+		words = append(words, englishTens[n/10])
+	default:
+		words = append(words, englishTens[n/10]+"-"+englishSmall[n%10])
+	}
+	return strings.Join(words, " ")
+}
+
+// englishOrdinal đổi từ cuối của số đếm thành số thứ tự: "twenty-one" -> "twenty-first".
+func englishOrdinal(cardinal string) string {
+	cut := strings.LastIndexAny(cardinal, " -") + 1
+	head, last := cardinal[:cut], cardinal[cut:]
+	switch {
+	case englishOrdinals[last] != "":
+		last = englishOrdinals[last]
+	case strings.HasSuffix(last, "y"):
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		last += "th"
+	}
+	return head + last
+}
@@ -0,0 +1,58 @@
+package service
+
+import "testing"
+
+func TestEnglishOrdinal(t *testing.T) {
+	tests := []struct {
+		cardinal string
+		want     string
+	}{
+		{"one", "first"},
+		{"two", "second"},
+		{"three", "third"},
+		{"four", "fourth"},
+		{"five", "fifth"},
+		{"eight", "eighth"},
+		{"nine", "ninth"},
+		{"eleven", "eleventh"},
+		{"twelve", "twelfth"},
+		{"twenty", "twentieth"},
+		{"twenty-one", "twenty-first"},
+		{"ninety-nine", "ninety-ninth"},
+		{"one hundred", "one hundredth"},
+		{"one hundred twelve", "one hundred twelfth"},
+		{"two thousand three", "two thousand third"},
+		{"one million", "one millionth"},
+	}
+	for _, tt := range tests {
+		if got := englishOrdinal(tt.cardinal); got != tt.want {
+			t.Errorf("englishOrdinal(%q) = %q, want %q", tt.cardinal, got, tt.want)
+		}
+	}
+}
+
+func TestSayOrdinal(t *testing.T) {
+	tests := []struct {
+		content string
+		lang    string
+		want    string
+		wantErr bool
+	}{
+		{"21", "en", "twenty-first", false},
+		{"1,000", "en", "one thousandth", false},
+		{"3", "es", "3", false},
+		{"-1", "en", "", true},
+		{"1.5", "en", "", true},
+		{"1st", "en", "", true},
+	}
+	for _, tt := range tests {
+		got, err := sayOrdinal(tt.content, "", tt.lang)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("sayOrdinal(%q) err = %v, wantErr %v", tt.content, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("sayOrdinal(%q, %s) = %q, want %q", tt.content, tt.lang, got, tt.want)
+		}
+	}
+}
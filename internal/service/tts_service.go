@@ -0,0 +1,563 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/helper"
+	"video-transcript/internal/model"
+	"video-transcript/internal/repository"
+	"video-transcript/internal/uploads"
+
+	"go.uber.org/zap"
+)
+
+// TTSJob là 1 yêu cầu chuyển text thành audio với options đã áp default.
+type TTSJob struct {
+	UserID   int64
+	Text     string
+	Options  *model.TTSOptions
+	Lexicon  []*model.LexiconEntry // lexicon phát âm của user, Start tự nạp nếu options không tắt
+	Dialogue *model.Dialogue       // != nil: Text là script hội thoại, mỗi câu đọc bằng voice của người nói
+
+	cacheScope string // phạm vi cache đã resolve, rỗng = không cache
+	cacheKey   string
+}
+
+// TTSService điều phối job TTS: cache -> chia chunk -> speech provider -> nối audio -> upload R2 -> lưu Video audio.
+type TTSService interface {
+	// Start tạo task TTS và chạy job ở background, trả về task vừa tạo.
+	// Markup không hợp lệ trả về *TTSMarkupError.
+	Start(ctx context.Context, job *TTSJob) (*model.Task, error)
+	// StartAudiobook tạo task audiobook và chạy ở background: mỗi chương 1 Video MP3, cuối cùng 1 Video M4B
+	// có chapter marker, tất cả gắn render_task_id = task.
+	StartAudiobook(ctx context.Context, job *AudiobookJob) (*model.Task, error)
+	// GetAudiobook trả về task audiobook kèm các chương đã tạo và file M4B (khi task đã xong).
+	GetAudiobook(ctx context.Context, taskID int64) (*model.Audiobook, error)
+}
+
+type ttsService struct {
+	taskSvc    TaskService
+	videoSvc   VideoService
+	lexiconSvc LexiconService
+	pipeline   MediaPipeline
+	cacheRepo  repository.TTSCacheRepository
+}
+
+// NewTTSService creates a new TTSService.
+func NewTTSService(taskSvc TaskService, videoSvc VideoService, lexiconSvc LexiconService, pipeline MediaPipeline, cacheRepo repository.TTSCacheRepository) TTSService {
+	return &ttsService{taskSvc: taskSvc, videoSvc: videoSvc, lexiconSvc: lexiconSvc, pipeline: pipeline, cacheRepo: cacheRepo}
+}
+
+func (s *ttsService) Start(ctx context.Context, job *TTSJob) (*model.Task, error) {
+	job.Options = job.Options.WithDefaults()
+	if !job.Options.SkipLexicon && job.Lexicon == nil {
+		lexicon, err := s.loadLexicon(ctx, job.UserID)
+		if err != nil {
+			return nil, err
+		}
+		job.Lexicon = lexicon
+	}
+	// Markup sai trả lỗi ngay (kèm dòng / cột) thay vì tạo task rồi mới fail.
+	pieces, err := ttsPieces(job)
+	if err != nil {
+		return nil, err
+	}
+	var cached *model.TTSCacheEntry
+	if scope := ttsCacheScope(job); scope != model.TTSCacheScopeNone {
+		if chunks, lead := planJobChunks(job, pieces); len(chunks) > 0 {
+			job.cacheScope = scope
+			job.cacheKey = ttsCacheKey(scope, job.UserID, chunks, ttsSilences(chunks, lead, job.Options), job.Options)
+			if !job.Options.CacheRefresh {
+				cached = s.lookupCache(ctx, job)
+			}
+		}
+	}
+	optionsJSON, err := json.Marshal(struct {
+		*model.TTSOptions
+		Dialogue *model.Dialogue `json:"dialogue,omitempty"`
+		CacheKey string          `json:"cache_key,omitempty"`
+		CacheHit bool            `json:"cache_hit,omitempty"`
+	}{job.Options, job.Dialogue, job.cacheKey, cached != nil})
+	if err != nil {
+		return nil, err
+	}
+
+	task := &model.Task{
+		TaskType:  model.TaskTypeTTS,
+		Status:    model.TaskStatusPending,
+		InputText: &job.Text,
+		UserID:    &job.UserID,
+		Options:   optionsJSON,
+	}
+	if err := s.taskSvc.Create(ctx, task); err != nil {
+		zap.S().Errorw("create task failed", "error", err)
+		return nil, err
+	}
+
+	if cached != nil {
+		err := s.completeFromCache(ctx, task, job, cached)
+		if err == nil {
+			return task, nil
+		}
+		zap.S().Errorw("complete tts task from cache failed, synthesizing", "id", task.ID, "error", err)
+	}
+
+	go s.run(task, job)
+
+	return task, nil
+}
+
+// loadLexicon nạp lexicon phát âm của user cho job.
+func (s *ttsService) loadLexicon(ctx context.Context, userID int64) ([]*model.LexiconEntry, error) {
+	lexicon, err := s.lexiconSvc.ListForUser(ctx, userID)
+	if err != nil {
+		zap.S().Errorw("list lexicon entries failed", "user_id", userID, "error", err)
+		return nil, err
+	}
+	return lexicon, nil
+}
+
+// run chạy job TTS, output_url của task là audio đã tạo.
+func (s *ttsService) run(task *model.Task, job *TTSJob) {
+	timeout := time.Duration(config.SvcCfg.TTSJobTimeoutMinute) * time.Minute
+	runTask(s.taskSvc, task, timeout, func(ctx context.Context) error {
+		video, err := s.synthesize(ctx, task, job)
+		if err != nil {
+			return err
+		}
+		if err := s.taskSvc.UpdateStatus(ctx, task.ID, model.TaskStatusCompleted, &video.LinkVideo, video.DurationSec, nil); err != nil {
+			zap.S().Errorw("update task status failed", "id", task.ID, "error", err)
+		}
+		return nil
+	})
+}
+
+// ttsResult là audio TTS đã upload.
+type ttsResult struct {
+	URL      string
+	Duration *float64
+	Provider string                // provider phục vụ nhiều chunk nhất
+	Spans    []model.SpeechSegment // thời gian từng chunk trên audio (chỉ có khi nối bằng ffmpeg)
+}
+
+// synthesize tổng hợp audio cho toàn bộ text, upload và lưu thành Video (tên file theo voice + định dạng thật).
+// Text ngắn gửi provider 1 lần; text dài, có <break> / đổi voice hoặc script hội thoại được chia chunk,
+// tổng hợp song song rồi nối lại bằng ffmpeg.
+func (s *ttsService) synthesize(ctx context.Context, task *model.Task, job *TTSJob) (*model.Video, error) {
+	pieces, err := ttsPieces(job)
+	if err != nil {
+		return nil, err
+	}
+	chunks, lead := planJobChunks(job, pieces)
+	if len(chunks) == 0 {
+		return nil, errors.New("text is empty")
+	}
+
+	key := fmt.Sprintf("text-to-speech/%d/%d-audio%s", job.UserID, time.Now().UnixNano(), job.Options.Extension())
+	if job.cacheKey != "" {
+		key = ttsCacheObjectKey(job)
+	}
+	var result *ttsResult
+	// Timing theo câu lấy từ thời gian các chunk, hậu xử lý (tempo / pitch / loudness / cắt khoảng lặng)
+	// chạy lúc encode audio đã nối, nên 2 trường hợp này luôn đi qua ffmpeg kể cả khi chỉ có 1 chunk.
+	if len(chunks) == 1 && lead == 0 && chunks[0].PauseAfter == nil && job.Dialogue == nil &&
+		job.Options.Timings != model.TTSTimingsSentences && !job.Options.HasPostProcess() {
+		result, err = s.synthesizeSingle(ctx, chunks[0], job.Options, key)
+	} else {
+		result, err = s.synthesizeChunked(ctx, task.ID, chunks, ttsSilences(chunks, lead, job.Options), job.Options, key)
+	}
+	if result != nil && result.Provider != "" {
+		if err := s.taskSvc.UpdateProvider(ctx, task.ID, result.Provider); err != nil {
+			zap.S().Errorw("update task provider failed", "id", task.ID, "error", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	video := &model.Video{
+		UserID:      job.UserID,
+		LinkVideo:   result.URL,
+		NameFile:    job.Options.FileName(),
+		Description: &job.Text,
+		DurationSec: result.Duration,
+	}
+	if job.Dialogue != nil {
+		video.NameFile = "dialogue" + job.Options.Extension()
+	}
+	if err := s.videoSvc.Create(ctx, video); err != nil {
+		zap.S().Errorw("create video failed", "user_id", job.UserID, "file_url", result.URL, "error", err)
+		return nil, err
+	}
+	// Gắn task với Video audio: dùng làm narration khi mix nhạc nền, làm transcript của video khi có timing.
+	if err := s.taskSvc.UpdateVideoID(ctx, task.ID, video.ID); err != nil {
+		zap.S().Errorw("update task video id failed", "id", task.ID, "error", err)
+	}
+
+	if job.cacheKey != "" {
+		s.storeCache(ctx, job, result)
+	}
+	// Transcript + phụ đề chỉ là phần phụ, lỗi không làm hỏng audio đã tạo.
+	var transcript *model.SimpleTranscript
+	switch {
+	case job.Dialogue != nil:
+		if transcript, err = s.storeDialogueCaptions(ctx, video, job, chunks, result.Spans); err != nil {
+			zap.S().Errorw("store dialogue captions failed", "video_id", video.ID, "error", err)
+		}
+	case job.Options.Timings != "":
+		if transcript, err = s.ttsTimings(ctx, job, chunks, result); err != nil {
+			zap.S().Errorw("build tts timings failed", "task_id", task.ID, "timings", job.Options.Timings, "error", err)
+		} else if cues := BuildCues(transcript); len(cues) > 0 {
+			if _, err := s.pipeline.StoreCaptions(ctx, video, FormatWebVTT(cues)); err != nil {
+				zap.S().Errorw("store tts captions failed", "video_id", video.ID, "error", err)
+			}
+		}
+	}
+	if transcript != nil {
+		if err := s.storeTTSTranscript(ctx, task.ID, transcript); err != nil {
+			zap.S().Errorw("store tts transcript failed", "task_id", task.ID, "error", err)
+		}
+	}
+	return video, nil
+}
+
+// planJobChunks chia chunk cho job: timing theo câu thì mỗi câu 1 chunk.
+func planJobChunks(job *TTSJob, pieces []ttsPiece) ([]ttsChunk, float64) {
+	return planTTSChunks(pieces, config.SvcCfg.TTSChunkChars, job.Options.Timings == model.TTSTimingsSentences)
+}
+
+// ttsPieces áp lexicon và biên dịch text của job (markup hoặc text thường, hoặc script hội thoại) thành các piece.
+func ttsPieces(job *TTSJob) ([]ttsPiece, error) {
+	var lexicon *lexiconMatcher
+	if !job.Options.SkipLexicon {
+		lexicon = newLexiconMatcher(job.Lexicon)
+	}
+	if job.Dialogue != nil {
+		return dialoguePieces(job.Dialogue, job.Options.Markup, lexicon)
+	}
+	if job.Options.Markup {
+		return compileTTSMarkup(job.Text, job.Options.Voice, lexicon)
+	}
+	return plainTTSPieces(job.Text, job.Options.Voice, lexicon), nil
+}
+
+// dialoguePieces biên dịch từng câu thoại bằng voice của người nói, chèn khoảng lặng giữa các câu
+// (dài hơn khi đổi người nói). Lỗi markup được đổi về vị trí trong script.
+func dialoguePieces(d *model.Dialogue, markup bool, lexicon *lexiconMatcher) ([]ttsPiece, error) {
+	var pieces []ttsPiece
+	for i, line := range d.Lines {
+		if i > 0 {
+			gap := float64(d.LineGapMs) / 1000
+			if line.Speaker != d.Lines[i-1].Speaker {
+				gap = float64(d.TurnGapMs) / 1000
+			}
+			pieces = append(pieces, ttsPiece{Pause: &gap})
+		}
+
+		voice := d.Speakers[line.Speaker]
+		var linePieces []ttsPiece
+		if markup {
+			var err error
+			if linePieces, err = compileTTSMarkup(line.Text, voice, lexicon); err != nil {
+				var markupErr *TTSMarkupError
+				if errors.As(err, &markupErr) && markupErr.Line <= len(line.Lines) {
+					column := markupErr.Column + line.Columns[markupErr.Line-1] - 1
+					return nil, markupErrorf(line.Lines[markupErr.Line-1], column, "%s", markupErr.Message)
+				}
+				return nil, err
+			}
+		} else {
+			linePieces = plainTTSPieces(line.Text, voice, lexicon)
+		}
+		for _, p := range linePieces {
+			if p.Paragraph {
+				continue
+			}
+			p.Line = i
+			pieces = append(pieces, p)
+		}
+	}
+	return pieces, nil
+}
+
+// storeDialogueCaptions dựng transcript (mỗi câu thoại 1 utterance, speaker theo thứ tự xuất hiện) từ thời
+// gian các chunk trên audio đã nối, lưu thành task STT của video và phụ đề WebVTT (voice tag = tên người nói).
+// Trả về transcript đã dựng (kể cả khi lưu lỗi) để lưu thêm lên task TTS.
+func (s *ttsService) storeDialogueCaptions(ctx context.Context, video *model.Video, job *TTSJob, chunks []ttsChunk, spans []model.SpeechSegment) (*model.SimpleTranscript, error) {
+	if len(spans) != len(chunks) {
+		return nil, errors.New("missing chunk timings")
+	}
+	d := job.Dialogue
+	lineSpans := make([]*model.SpeechSegment, len(d.Lines))
+	for i, chunk := range chunks {
+		span := spans[i]
+		if ls := lineSpans[chunk.Line]; ls != nil {
+			ls.End = span.End
+			continue
+		}
+		lineSpans[chunk.Line] = &span
+	}
+
+	speakerIndex := d.SpeakerIndex()
+	transcript := &model.SimpleTranscript{Words: []model.SimpleWord{}, Utterances: []model.SimpleUtterance{}}
+	var cues []Cue
+	var text []string
+	for i, line := range d.Lines {
+		span := lineSpans[i]
+		if span == nil {
+			continue
+		}
+		display := dialogueDisplayText(line.Text, job.Options.Markup)
+		speaker := speakerIndex[line.Speaker]
+		transcript.Utterances = append(transcript.Utterances, model.SimpleUtterance{
+			Start:      span.Start,
+			End:        span.End,
+			Transcript: display,
+			Speaker:    &speaker,
+		})
+		cues = append(cues, Cue{Start: span.Start, End: span.End, Text: display, Speaker: &speaker, Name: line.Speaker})
+		text = append(text, display)
+	}
+	transcript.TranscriptText = strings.Join(text, " ")
+
+	if err := saveVideoTranscript(ctx, s.taskSvc, video, transcript); err != nil {
+		return transcript, err
+	}
+	_, err := s.pipeline.StoreCaptions(ctx, video, FormatWebVTT(cues))
+	return transcript, err
+}
+
+// dialogueDisplayText là text hiển thị của 1 câu thoại: bỏ markup (giữ nội dung gốc của <sub> / <say-as>),
+// gộp khoảng trắng.
+func dialogueDisplayText(text string, markup bool) string {
+	if markup {
+		if root, err := parseTTSMarkup(text); err == nil {
+			text = markupPlainText(root)
+		}
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func markupPlainText(node *markupNode) string {
+	if node.Tag == "" && node.Children == nil {
+		return node.Text
+	}
+	var sb strings.Builder
+	for _, child := range node.Children {
+		if child.Tag == "break" {
+			sb.WriteByte(' ')
+			continue
+		}
+		sb.WriteString(markupPlainText(child))
+	}
+	return sb.String()
+}
+
+// ttsSilences tính khoảng lặng trước chunk đầu và sau từng chunk: <break> tường minh được ưu tiên,
+// sau đó là khoảng lặng giữa đoạn văn / giữa câu. Sau chunk cuối chỉ có <break> tường minh.
+func ttsSilences(chunks []ttsChunk, lead float64, opts *model.TTSOptions) []float64 {
+	silences := make([]float64, len(chunks)+1)
+	silences[0] = lead
+	for i, chunk := range chunks {
+		switch {
+		case chunk.PauseAfter != nil:
+			silences[i+1] = *chunk.PauseAfter
+		case i == len(chunks)-1:
+		case chunk.ParagraphEnd:
+			silences[i+1] = float64(*opts.ParagraphGapMs) / 1000
+		default:
+			silences[i+1] = float64(*opts.SentenceGapMs) / 1000
+		}
+	}
+	return silences
+}
+
+// synthesizeSingle: text vừa 1 request, upload thẳng audio provider trả về (không cần ffmpeg).
+func (s *ttsService) synthesizeSingle(ctx context.Context, chunk ttsChunk, opts *model.TTSOptions, key string) (*ttsResult, error) {
+	audio, provider, err := speakWithRetry(ctx, chunk, opts)
+	result := &ttsResult{Provider: provider}
+	if err != nil {
+		return result, err
+	}
+	if result.URL, err = uploads.UploadToR2(ctx, key, bytes.NewReader(audio.Data), int64(len(audio.Data)), audio.ContentType); err != nil {
+		return result, fmt.Errorf("upload tts audio: %w", err)
+	}
+	return result, nil
+}
+
+// synthesizeChunked tổng hợp các chunk song song, ghi progress lên task, rồi nối theo đúng thứ tự với
+// các khoảng lặng silences (kèm hậu xử lý của opts).
+func (s *ttsService) synthesizeChunked(ctx context.Context, taskID int64, chunks []ttsChunk, silences []float64, opts *model.TTSOptions, key string) (*ttsResult, error) {
+	if !config.SvcCfg.MediaPipelineEnabled {
+		if len(chunks) == 1 {
+			return nil, fmt.Errorf("audio post-processing and sentence timings require the media pipeline: %w", ErrMediaPipelineDisabled)
+		}
+		return nil, fmt.Errorf("text needs %d tts requests but joining audio requires the media pipeline: %w", len(chunks), ErrMediaPipelineDisabled)
+	}
+
+	workDir, err := os.MkdirTemp(config.SvcCfg.MediaWorkDir, fmt.Sprintf("tts-%d-*", taskID))
+	if err != nil {
+		return nil, fmt.Errorf("create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	paths, provider, err := speakChunks(ctx, workDir, chunks, opts, s.chunkProgress(ctx, taskID, len(chunks)))
+	result := &ttsResult{Provider: provider}
+	if err != nil {
+		return result, err
+	}
+
+	outPath := filepath.Join(workDir, "tts"+opts.Extension())
+	spans, duration, err := JoinTTSAudio(ctx, workDir, paths, silences, opts, outPath)
+	if err != nil {
+		return result, fmt.Errorf("join tts audio: %w", err)
+	}
+	result.Spans, result.Duration = spans, &duration
+
+	if result.URL, err = uploadFile(ctx, outPath, key, opts.ContentType()); err != nil {
+		return result, fmt.Errorf("upload tts audio: %w", err)
+	}
+	return result, nil
+}
+
+// speakChunks tổng hợp các chunk song song (tối đa TTSChunkWorkers request cùng lúc) thành các file trong
+// workDir, gọi done sau mỗi chunk xong. Trả về đường dẫn audio từng chunk (theo thứ tự chunk) và provider
+// phục vụ nhiều chunk nhất.
+func speakChunks(ctx context.Context, workDir string, chunks []ttsChunk, opts *model.TTSOptions, done func()) ([]string, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	paths := make([]string, len(chunks))
+	providers := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(1, config.SvcCfg.TTSChunkWorkers))
+
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk ttsChunk) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			audio, provider, err := speakWithRetry(ctx, chunk, opts)
+			providers[i] = provider
+			if err == nil {
+				paths[i] = filepath.Join(workDir, fmt.Sprintf("chunk-%04d%s", i, opts.Extension()))
+				err = os.WriteFile(paths[i], audio.Data, 0o600)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("chunk %d: %w", i, err)
+				cancel() // 1 chunk hỏng hẳn thì cả file không dùng được, dừng các chunk còn lại
+				return
+			}
+			done()
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	provider := mostFrequent(providers)
+	if err := firstChunkError(errs); err != nil {
+		return nil, provider, err
+	}
+	return paths, provider, nil
+}
+
+// chunkProgress ghi progress 0/total lên task và trả về hàm gọi sau mỗi chunk xong (đếm, ghi current/total).
+func (s *ttsService) chunkProgress(ctx context.Context, taskID int64, total int) func() {
+	var (
+		mu   sync.Mutex
+		done int
+	)
+	s.updateProgress(ctx, taskID, 0, total)
+	return func() {
+		mu.Lock()
+		done++
+		current := done
+		mu.Unlock()
+		s.updateProgress(ctx, taskID, current, total)
+	}
+}
+
+func (s *ttsService) updateProgress(ctx context.Context, taskID int64, current, total int) {
+	if err := s.taskSvc.UpdateProgress(ctx, taskID, current, total); err != nil {
+		zap.S().Errorw("update task progress failed", "id", taskID, "error", err)
+	}
+}
+
+// speakWithRetry gọi provider cho 1 chunk (theo voice của chunk), thử lại tối đa TTSChunkRetries lần
+// (chờ tăng dần) khi lỗi do provider (5xx, 429, mất kết nối). Router đã failover giữa các provider,
+// retry ở đây để vượt qua lỗi tạm thời của cả pool.
+func speakWithRetry(ctx context.Context, chunk ttsChunk, opts *model.TTSOptions) (*helper.SpeechAudio, string, error) {
+	if chunk.Voice != "" && chunk.Voice != opts.Voice {
+		voiceOpts := *opts
+		voiceOpts.Voice = chunk.Voice
+		opts = &voiceOpts
+	}
+	attempts := max(1, config.SvcCfg.TTSChunkRetries+1)
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		audio, provider, err := helper.Speak(ctx, chunk.Text, opts)
+		if err == nil {
+			return audio, provider, nil
+		}
+		lastErr = err
+		// Lỗi do request (4xx: text / voice không hợp lệ...) thử lại cũng vậy.
+		if !helper.IsProviderFailure(ctx, err) || attempt == attempts {
+			return nil, provider, err
+		}
+		zap.S().Warnw("tts chunk failed, retrying", "chunk", chunk.Index, "attempt", attempt, "error", err)
+		select {
+		case <-time.After(time.Duration(attempt) * time.Second):
+		case <-ctx.Done():
+			return nil, provider, ctx.Err()
+		}
+	}
+	return nil, "", lastErr
+}
+
+// firstChunkError trả về lỗi thật đầu tiên, bỏ qua lỗi context.Canceled của các chunk bị huỷ theo.
+func firstChunkError(errs []error) error {
+	var canceled error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+		canceled = err
+	}
+	return canceled
+}
+
+// mostFrequent trả về giá trị khác rỗng xuất hiện nhiều nhất.
+func mostFrequent(values []string) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		counts[v]++
+		if best == "" || counts[v] > counts[best] {
+			best = v
+		}
+	}
+	return best
+}
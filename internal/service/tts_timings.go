@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"video-transcript/internal/config"
+	"video-transcript/internal/helper"
+	"video-transcript/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// ttsTimings dựng transcript timing cho audio TTS vừa tạo theo options.Timings:
+//   - sentences: mỗi chunk là 1 câu nên thời gian chunk trên audio đã nối chính là thời gian câu;
+//   - words: chạy STT lại trên audio (URL đã upload) để lấy thời gian từng từ.
+func (s *ttsService) ttsTimings(ctx context.Context, job *TTSJob, chunks []ttsChunk, result *ttsResult) (*model.SimpleTranscript, error) {
+	switch job.Options.Timings {
+	case model.TTSTimingsSentences:
+		return sentenceTimings(job, chunks, result.Spans)
+	case model.TTSTimingsWords:
+		return wordTimings(ctx, job, result.URL)
+	}
+	return nil, nil
+}
+
+// sentenceTimings: mỗi câu 1 utterance. Text hiển thị là câu gốc (trước lexicon / markup) nếu tách ra
+// đúng số câu với các chunk, không thì dùng text đã gửi provider.
+func sentenceTimings(job *TTSJob, chunks []ttsChunk, spans []model.SpeechSegment) (*model.SimpleTranscript, error) {
+	if len(spans) != len(chunks) {
+		return nil, errors.New("missing chunk timings")
+	}
+	display := ttsDisplaySentences(job)
+	if len(display) != len(chunks) {
+		display = nil
+	}
+
+	transcript := &model.SimpleTranscript{
+		Words:      []model.SimpleWord{},
+		Utterances: make([]model.SimpleUtterance, 0, len(chunks)),
+	}
+	if lang := voiceLanguage(job.Options.Voice); lang != "" {
+		transcript.DetectedLanguage, transcript.LanguageConfidence = lang, 1
+	}
+	text := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		sentence := chunk.Text
+		if display != nil {
+			sentence = display[i]
+		}
+		transcript.Utterances = append(transcript.Utterances, model.SimpleUtterance{
+			Start:      spans[i].Start,
+			End:        spans[i].End,
+			Transcript: sentence,
+			Language:   voiceLanguage(chunk.Voice),
+		})
+		text = append(text, sentence)
+	}
+	transcript.TranscriptText = strings.Join(text, " ")
+	return transcript, nil
+}
+
+// ttsDisplaySentences tách text hiển thị của job (bỏ markup, không áp lexicon) thành các câu theo đúng
+// cách planTTSChunks tách câu.
+func ttsDisplaySentences(job *TTSJob) []string {
+	text := job.Text
+	if job.Options.Markup {
+		root, err := parseTTSMarkup(text)
+		if err != nil {
+			return nil
+		}
+		text = markupPlainText(root)
+	}
+	var sentences []string
+	for _, paragraph := range paragraphSeparator.Split(text, -1) {
+		for _, sentence := range splitSentences(joinWords(paragraph)) {
+			sentences = append(sentences, splitLongText(sentence, config.SvcCfg.TTSChunkChars)...)
+		}
+	}
+	return sentences
+}
+
+// wordTimings transcribe lại audio TTS bằng ngôn ngữ của voice (không diarize, không redact) để lấy
+// thời gian từng từ.
+func wordTimings(ctx context.Context, job *TTSJob, audioURL string) (*model.SimpleTranscript, error) {
+	on, off := true, false
+	opts := model.DefaultSTTOptions()
+	opts.Diarize = &off
+	opts.Redact = nil
+	opts.SmartFormat = &on
+	if lang := voiceLanguage(job.Options.Voice); lang != "" {
+		opts.Language = lang
+	}
+
+	res, _, err := helper.Transcribe(ctx, audioURL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("transcribe tts audio: %w", err)
+	}
+	return model.ConvertDeepgramToSimple(res)
+}
+
+// storeTTSTranscript lưu transcript timing lên chính task TTS (task đã gắn với Video audio), để task dùng
+// được như transcript của video (render phụ đề, edit, clip, HLS). Task vẫn ở processing, run đánh completed sau.
+func (s *ttsService) storeTTSTranscript(ctx context.Context, taskID int64, transcript *model.SimpleTranscript) error {
+	transcriptJSON, err := json.Marshal(transcript)
+	if err != nil {
+		return err
+	}
+	if err := s.taskSvc.UpdateTranscript(ctx, taskID, model.TaskStatusProcessing, &transcript.TranscriptText, transcriptJSON); err != nil {
+		return fmt.Errorf("save transcript: %w", err)
+	}
+	if transcript.DetectedLanguage != "" {
+		if err := s.taskSvc.UpdateDetectedLanguage(ctx, taskID, &transcript.DetectedLanguage, &transcript.LanguageConfidence); err != nil {
+			zap.S().Errorw("update detected language failed", "task_id", taskID, "error", err)
+		}
+	}
+	return nil
+}
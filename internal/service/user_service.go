@@ -21,6 +21,10 @@ type UserService interface {
 	Delete(ctx context.Context, id int64) error
 
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+
+	GetSTTDefaults(ctx context.Context, id int64) (*model.STTOptions, error)
+	UpdateSTTDefaults(ctx context.Context, id int64, opts *model.STTOptions) error
+	ResolveSTTOptions(ctx context.Context, id int64, requested *model.STTOptions) (*model.STTOptions, error)
 }
 
 type userService struct {
@@ -95,3 +99,28 @@ func (s *userService) Delete(ctx context.Context, id int64) error {
 func (s *userService) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	return s.repo.GetByEmail(ctx, email)
 }
+
+func (s *userService) GetSTTDefaults(ctx context.Context, id int64) (*model.STTOptions, error) {
+	return s.repo.GetSTTDefaults(ctx, id)
+}
+
+func (s *userService) UpdateSTTDefaults(ctx context.Context, id int64, opts *model.STTOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	return s.repo.UpdateSTTDefaults(ctx, id, opts)
+}
+
+// ResolveSTTOptions ghép options theo thứ tự: mặc định hệ thống < defaults của user < request,
+// rồi validate kết quả cuối cùng.
+func (s *userService) ResolveSTTOptions(ctx context.Context, id int64, requested *model.STTOptions) (*model.STTOptions, error) {
+	userDefaults, err := s.repo.GetSTTDefaults(ctx, id)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	opts := model.DefaultSTTOptions().Merge(userDefaults).Merge(requested)
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"video-transcript/internal/model"
+)
+
+// Tham số VAD (ffmpeg silencedetect): dưới vadNoiseDB trong ít nhất vadMinSilenceSec giây là khoảng lặng.
+// Mỗi đoạn tiếng nói được nới thêm vadPaddingSec 2 đầu để không cắt mất phụ âm đầu/cuối từ.
+const (
+	vadNoiseDB       = -35
+	vadMinSilenceSec = 0.4
+	vadPaddingSec    = 0.15
+	vadMinSpeechSec  = 0.05
+)
+
+// speechSegmentsFromSilences lấy phần bù của silences trong [0, duration], nới padding và gộp các đoạn chồng nhau.
+func speechSegmentsFromSilences(silences []Silence, duration float64) []model.SpeechSegment {
+	segments := []model.SpeechSegment{}
+	add := func(start, end float64) {
+		start = max(0, start-vadPaddingSec)
+		end = min(duration, end+vadPaddingSec)
+		if end-start < vadMinSpeechSec {
+			return
+		}
+		if n := len(segments); n > 0 && start <= segments[n-1].End {
+			segments[n-1].End = max(segments[n-1].End, end)
+			return
+		}
+		segments = append(segments, model.SpeechSegment{Start: start, End: end})
+	}
+
+	pos := 0.0
+	for _, s := range silences {
+		if s.Start > pos {
+			add(pos, s.Start)
+		}
+		if s.End > pos {
+			pos = s.End
+		}
+	}
+	if duration > pos {
+		add(pos, duration)
+	}
+	return segments
+}
+
+// ConcatSegments ghép các đoạn segments của audioPath liền nhau thành outPath (MP3 mono 16 kHz),
+// dùng concat demuxer với inpoint/outpoint để không phải dựng filter graph dài với file nhiều đoạn.
+func ConcatSegments(ctx context.Context, audioPath, outPath string, segments []model.SpeechSegment) error {
+	abs, err := filepath.Abs(audioPath)
+	if err != nil {
+		return err
+	}
+	quoted := strings.ReplaceAll(abs, "'", `'\''`)
+
+	var list strings.Builder
+	list.WriteString("ffconcat version 1.0\n")
+	for _, s := range segments {
+		fmt.Fprintf(&list, "file '%s'\ninpoint %s\noutpoint %s\n", quoted, formatSeconds(s.Start), formatSeconds(s.End))
+	}
+	listPath := outPath + ".ffconcat"
+	if err := os.WriteFile(listPath, []byte(list.String()), 0o600); err != nil {
+		return err
+	}
+	defer os.Remove(listPath)
+
+	return runFFmpeg(ctx,
+		"-f", "concat",
+		"-safe", "0",
+		"-i", listPath,
+		"-vn",
+		"-acodec", "libmp3lame",
+		"-b:a", "64k",
+		"-ar", "16000",
+		"-ac", "1",
+		"-y",
+		outPath,
+	)
+}
+
+// remapSpeechTranscript đổi timestamp của transcript trên audio chỉ gồm tiếng nói về timeline của file gốc.
+func remapSpeechTranscript(t *model.SimpleTranscript, speech *model.SpeechMap) {
+	for i := range t.Words {
+		t.Words[i].Start = speech.OriginalTime(t.Words[i].Start, false)
+		t.Words[i].End = speech.OriginalTime(t.Words[i].End, true)
+	}
+	for i := range t.Utterances {
+		t.Utterances[i].Start = speech.OriginalTime(t.Utterances[i].Start, false)
+		t.Utterances[i].End = speech.OriginalTime(t.Utterances[i].End, true)
+	}
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"video-transcript/internal/model"
 	"video-transcript/internal/repository"
+	"video-transcript/internal/uploads"
 )
 
 // VideoService defines business logic for videos.
@@ -14,15 +16,29 @@ type VideoService interface {
 	GetByID(ctx context.Context, id int64) (*model.Video, error)
 	GetVideoByUserIDAndURL(ctx context.Context, userID int64, url string) ([]*model.Video, error)
 	ListVideoByUserID(ctx context.Context, userID int64, limit, offset int, search string) (*model.ListVideoByUserIDResponse, error)
+	GetOrCreateByURL(ctx context.Context, userID int64, url string) (*model.Video, error)
+	// ListDerived trả về các video render từ video videoID (phụ đề, bản cắt, clip, ...), kèm assets.
+	ListDerived(ctx context.Context, videoID int64) ([]*model.Video, error)
+	// ListByRenderTask trả về các video do 1 task tạo ra (render, audiobook), theo thứ tự tạo.
+	ListByRenderTask(ctx context.Context, taskID int64) ([]*model.Video, error)
+	// AttachAssets gắn waveform / poster / sprite (nếu đã sinh) vào các video trước khi trả về API.
+	AttachAssets(ctx context.Context, videos ...*model.Video) error
 }
 
+// ErrSourceNotOwned: file_url trỏ vào object R2 không thuộc user.
+var ErrSourceNotOwned = errors.New("file does not belong to current user")
+
+// assetKinds là các artifact được trả kèm Video trong API.
+var assetKinds = []model.ArtifactKind{model.ArtifactKindWaveform, model.ArtifactKindPoster, model.ArtifactKindSprite, model.ArtifactKindCaptions}
+
 type videoService struct {
-	repo repository.VideoRepository
+	repo         repository.VideoRepository
+	artifactRepo repository.VideoArtifactRepository
 }
 
 // NewVideoService creates a new VideoService.
-func NewVideoService(repo repository.VideoRepository) VideoService {
-	return &videoService{repo: repo}
+func NewVideoService(repo repository.VideoRepository, artifactRepo repository.VideoArtifactRepository) VideoService {
+	return &videoService{repo: repo, artifactRepo: artifactRepo}
 }
 
 func (s *videoService) Create(ctx context.Context, v *model.Video) error {
@@ -38,7 +54,75 @@ func (s *videoService) GetVideoByUserIDAndURL(ctx context.Context, userID int64,
 }
 
 func (s *videoService) ListVideoByUserID(ctx context.Context, userID int64, limit, offset int, search string) (*model.ListVideoByUserIDResponse, error) {
-	return s.repo.ListVideoByUserID(ctx, userID, limit, offset, search)
+	resp, err := s.repo.ListVideoByUserID(ctx, userID, limit, offset, search)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.AttachAssets(ctx, resp.Videos...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *videoService) ListDerived(ctx context.Context, videoID int64) ([]*model.Video, error) {
+	videos, err := s.repo.ListBySourceVideoID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.AttachAssets(ctx, videos...); err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+func (s *videoService) ListByRenderTask(ctx context.Context, taskID int64) ([]*model.Video, error) {
+	return s.repo.ListByRenderTaskID(ctx, taskID)
+}
+
+func (s *videoService) AttachAssets(ctx context.Context, videos ...*model.Video) error {
+	ids := make([]int64, 0, len(videos))
+	for _, v := range videos {
+		ids = append(ids, v.ID)
+	}
+	artifacts, err := s.artifactRepo.ListByVideoIDs(ctx, ids, assetKinds)
+	if err != nil {
+		return err
+	}
+	for _, v := range videos {
+		v.Assets = model.AssetsFromArtifacts(artifacts[v.ID])
+	}
+	return nil
+}
+
+// GetOrCreateByURL trả về video của user có link_video = url, chưa có thì tạo mới
+// (STT cho link ngoài chưa upload qua /api/upload). Chỉ tạo mới cho file trên R2 nằm trong thư mục upload
+// của user (ErrSourceNotOwned) hoặc link ngoài public (ErrUnsafeSourceURL).
+func (s *videoService) GetOrCreateByURL(ctx context.Context, userID int64, url string) (*model.Video, error) {
+	videos, err := s.repo.GetVideoByUserIDAndURL(ctx, userID, url)
+	if err == nil && len(videos) > 0 {
+		return videos[0], nil
+	}
+	if err != nil && err.Error() != "video not found" {
+		return nil, err
+	}
+
+	if key, ok := uploads.ObjectKeyFromURL(url); ok {
+		if !uploads.IsUserUploadKey(userID, key) {
+			return nil, ErrSourceNotOwned
+		}
+	} else if err := CheckSourceURL(ctx, url); err != nil {
+		return nil, err
+	}
+
+	v := &model.Video{
+		UserID:    userID,
+		LinkVideo: url,
+		NameFile:  "",
+	}
+	if err := s.repo.Create(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
 }
 
 func (s *videoService) UpdateDescription(ctx context.Context, id int64, description *string) error {
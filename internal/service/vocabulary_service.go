@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"video-transcript/internal/model"
+	"video-transcript/internal/repository"
+)
+
+// ErrVocabularyForbidden trả về khi user truy cập vocabulary không phải của mình.
+var ErrVocabularyForbidden = errors.New("vocabulary does not belong to current user")
+
+// VocabularyService defines business logic for vocabularies.
+type VocabularyService interface {
+	Create(ctx context.Context, v *model.Vocabulary) error
+	GetForUser(ctx context.Context, id int64, user *model.User) (*model.Vocabulary, error)
+	ListForUser(ctx context.Context, userID int64) ([]*model.Vocabulary, error)
+	Update(ctx context.Context, v *model.Vocabulary, user *model.User) error
+	Delete(ctx context.Context, id int64, user *model.User) error
+}
+
+type vocabularyService struct {
+	repo repository.VocabularyRepository
+}
+
+// NewVocabularyService creates a new VocabularyService.
+func NewVocabularyService(repo repository.VocabularyRepository) VocabularyService {
+	return &vocabularyService{repo: repo}
+}
+
+func (s *vocabularyService) Create(ctx context.Context, v *model.Vocabulary) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, v)
+}
+
+// GetForUser trả về vocabulary nếu user được phép dùng: của chính user, dùng chung, hoặc user là admin.
+func (s *vocabularyService) GetForUser(ctx context.Context, id int64, user *model.User) (*model.Vocabulary, error) {
+	v, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if v.UserID != nil && *v.UserID != user.ID && user.Role != "admin" {
+		return nil, ErrVocabularyForbidden
+	}
+	return v, nil
+}
+
+func (s *vocabularyService) ListForUser(ctx context.Context, userID int64) ([]*model.Vocabulary, error) {
+	return s.repo.ListForUser(ctx, userID)
+}
+
+// Update chỉ cho phép chủ sở hữu sửa; vocabulary dùng chung chỉ admin sửa được.
+func (s *vocabularyService) Update(ctx context.Context, v *model.Vocabulary, user *model.User) error {
+	existing, err := s.repo.GetByID(ctx, v.ID)
+	if err != nil {
+		return err
+	}
+	if !canModifyVocabulary(existing, user) {
+		return ErrVocabularyForbidden
+	}
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	v.UserID = existing.UserID
+	v.CreatedAt = existing.CreatedAt
+	return s.repo.Update(ctx, v)
+}
+
+func (s *vocabularyService) Delete(ctx context.Context, id int64, user *model.User) error {
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !canModifyVocabulary(existing, user) {
+		return ErrVocabularyForbidden
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func canModifyVocabulary(v *model.Vocabulary, user *model.User) bool {
+	if user.Role == "admin" {
+		return true
+	}
+	return v.UserID != nil && *v.UserID == user.ID
+}
+
+const (
+	// maxVocabularyPhraseWords giới hạn số từ liên tiếp được gộp khi so khớp 1 term.
+	maxVocabularyPhraseWords = 4
+	// fuzzyMinLength: term quá ngắn thì chỉ sửa khi khớp chính xác, tránh sửa nhầm.
+	fuzzyMinLength = 5
+	// fuzzyMinSimilarity: độ giống tối thiểu (1 - levenshtein/len) để coi là khớp gần đúng.
+	fuzzyMinSimilarity = 0.85
+)
+
+// vocabularyPattern là 1 cách viết (term hoặc sounds_like) đã chuẩn hoá để so khớp.
+type vocabularyPattern struct {
+	replacement string
+	compact     string // chữ thường, bỏ dấu câu và khoảng trắng
+	words       int
+	fuzzy       bool
+}
+
+// ApplyVocabulary là bước hậu xử lý local: sửa các từ provider nhận sai dựa trên vocabulary.
+// Khớp chính xác với term / sounds_like (kể cả khi bị tách thành nhiều từ), hoặc khớp gần đúng
+// với term theo khoảng cách Levenshtein. Trả về số chỗ đã sửa.
+func ApplyVocabulary(t *model.SimpleTranscript, v *model.Vocabulary) int {
+	if t == nil || v == nil || len(t.Words) == 0 {
+		return 0
+	}
+
+	exact := make(map[string]vocabularyPattern)
+	var fuzzy []vocabularyPattern
+	for _, term := range v.Terms {
+		replacement := strings.TrimSpace(term.Term)
+		candidates := append([]string{replacement}, term.SoundsLike...)
+		for i, candidate := range candidates {
+			compact := compactWord(candidate)
+			if compact == "" {
+				continue
+			}
+			p := vocabularyPattern{
+				replacement: replacement,
+				compact:     compact,
+				words:       len(strings.Fields(candidate)),
+				fuzzy:       i == 0 && len([]rune(compact)) >= fuzzyMinLength,
+			}
+			if _, ok := exact[compact]; !ok {
+				exact[compact] = p
+			}
+			if p.fuzzy {
+				fuzzy = append(fuzzy, p)
+			}
+		}
+	}
+
+	corrected := 0
+	words := make([]model.SimpleWord, 0, len(t.Words))
+	for i := 0; i < len(t.Words); {
+		n, replacement := matchVocabulary(t.Words[i:], exact, fuzzy)
+		if n == 0 {
+			words = append(words, t.Words[i])
+			i++
+			continue
+		}
+
+		first, last := t.Words[i], t.Words[i+n-1]
+		leading, _ := splitPunctuation(first.Word)
+		_, trailing := splitPunctuation(last.Word)
+		// Giữ speaker / language... của từ đầu tiên, chỉ thay text và thời điểm kết thúc.
+		merged := first
+		merged.Word = leading + replacement + trailing
+		merged.End = last.End
+		if n > 1 || merged.Word != first.Word {
+			corrected++
+		}
+		words = append(words, merged)
+		i += n
+	}
+
+	if corrected == 0 {
+		return 0
+	}
+	t.Words = words
+	rebuildTranscriptText(t)
+	return corrected
+}
+
+// matchVocabulary tìm pattern khớp bắt đầu từ words[0] (khớp chính xác: cụm dài nhất; gần đúng: cụm
+// giống nhất), trả về số từ bị thay thế.
+func matchVocabulary(words []model.SimpleWord, exact map[string]vocabularyPattern, fuzzy []vocabularyPattern) (int, string) {
+	maxN := maxVocabularyPhraseWords
+	if len(words) < maxN {
+		maxN = len(words)
+	}
+
+	var window strings.Builder
+	compacts := make([]string, 0, maxN)
+	for n := 1; n <= maxN; n++ {
+		window.WriteString(compactWord(words[n-1].Word))
+		compacts = append(compacts, window.String())
+	}
+
+	// Ưu tiên khớp chính xác, cụm dài trước.
+	for n := maxN; n >= 1; n-- {
+		if compacts[n-1] == "" {
+			continue
+		}
+		if p, ok := exact[compacts[n-1]]; ok {
+			return n, p.replacement
+		}
+	}
+
+	// Khớp gần đúng: chỉ xét cửa sổ có số từ không vượt quá số từ của term + 1. Cửa sổ ngắn trước,
+	// cửa sổ dài hơn chỉ thay khi giống term hơn hẳn, tránh nuốt luôn từ phía sau term.
+	bestN, bestSim, best := 0, 0.0, ""
+	for n := 1; n <= maxN; n++ {
+		candidate := compacts[n-1]
+		if candidate == "" {
+			continue
+		}
+		for _, p := range fuzzy {
+			if n > p.words+1 {
+				continue
+			}
+			sim := similarity(candidate, p.compact)
+			if sim >= fuzzyMinSimilarity && sim > bestSim {
+				bestN, bestSim, best = n, sim, p.replacement
+			}
+		}
+	}
+	return bestN, best
+}
+
+// rebuildTranscriptText dựng lại transcript text và text từng utterance từ danh sách words.
+func rebuildTranscriptText(t *model.SimpleTranscript) {
+	all := make([]string, 0, len(t.Words))
+	for _, w := range t.Words {
+		all = append(all, w.Word)
+	}
+	t.TranscriptText = strings.Join(all, " ")
+
+	const epsilon = 0.001
+	for i := range t.Utterances {
+		utt := &t.Utterances[i]
+		var parts []string
+		for _, w := range t.Words {
+			if w.Start >= utt.Start-epsilon && w.End <= utt.End+epsilon {
+				parts = append(parts, w.Word)
+			}
+		}
+		if len(parts) > 0 {
+			utt.Transcript = strings.Join(parts, " ")
+		}
+	}
+}
+
+// compactWord chuẩn hoá để so khớp: chữ thường, chỉ giữ chữ và số.
+func compactWord(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// splitPunctuation tách dấu câu ở đầu và cuối 1 từ (vd: `"Lipitor,` -> `"`, `,`).
+func splitPunctuation(word string) (string, string) {
+	runes := []rune(word)
+	start, end := 0, len(runes)
+	for start < end && !unicode.IsLetter(runes[start]) && !unicode.IsDigit(runes[start]) {
+		start++
+	}
+	for end > start && !unicode.IsLetter(runes[end-1]) && !unicode.IsDigit(runes[end-1]) {
+		end--
+	}
+	return string(runes[:start]), string(runes[end:])
+}
+
+// similarity = 1 - levenshtein(a, b) / max(len(a), len(b)).
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	// Lệch độ dài quá nhiều thì chắc chắn không đạt ngưỡng, bỏ qua cho nhanh.
+	diff := len(ra) - len(rb)
+	if diff < 0 {
+		diff = -diff
+	}
+	if float64(diff)/float64(longest) > 1-fuzzyMinSimilarity {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
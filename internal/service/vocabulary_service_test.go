@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"video-transcript/internal/model"
+)
+
+// transcriptOf dựng transcript từ text, mỗi từ dài 1 giây.
+func transcriptOf(text string) *model.SimpleTranscript {
+	t := &model.SimpleTranscript{TranscriptText: text}
+	for i, w := range strings.Fields(text) {
+		t.Words = append(t.Words, model.SimpleWord{Word: w, Start: float64(i), End: float64(i + 1)})
+	}
+	return t
+}
+
+func TestApplyVocabulary(t *testing.T) {
+	vocabulary := &model.Vocabulary{Terms: model.VocabularyTerms{
+		{Term: "hydrochlorothiazide"},
+		{Term: "Lipitor", SoundsLike: []string{"lip it or"}},
+		{Term: "CBC"},
+	}}
+
+	tests := []struct {
+		name      string
+		text      string
+		want      string
+		corrected int
+	}{
+		{"no match", "take aspirin daily", "take aspirin daily", 0},
+		{"exact match keeps punctuation", "take lipitor, daily", "take Lipitor, daily", 1},
+		{"sounds like across words", "take lip it or daily", "take Lipitor daily", 1},
+		{"term split into words", "take hydro chlorothiazide daily", "take hydrochlorothiazide daily", 1},
+		{"fuzzy match single word", "take hydrochlorothiazid daily", "take hydrochlorothiazide daily", 1},
+		{"fuzzy match does not swallow next word", "take hydrochlorothiazid is daily", "take hydrochlorothiazide is daily", 1},
+		{"short term exact only", "order a CBD test", "order a CBD test", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transcript := transcriptOf(tt.text)
+			corrected := ApplyVocabulary(transcript, vocabulary)
+			if corrected != tt.corrected {
+				t.Errorf("corrected = %d, want %d", corrected, tt.corrected)
+			}
+			if transcript.TranscriptText != tt.want {
+				t.Errorf("text = %q, want %q", transcript.TranscriptText, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyVocabularyKeepsWordTiming(t *testing.T) {
+	transcript := transcriptOf("take lip it or daily")
+	ApplyVocabulary(transcript, &model.Vocabulary{Terms: model.VocabularyTerms{{Term: "Lipitor", SoundsLike: []string{"lip it or"}}}})
+
+	got := transcript.Words[1]
+	if got.Word != "Lipitor" || got.Start != 1 || got.End != 4 {
+		t.Errorf("merged word = %+v, want Lipitor [1, 4]", got)
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Waveform được tính trên PCM mono 8 kHz; mỗi zoom level là số sample gộp thành 1 điểm (min/max).
+// 256 sample/điểm ~ 31 điểm/giây, các level sau gộp lại từ level trước nên phải là bội số của nhau.
+const waveformSampleRate = 8000
+
+var waveformLevels = []int{256, 1024, 4096}
+
+// WaveformLevel là peak data ở 1 mức zoom: Data gồm cặp (min, max) 8-bit cho mỗi điểm.
+type WaveformLevel struct {
+	SamplesPerPixel int    `json:"samples_per_pixel"`
+	Length          int    `json:"length"`
+	Data            []int8 `json:"data"`
+}
+
+// Waveform là JSON peak data trả cho web player (tương tự format của audiowaveform).
+type Waveform struct {
+	Version     int             `json:"version"`
+	Channels    int             `json:"channels"`
+	SampleRate  int             `json:"sample_rate"`
+	Bits        int             `json:"bits"`
+	DurationSec float64         `json:"duration_sec"`
+	Levels      []WaveformLevel `json:"levels"`
+}
+
+// GenerateWaveform decode audio của inputPath thành PCM (file tạm pcmPath) rồi tính peak data ở các mức zoom.
+func GenerateWaveform(ctx context.Context, inputPath, pcmPath string) (*Waveform, error) {
+	if err := runFFmpeg(ctx,
+		"-i", inputPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le",
+		"-y",
+		pcmPath,
+	); err != nil {
+		return nil, fmt.Errorf("decode audio: %w", err)
+	}
+	defer os.Remove(pcmPath)
+
+	f, err := os.Open(pcmPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return computeWaveform(bufio.NewReader(f), waveformSampleRate, waveformLevels)
+}
+
+// computeWaveform đọc PCM s16le mono từ r và tính (min, max) cho mỗi nhóm levels[i] sample.
+func computeWaveform(r io.Reader, sampleRate int, levels []int) (*Waveform, error) {
+	base := levels[0]
+	finest := WaveformLevel{SamplesPerPixel: base}
+
+	var (
+		buf        [2]byte
+		total      int
+		n          int
+		minV, maxV int16
+	)
+	flush := func() {
+		finest.Data = append(finest.Data, int8(minV>>8), int8(maxV>>8))
+		n, minV, maxV = 0, 0, 0
+	}
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+		v := int16(binary.LittleEndian.Uint16(buf[:]))
+		if n == 0 || v < minV {
+			minV = v
+		}
+		if n == 0 || v > maxV {
+			maxV = v
+		}
+		n++
+		total++
+		if n == base {
+			flush()
+		}
+	}
+	if n > 0 {
+		flush()
+	}
+	finest.Length = len(finest.Data) / 2
+
+	w := &Waveform{
+		Version:     2,
+		Channels:    1,
+		SampleRate:  sampleRate,
+		Bits:        8,
+		DurationSec: float64(total) / float64(sampleRate),
+		Levels:      []WaveformLevel{finest},
+	}
+	for _, spp := range levels[1:] {
+		w.Levels = append(w.Levels, downsampleLevel(finest, spp))
+	}
+	return w, nil
+}
+
+// downsampleLevel gộp các điểm của level mịn hơn thành level spp sample/điểm.
+func downsampleLevel(src WaveformLevel, spp int) WaveformLevel {
+	group := spp / src.SamplesPerPixel
+	out := WaveformLevel{SamplesPerPixel: spp}
+	for i := 0; i < src.Length; i += group {
+		end := min(i+group, src.Length)
+		minV, maxV := src.Data[2*i], src.Data[2*i+1]
+		for j := i + 1; j < end; j++ {
+			minV = min(minV, src.Data[2*j])
+			maxV = max(maxV, src.Data[2*j+1])
+		}
+		out.Data = append(out.Data, minV, maxV)
+	}
+	out.Length = len(out.Data) / 2
+	return out
+}
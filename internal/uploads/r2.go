@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"video-transcript/internal/config"
 
@@ -111,3 +112,81 @@ func UploadToR2(ctx context.Context, key string, body io.Reader, size int64, con
 
 	return fmt.Sprintf("%s/%s", baseURL, key), nil
 }
+
+// ObjectKeyFromURL trả về key trên R2 của URL do UploadToR2 trả về.
+// ok = false nếu URL không nằm trên bucket (vd: link ngoài do user nhập).
+func ObjectKeyFromURL(url string) (string, bool) {
+	baseURL := strings.TrimSuffix(config.SvcCfg.AWS_BASE_URL, "/")
+	if baseURL == "" {
+		// UploadToR2 chỉ trả về key khi không cấu hình base URL.
+		if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+			return "", false
+		}
+		return url, url != ""
+	}
+	if !strings.HasPrefix(url, baseURL+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(url, baseURL+"/"), true
+}
+
+// UserUploadKey trả về key R2 cho file user upload: uploads/<userID>/<name>.
+func UserUploadKey(userID int64, name string) string {
+	return fmt.Sprintf("uploads/%d/%s", userID, name)
+}
+
+// IsUserUploadKey cho biết key có nằm dưới thư mục upload của user không.
+func IsUserUploadKey(userID int64, key string) bool {
+	prefix := UserUploadKey(userID, "")
+	return strings.HasPrefix(key, prefix) && !strings.Contains(key[len(prefix):], "..")
+}
+
+// DownloadFromR2 ghi object có key tương ứng vào w, trả về số byte đã ghi.
+func DownloadFromR2(ctx context.Context, key string, w io.Writer) (int64, error) {
+	if r2Client == nil {
+		zap.S().Error("R2 client is not initialized")
+		return 0, fmt.Errorf("R2 client is not initialized")
+	}
+
+	out, err := r2Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(config.SvcCfg.BUCKET_KEY),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		zap.S().Errorw("download from R2 failed",
+			"error", err,
+			"bucket", config.SvcCfg.BUCKET_KEY,
+			"key", key,
+		)
+		return 0, fmt.Errorf("download from R2: %w", err)
+	}
+	defer out.Body.Close()
+
+	n, err := io.Copy(w, out.Body)
+	if err != nil {
+		return n, fmt.Errorf("read R2 object: %w", err)
+	}
+	return n, nil
+}
+
+// DeleteFromR2 xoá object có key tương ứng (xoá key không tồn tại không báo lỗi).
+func DeleteFromR2(ctx context.Context, key string) error {
+	if r2Client == nil {
+		zap.S().Error("R2 client is not initialized")
+		return fmt.Errorf("R2 client is not initialized")
+	}
+
+	_, err := r2Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(config.SvcCfg.BUCKET_KEY),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		zap.S().Errorw("delete from R2 failed",
+			"error", err,
+			"bucket", config.SvcCfg.BUCKET_KEY,
+			"key", key,
+		)
+		return fmt.Errorf("delete from R2: %w", err)
+	}
+	return nil
+}
@@ -17,6 +17,8 @@ CREATE TABLE IF NOT EXISTS users (
 
     credit INT NOT NULL DEFAULT 0,
 
+    stt_defaults JSONB,               -- STT options mặc định của user
+
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
@@ -30,10 +32,36 @@ CREATE TABLE IF NOT EXISTS videos (
     name_file TEXT NOT NULL,          -- tên file gốc (vd: myvideo.mp4)
     description TEXT,                 -- mô tả video
 
+    -- thông tin kỹ thuật từ ffprobe (NULL = chưa probe)
+    duration_sec FLOAT,
+    container VARCHAR(64),            -- format_name của ffprobe (vd: mov,mp4,m4a,3gp,3g2,mj2)
+    video_codec VARCHAR(32),          -- NULL = file chỉ có audio
+    audio_codec VARCHAR(32),
+    audio_channels INT,
+    sample_rate INT,
+    width INT,
+    height INT,
+    bit_rate BIGINT,
+    size_bytes BIGINT,
+    probed_at TIMESTAMP,
+
+    -- HLS cho web player
+    transcode_status VARCHAR(10),      -- pending / processing / completed / failed, NULL = chưa transcode
+    transcode_error TEXT,
+    hls_url TEXT,                      -- master playlist trên R2
+
+    -- video dẫn xuất (render phụ đề, cắt ghép): NULL = video upload gốc
+    source_video_id BIGINT,
+    source_task_id BIGINT,             -- task transcript dùng để render
+    render_task_id BIGINT,             -- task render đã tạo ra video
+
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
+CREATE INDEX IF NOT EXISTS idx_videos_source_video_id ON videos (source_video_id);
+CREATE INDEX IF NOT EXISTS idx_videos_render_task_id ON videos (render_task_id);
+
 CREATE TABLE tasks (
     id              BIGSERIAL PRIMARY KEY,
 
@@ -50,6 +78,15 @@ CREATE TABLE tasks (
     duration_sec    FLOAT,
     error_message   TEXT,
     user_id         BIGINT,
+    options         JSONB,            -- options đã resolve của job (model, language, redact, ...)
+
+    detected_language   VARCHAR(16),  -- ngôn ngữ provider nhận diện được (STT)
+    language_confidence FLOAT,
+    provider        VARCHAR(32),      -- speech provider đã phục vụ task (deepgram, deepgram-secondary, ...)
+    video_id        BIGINT,           -- video nguồn (STT)
+    audio_url       TEXT,             -- audio đã tách bởi media pipeline, gửi cho STT
+    progress_current INT,             -- số chunk đã transcribe xong (file dài cắt chunk)
+    progress_total   INT,             -- tổng số chunk
 
     created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at      TIMESTAMP NOT NULL DEFAULT NOW()
@@ -80,3 +117,112 @@ CREATE TABLE tasks (
 --     ADD COLUMN IF NOT EXISTS address TEXT;
 
 
+
+-- tạo bảng vocabularies (custom vocabulary / keyterm boosting cho STT)
+CREATE TABLE IF NOT EXISTS vocabularies (
+    id BIGSERIAL PRIMARY KEY,
+
+    user_id BIGINT,                   -- NULL = vocabulary dùng chung (admin quản lý)
+    name VARCHAR(255) NOT NULL,
+    terms JSONB NOT NULL DEFAULT '[]', -- [{term, boost, sounds_like}]
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vocabularies_user_id ON vocabularies (user_id);
+
+
+
+-- tạo bảng provider_keys (pool API key của speech provider, key được mã hoá)
+CREATE TABLE IF NOT EXISTS provider_keys (
+    id BIGSERIAL PRIMARY KEY,
+
+    provider VARCHAR(32) NOT NULL DEFAULT 'deepgram', -- tên provider trong speech router
+    name VARCHAR(255) NOT NULL,
+    key_encrypted TEXT NOT NULL,                      -- base64(nonce || AES-GCM ciphertext)
+    key_hint VARCHAR(16) NOT NULL,                    -- 4 ký tự cuối của key
+
+    weight INT NOT NULL DEFAULT 1,                    -- trọng số weighted round-robin
+    quota_limit BIGINT,                               -- số request tối đa, NULL = không giới hạn
+    usage_count BIGINT NOT NULL DEFAULT 0,
+
+    status VARCHAR(16) NOT NULL DEFAULT 'active',     -- active / disabled
+    disabled_reason TEXT,
+    cooldown_until TIMESTAMP,                         -- key bị 429 tạm bỏ qua tới thời điểm này
+    last_used_at TIMESTAMP,
+    last_error TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_keys_provider_status ON provider_keys (provider, status);
+
+
+-- tạo bảng video_artifacts (file dẫn xuất từ video: audio đã tách, ...)
+CREATE TABLE IF NOT EXISTS video_artifacts (
+    id BIGSERIAL PRIMARY KEY,
+
+    video_id BIGINT NOT NULL,          -- video gốc
+    kind VARCHAR(64) NOT NULL,         -- loại artifact (audio, audio_<profile>, waveform, poster, sprite, ...)
+    url TEXT NOT NULL,                 -- URL trên R2
+    storage_key TEXT NOT NULL,         -- key trên R2 (cạnh video gốc)
+    content_type VARCHAR(128) NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    duration_sec FLOAT,                -- thời lượng (artifact audio)
+    meta JSONB,                        -- thông tin thêm theo kind (vd: layout sprite sheet)
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE (video_id, kind)
+);
+
+-- tạo bảng video_speech_maps (kết quả VAD: các đoạn có tiếng nói của video)
+CREATE TABLE IF NOT EXISTS video_speech_maps (
+    video_id BIGINT PRIMARY KEY,       -- video gốc
+
+    duration_sec FLOAT NOT NULL,       -- thời lượng audio đã phân tích
+    speech_sec FLOAT NOT NULL,         -- tổng thời lượng có tiếng nói
+    noise_db FLOAT NOT NULL,           -- ngưỡng silencedetect
+    min_silence_sec FLOAT NOT NULL,    -- khoảng lặng ngắn hơn ngưỡng này vẫn tính là tiếng nói
+    segments JSONB NOT NULL,           -- [{start, end}, ...] các đoạn có tiếng nói
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- tạo bảng tts_lexicon_entries (lexicon phát âm của user cho text-to-speech)
+CREATE TABLE IF NOT EXISTS tts_lexicon_entries (
+    id BIGSERIAL PRIMARY KEY,
+
+    user_id BIGINT NOT NULL,
+    word VARCHAR(255) NOT NULL,                     -- từ / cụm từ cần đọc khác đi
+    replacement TEXT NOT NULL,                      -- cách viết phiên âm hoặc alias gửi provider
+    kind VARCHAR(16) NOT NULL DEFAULT 'alias',      -- respelling / alias
+    language VARCHAR(8),                            -- chỉ áp dụng cho voice ngôn ngữ này, NULL = mọi ngôn ngữ
+    match_case BOOLEAN NOT NULL DEFAULT FALSE,      -- chỉ khớp đúng hoa / thường
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tts_lexicon_entries_user_id ON tts_lexicon_entries (user_id);
+
+-- tạo bảng tts_cache_entries (cache audio text-to-speech theo hash nội dung)
+CREATE TABLE IF NOT EXISTS tts_cache_entries (
+    id BIGSERIAL PRIMARY KEY,
+
+    cache_key CHAR(64) NOT NULL UNIQUE,             -- sha256 hex của text đã chuẩn hoá + voice + định dạng + scope
+    scope VARCHAR(10) NOT NULL,                     -- user / shared
+    user_id BIGINT NOT NULL,                        -- user đã tạo audio
+    url TEXT NOT NULL,                              -- object R2 của audio
+    content_type VARCHAR(64) NOT NULL,
+    duration_sec DOUBLE PRECISION,
+    provider VARCHAR(32),                           -- provider đã tổng hợp audio
+
+    hit_count BIGINT NOT NULL DEFAULT 0,
+    last_hit_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
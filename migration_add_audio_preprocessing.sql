@@ -0,0 +1,7 @@
+-- Migration: profile tiền xử lý audio (loudnorm, khử nhiễu, cắt khoảng lặng)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm: video_artifacts.duration_sec
+
+ALTER TABLE video_artifacts ADD COLUMN IF NOT EXISTS duration_sec FLOAT;
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added video_artifacts.duration_sec' AS status;
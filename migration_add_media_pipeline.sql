@@ -0,0 +1,24 @@
+-- Migration: media pipeline (tách audio bằng ffmpeg trước khi gửi STT)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm: bảng video_artifacts, cột tasks.video_id, tasks.audio_url
+
+CREATE TABLE IF NOT EXISTS video_artifacts (
+    id BIGSERIAL PRIMARY KEY,
+
+    video_id BIGINT NOT NULL,          -- video gốc
+    kind VARCHAR(64) NOT NULL,         -- loại artifact (audio, ...)
+    url TEXT NOT NULL,                 -- URL trên R2
+    storage_key TEXT NOT NULL,         -- key trên R2 (cạnh video gốc)
+    content_type VARCHAR(128) NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE (video_id, kind)
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS video_id BIGINT;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS audio_url TEXT;
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added video_artifacts, tasks.video_id, tasks.audio_url' AS status;
@@ -0,0 +1,29 @@
+-- Migration: pool API key của speech provider (mã hoá bằng PROVIDER_KEY_SECRET)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm bảng: provider_keys
+
+CREATE TABLE IF NOT EXISTS provider_keys (
+    id BIGSERIAL PRIMARY KEY,
+
+    provider VARCHAR(32) NOT NULL DEFAULT 'deepgram', -- tên provider trong speech router
+    name VARCHAR(255) NOT NULL,
+    key_encrypted TEXT NOT NULL,                      -- base64(nonce || AES-GCM ciphertext)
+    key_hint VARCHAR(16) NOT NULL,                    -- 4 ký tự cuối của key
+
+    weight INT NOT NULL DEFAULT 1,                    -- trọng số weighted round-robin
+    quota_limit BIGINT,                               -- số request tối đa, NULL = không giới hạn
+    usage_count BIGINT NOT NULL DEFAULT 0,
+
+    status VARCHAR(16) NOT NULL DEFAULT 'active',     -- active / disabled
+    disabled_reason TEXT,
+    cooldown_until TIMESTAMP,                         -- key bị 429 tạm bỏ qua tới thời điểm này
+    last_used_at TIMESTAMP,
+    last_error TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_keys_provider_status ON provider_keys (provider, status);
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added provider_keys table' AS status;
@@ -0,0 +1,18 @@
+-- Migration: VAD / speech map của video (đoạn có tiếng nói, khoảng lặng)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm: bảng video_speech_maps
+
+CREATE TABLE IF NOT EXISTS video_speech_maps (
+    video_id BIGINT PRIMARY KEY,       -- video gốc
+
+    duration_sec FLOAT NOT NULL,       -- thời lượng audio đã phân tích
+    speech_sec FLOAT NOT NULL,         -- tổng thời lượng có tiếng nói
+    noise_db FLOAT NOT NULL,           -- ngưỡng silencedetect
+    min_silence_sec FLOAT NOT NULL,    -- khoảng lặng ngắn hơn ngưỡng này vẫn tính là tiếng nói
+    segments JSONB NOT NULL,           -- [{start, end}, ...] các đoạn có tiếng nói
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added video_speech_maps' AS status;
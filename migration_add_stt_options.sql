@@ -0,0 +1,11 @@
+-- Migration: STT options theo từng request + defaults theo user
+-- Chạy file này nếu database đã có dữ liệu và cần thêm các cột: tasks.options, users.stt_defaults
+
+-- Options đã resolve của mỗi task (để chạy lại job với đúng cấu hình)
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS options JSONB;
+
+-- STT options mặc định do user tự lưu
+ALTER TABLE users ADD COLUMN IF NOT EXISTS stt_defaults JSONB;
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added tasks.options, users.stt_defaults' AS status;
@@ -0,0 +1,8 @@
+-- Migration: lưu ngôn ngữ nhận diện được của task STT
+-- Chạy file này nếu database đã có dữ liệu và cần thêm các cột: detected_language, language_confidence
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS detected_language VARCHAR(16);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS language_confidence FLOAT;
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added tasks.detected_language, tasks.language_confidence' AS status;
@@ -0,0 +1,8 @@
+-- Migration: tiến độ task (transcribe file dài theo chunk song song)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm: tasks.progress_current, tasks.progress_total
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS progress_current INT;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS progress_total INT;
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added tasks.progress_current, tasks.progress_total' AS status;
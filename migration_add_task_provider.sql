@@ -0,0 +1,7 @@
+-- Migration: ghi lại speech provider đã phục vụ mỗi task (failover / circuit breaker)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm cột: provider
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS provider VARCHAR(32);
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added tasks.provider' AS status;
@@ -0,0 +1,21 @@
+-- Migration: cache audio text-to-speech theo hash nội dung
+-- Chạy file này nếu database đã có dữ liệu và cần thêm bảng tts_cache_entries
+
+CREATE TABLE IF NOT EXISTS tts_cache_entries (
+    id BIGSERIAL PRIMARY KEY,
+
+    cache_key CHAR(64) NOT NULL UNIQUE,             -- sha256 hex của text đã chuẩn hoá + voice + định dạng + scope
+    scope VARCHAR(10) NOT NULL,                     -- user / shared
+    user_id BIGINT NOT NULL,                        -- user đã tạo audio
+    url TEXT NOT NULL,                              -- object R2 của audio
+    content_type VARCHAR(64) NOT NULL,
+    duration_sec DOUBLE PRECISION,
+    provider VARCHAR(32),                           -- provider đã tổng hợp audio
+
+    hit_count BIGINT NOT NULL DEFAULT 0,
+    last_hit_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added tts_cache_entries table' AS status;
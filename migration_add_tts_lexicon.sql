@@ -0,0 +1,21 @@
+-- Migration: lexicon phát âm cho text-to-speech
+-- Chạy file này nếu database đã có dữ liệu và cần thêm bảng tts_lexicon_entries
+
+CREATE TABLE IF NOT EXISTS tts_lexicon_entries (
+    id BIGSERIAL PRIMARY KEY,
+
+    user_id BIGINT NOT NULL,
+    word VARCHAR(255) NOT NULL,                     -- từ / cụm từ cần đọc khác đi
+    replacement TEXT NOT NULL,                      -- cách viết phiên âm hoặc alias gửi provider
+    kind VARCHAR(16) NOT NULL DEFAULT 'alias',      -- respelling / alias
+    language VARCHAR(8),                            -- chỉ áp dụng cho voice ngôn ngữ này, NULL = mọi ngôn ngữ
+    match_case BOOLEAN NOT NULL DEFAULT FALSE,      -- chỉ khớp đúng hoa / thường
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tts_lexicon_entries_user_id ON tts_lexicon_entries (user_id);
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added tts_lexicon_entries table' AS status;
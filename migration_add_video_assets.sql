@@ -0,0 +1,7 @@
+-- Migration: asset cho web player (waveform, poster, sprite sheet)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm: video_artifacts.meta
+
+ALTER TABLE video_artifacts ADD COLUMN IF NOT EXISTS meta JSONB;
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added video_artifacts.meta' AS status;
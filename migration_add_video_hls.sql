@@ -0,0 +1,9 @@
+-- Migration: transcode HLS (adaptive streaming) cho video
+-- Chạy file này nếu database đã có dữ liệu và cần thêm: videos.transcode_status, videos.transcode_error, videos.hls_url
+
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS transcode_status VARCHAR(10);
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS transcode_error TEXT;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS hls_url TEXT;
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added videos.transcode_status, videos.transcode_error, videos.hls_url' AS status;
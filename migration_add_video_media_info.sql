@@ -0,0 +1,18 @@
+-- Migration: thông tin kỹ thuật của video (ffprobe)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm cột: duration_sec, container, codec, ...
+
+ALTER TABLE videos
+    ADD COLUMN IF NOT EXISTS duration_sec FLOAT,
+    ADD COLUMN IF NOT EXISTS container VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS video_codec VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS audio_codec VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS audio_channels INT,
+    ADD COLUMN IF NOT EXISTS sample_rate INT,
+    ADD COLUMN IF NOT EXISTS width INT,
+    ADD COLUMN IF NOT EXISTS height INT,
+    ADD COLUMN IF NOT EXISTS bit_rate BIGINT,
+    ADD COLUMN IF NOT EXISTS size_bytes BIGINT,
+    ADD COLUMN IF NOT EXISTS probed_at TIMESTAMP;
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added video media info columns' AS status;
@@ -0,0 +1,9 @@
+-- Migration: liên kết video dẫn xuất với task render đã tạo ra nó (1 task clip có thể tạo nhiều video)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm: videos.render_task_id, index videos.source_video_id
+
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS render_task_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_videos_source_video_id ON videos (source_video_id);
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added videos.render_task_id, idx_videos_source_video_id' AS status;
@@ -0,0 +1,7 @@
+-- Migration: index videos.render_task_id (audiobook liệt kê các chương MP3 + file M4B theo task)
+-- Chạy file này nếu database đã có bảng videos với cột render_task_id
+
+CREATE INDEX IF NOT EXISTS idx_videos_render_task_id ON videos (render_task_id);
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added idx_videos_render_task_id' AS status;
@@ -0,0 +1,8 @@
+-- Migration: video dẫn xuất được render từ video khác (burn-in phụ đề, ...)
+-- Chạy file này nếu database đã có dữ liệu và cần thêm: videos.source_video_id, videos.source_task_id
+
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS source_video_id BIGINT;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS source_task_id BIGINT;
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added videos.source_video_id, videos.source_task_id' AS status;
@@ -0,0 +1,18 @@
+-- Migration: custom vocabulary cho STT
+-- Chạy file này nếu database đã có dữ liệu và cần thêm bảng vocabularies
+
+CREATE TABLE IF NOT EXISTS vocabularies (
+    id BIGSERIAL PRIMARY KEY,
+
+    user_id BIGINT,                   -- NULL = vocabulary dùng chung (admin quản lý)
+    name VARCHAR(255) NOT NULL,
+    terms JSONB NOT NULL DEFAULT '[]', -- [{term, boost, sounds_like}]
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vocabularies_user_id ON vocabularies (user_id);
+
+-- Xác nhận migration hoàn tất
+SELECT 'Migration completed: Added vocabularies table' AS status;